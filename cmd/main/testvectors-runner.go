@@ -10,9 +10,24 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
 
+	"github.com/stratum/testvectors-runner/pkg/framework/alarm"
+	"github.com/stratum/testvectors-runner/pkg/framework/dataplane"
+	"github.com/stratum/testvectors-runner/pkg/framework/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/framework/p4rt"
 	"github.com/stratum/testvectors-runner/pkg/logger"
+	"github.com/stratum/testvectors-runner/pkg/orchestrator/action"
+	"github.com/stratum/testvectors-runner/pkg/orchestrator/expectation"
+	"github.com/stratum/testvectors-runner/pkg/orchestrator/testvector"
 	"github.com/stratum/testvectors-runner/pkg/test"
+	"github.com/stratum/testvectors-runner/pkg/test/teardown"
+	"github.com/stratum/testvectors-runner/pkg/utils/p4info"
+	"github.com/stratum/testvectors-runner/pkg/utils/schema"
+	"github.com/stratum/testvectors-runner/pkg/utils/transport"
 )
 
 var log = logger.NewLogger()
@@ -29,14 +44,43 @@ func main() {
 	pmFile := flag.String("portmap", "", "Path to the portmap file")
 	dpMode := flag.String("dp-mode", "direct", "Data plane mode: 'direct' or 'loopback'")
 	matchType := flag.String("match-type", "exact", "Data plane match type: 'exact' or 'in'")
+	pktOutPortMetadata := flag.String("pkt-out-port-metadata", "", "Packet-out metadata carrying the egress port in loopback mode as <id>:<bitwidth>, derived from P4Info if empty")
+	pktInPortMetadata := flag.String("pkt-in-port-metadata", "", "Packet-in metadata carrying the ingress port in loopback mode as <id>:<bitwidth>, derived from P4Info if empty")
+	pktOutPadding := flag.String("pkt-out-padding", "", "Other packet-out metadata set to zero in loopback mode as <id>:<bitwidth>,..., 'none' or derived from P4Info if empty")
+	pktInPadding := flag.String("pkt-in-padding", "", "Other packet-in metadata expected to be zero in loopback mode as <id>:<bitwidth>,..., 'none' or derived from P4Info if empty")
+	ignoreMetadataIDs := flag.String("ignore-metadata-ids", "", "Packet-in metadata IDs not compared when verifying packet-ins, separated by comma")
+	portMode := flag.String("port-mode", "gnmi", "Port stimulus mode: 'gnmi' or 'link'")
+	alarmMode := flag.String("alarm-mode", "gnmi", "Alarm stimulus mode: 'gnmi' or 'exec'")
+	alarmSetLeaf := flag.String("alarm-set-leaf", "", "Leaf relative to the alarm path which is set to true to raise the alarm in gnmi alarm mode")
+	alarmHook := flag.String("alarm-hook", "", "Command executed with the alarm path as argument to raise the alarm in exec alarm mode")
+	tlsEnabled := flag.Bool("tls", false, "Connect to the target with TLS, implied by the other TLS flags, --tls=false connects without TLS")
+	caCert := flag.String("ca-cert", "", "CA certificate to verify the target certificate, system CA certificates are used if empty")
+	clientCert := flag.String("client-cert", "", "Client certificate for mutual TLS")
+	clientKey := flag.String("client-key", "", "Client key for mutual TLS")
+	tlsServerName := flag.String("tls-server-name", "", "Server name to verify the target certificate against")
+	failurePolicy := flag.String("failure-policy", "skip-expectations", "Failure policy: 'skip-expectations' of test cases whose actions failed, 'continue' and report all failures or 'stop' each test case at its first failure")
+	reconnectTimeout := flag.Duration("p4rt-reconnect-timeout", time.Minute, "How long to try reopening a broken P4Runtime stream channel, 0 disables reconnecting")
+	p4rtCleanup := flag.Bool("p4rt-cleanup", false, "Reset the P4Runtime state to the baseline after each test case")
 	logDir := flag.String("log-dir", "/tmp", "Location to store logs")
 	logLevel := flag.String("log-level", "warn", "Log Level")
 	templateConfig := flag.String("template-config", "", "Path to template config file")
+	p4infoFile := flag.String("p4info", "", "Path to the P4Info file used to resolve P4 names in Test Vectors")
+	randomSeed := flag.Int64("random-seed", 0, "Seed for randomized action groups, 0 generates a new seed")
+	maxConcurrency := flag.Int("parallel-max-concurrency", 0, "Maximum number of actions running at once in a parallel action group, 0 means no limit")
+	barrier := flag.Bool("parallel-barrier", false, "Release all actions in a parallel action group at the same instant")
+	startSkew := flag.Duration("parallel-start-skew", 0, "Delay between the starts of consecutive actions in a parallel action group, the i-th action starts i times the delay after the start of the group")
+	pollInterval := flag.Duration("poll-interval", time.Second, "Interval between requests of polled expectations")
+	pollDeadline := flag.Duration("poll-deadline", 0, "Deadline for gNMI Get, P4Runtime Read and pipeline config expectations to match, 0 disables polling")
+	gnmiMatchType := flag.String("gnmi-match-type", "exact", "gNMI subscription match type: 'exact' or 'in'")
+	sampleTolerance := flag.Float64("gnmi-sample-tolerance", 0.2, "Deviation from the sample interval allowed between samples of gNMI SAMPLE subscriptions, relative to the interval")
+	gnmiSchema := flag.String("gnmi-schema", "", "Compiled schema used to validate gNMI paths and values of Test Vectors before running them, empty disables validation")
+	gnmiYangDir := flag.String("gnmi-yang-dir", "", "Directory of the YANG modules used to validate gNMI paths of Test Vectors before running them, empty disables validation")
 
 	help := flag.Bool("help", false, "Help")
 	h := flag.Bool("h", false, "Help")
-	//Add -test.v to list of arguments for verbose go test output
+	//Add -test.v to list of arguments for verbose go test output, the test flags are registered by testing.Init
 	os.Args = append(os.Args, "-test.v")
+	testing.Init()
 
 	flag.Parse()
 	flag.Usage = usage
@@ -51,8 +95,93 @@ func main() {
 	}
 
 	setupLog(*logDir, *logLevel)
+	tlsOptions := transport.TLSOptions{CACert: *caCert, ClientCert: *clientCert, ClientKey: *clientKey, ServerName: *tlsServerName}
+	//--tls overrides the TLS directive of the target file only if it is given, e.g. --tls=false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "tls" {
+			tlsOptions.Enabled = tlsEnabled
+		}
+	})
+	transport.SetTLSOptions(tlsOptions)
+	policy, err := testvector.ParseFailurePolicy(*failurePolicy)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+	testvector.SetFailurePolicy(policy)
+	teardown.SetP4RuntimeCleanup(*p4rtCleanup)
+	p4rt.SetReconnectTimeout(*reconnectTimeout)
+	action.SetRandomSeed(*randomSeed)
+	alarm.CreateAlarmStimulus(*alarmMode, *alarmSetLeaf, *alarmHook)
+	action.SetParallelOptions(action.ParallelOptions{MaxConcurrency: *maxConcurrency, Barrier: *barrier, StartSkew: *startSkew})
+	if *pollInterval <= 0 {
+		log.Fatalf("Error parsing --poll-interval: %s is not a positive duration", *pollInterval)
+	}
+	expectation.SetPollOptions(expectation.PollOptions{Interval: *pollInterval, Deadline: *pollDeadline})
+	gnmi.SetSampleTolerance(*sampleTolerance)
+	if err := gnmi.SetSubscribeMatch(*gnmiMatchType); err != nil {
+		log.Fatalf("%s", err)
+	}
+	if *gnmiSchema != "" && *gnmiYangDir != "" {
+		log.Fatalf("Only one of --gnmi-schema and --gnmi-yang-dir can be given")
+	}
+	if err := schema.Set(*gnmiSchema); err != nil {
+		log.Fatalf("%s", err)
+	}
+	if *gnmiYangDir != "" {
+		if err := schema.LoadDir(*gnmiYangDir); err != nil {
+			log.Fatalf("%s", err)
+		}
+	}
+	if *p4infoFile != "" {
+		if err := p4info.Load(*p4infoFile); err != nil {
+			log.Fatalf("%s", err)
+		}
+	}
+	p4rt.SetIgnoredMetadataIDs(getMetadataIDs(*ignoreMetadataIDs))
+	dataplane.SetLoopbackMetadata(getLoopbackMetadata(*pktOutPortMetadata, *pktInPortMetadata, *pktOutPadding, *pktInPadding))
 	testSuiteSlice := test.CreateSuite(*testNames, *tvDir, *tvName, *templateConfig)
-	test.Run(*tgFile, *dpMode, *matchType, *pmFile, testSuiteSlice)
+	test.Run(*tgFile, *dpMode, *matchType, *portMode, *pmFile, testSuiteSlice)
+}
+
+//getLoopbackMetadata parses the loopback metadata flags, exiting on error
+func getLoopbackMetadata(outPort, inPort, outPadding, inPadding string) dataplane.LoopbackMetadata {
+	var metadata dataplane.LoopbackMetadata
+	parse := func(flagName, value string) []dataplane.PacketMetadata {
+		md, err := dataplane.ParsePacketMetadata(value)
+		if err != nil {
+			log.Fatalf("Error parsing --%s: %s", flagName, err)
+		}
+		return md
+	}
+	if md := parse("pkt-out-port-metadata", outPort); len(md) == 1 {
+		metadata.PacketOutPort = md[0]
+	} else if len(md) > 1 {
+		log.Fatalf("Error parsing --pkt-out-port-metadata: expected a single <id>:<bitwidth>")
+	}
+	if md := parse("pkt-in-port-metadata", inPort); len(md) == 1 {
+		metadata.PacketInPort = md[0]
+	} else if len(md) > 1 {
+		log.Fatalf("Error parsing --pkt-in-port-metadata: expected a single <id>:<bitwidth>")
+	}
+	metadata.PacketOutPadding = parse("pkt-out-padding", outPadding)
+	metadata.PacketInPadding = parse("pkt-in-padding", inPadding)
+	return metadata
+}
+
+//getMetadataIDs parses a comma separated list of metadata IDs, exiting on error
+func getMetadataIDs(s string) []uint32 {
+	var ids []uint32
+	if s == "" {
+		return ids
+	}
+	for _, field := range strings.Split(s, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(field), 10, 32)
+		if err != nil {
+			log.Fatalf("Error parsing --ignore-metadata-ids: %s", err)
+		}
+		ids = append(ids, uint32(id))
+	}
+	return ids
 }
 
 func setupLog(logDir string, logLevel string) {
@@ -70,14 +199,66 @@ func usage() {
 ***optional arguments***
 	[--template-config <filename>]			use the provided config file to convert templates to test vectors
 	[--tv-name <regex>]                 	run all the testvectors matching provided regular expression
+	[--p4info <filename>]               	resolve P4 names in testvectors using the provided P4Info file
+											default is empty which uses the P4Info of the pipeline config testvector
 	[--dp-mode <mode>]                  	run the testvectors in provided mode
 											default is direct; acceptable modes are <direct, loopbak>
 	[--match-type <type>]               	match packets based on the provided match-type
 											default is exact; acceptable modes <exact, in>
+	[--pkt-out-port-metadata <id>:<bitwidth>]	in loopback mode, send the egress port in provided packet-out metadata
+											default is derived from P4Info egress_port metadata, or 1:16 without P4Info
+	[--pkt-in-port-metadata <id>:<bitwidth>]	in loopback mode, read the ingress port from provided packet-in metadata
+											default is derived from P4Info ingress_port metadata, or 1:16 without P4Info
+	[--pkt-out-padding <id>:<bitwidth>,...]	in loopback mode, set provided packet-out metadata to zero
+											default is the other packet_out metadata in P4Info; use none for no padding
+	[--pkt-in-padding <id>:<bitwidth>,...]	in loopback mode, expect provided packet-in metadata to be zero
+											default is the other packet_in metadata in P4Info; use none for no padding
+	[--ignore-metadata-ids <id>,...]    	skip provided packet-in metadata IDs when verifying packet-ins
+											default is empty which compares all metadata
+	[--port-mode <mode>]                	bring ports up or down using provided mode
+											default is gnmi; acceptable modes <gnmi, link>
+	[--alarm-mode <mode>]               	raise alarms using provided mode
+											default is gnmi; acceptable modes <gnmi, exec>
+	[--alarm-set-leaf <path>]           	in gnmi alarm mode, set provided leaf under the alarm path to true
+											default is empty which sets the alarm path itself
+	[--tls[=false]]                     	connect to the target with TLS, implied by the other TLS arguments
+											--tls=false connects without TLS even if the target file enables it
+	[--ca-cert <filename>]              	verify the target certificate with the provided CA certificate
+											default is empty which uses the system CA certificates
+	[--client-cert <filename>]          	use the provided client certificate for mutual TLS
+	[--client-key <filename>]           	use the provided client key for mutual TLS
+	[--tls-server-name <name>]          	verify the target certificate against the provided server name
+	[--failure-policy <policy>]         	skip expectations of test cases whose actions failed, continue and report all failures
+											or stop each test case at its first failure
+											default is skip-expectations; acceptable policies are <skip-expectations, continue, stop>
+	[--p4rt-reconnect-timeout <duration>]	try reopening a broken P4Runtime stream channel for provided duration
+											default is 1m; 0 disables reconnecting
+	[--p4rt-cleanup]                    	after each test case delete P4Runtime entities which are not in the baseline
+											and reset meters and counters
+	[--alarm-hook <command>]            	in exec alarm mode, run provided command with the alarm path as argument
 	[--log-level <level>]               	run tvrunner binary with provided log level
 											default is warn; acceptable levels are <panic, fatal, error, warn, info, debug>
 	[--log-dir <directory>]             	save logs to provided directory
 											default is /tmp
+	[--random-seed <seed>]              	shuffle randomized action groups using provided seed
+											default is 0 which generates and logs a new seed
+	[--parallel-max-concurrency <num>]  	limit the number of actions running at once in parallel action groups
+											default is 0 which means no limit
+	[--parallel-barrier]                	release all actions in parallel action groups at the same instant
+	[--parallel-start-skew <duration>]  	start the i-th action of parallel action groups i times provided duration after the start of the group
+											default is 0s; e.g. 10ms
+	[--poll-interval <duration>]        	re-issue requests of polled expectations at provided interval
+											default is 1s
+	[--poll-deadline <duration>]        	poll gNMI Get, P4Runtime Read and pipeline config expectations until they match or provided deadline expires
+											default is 0s which sends each request once
+	[--gnmi-match-type <type>]          	match gNMI subscription responses using provided type
+											default is exact; acceptable types are <exact, in>
+	[--gnmi-sample-tolerance <ratio>]   	accept samples of gNMI SAMPLE subscriptions deviating from the sample interval by provided ratio
+											default is 0.2
+	[--gnmi-schema <name>]              	validate gNMI paths and values in testvectors against provided compiled schema before running them
+											default is empty which disables validation; acceptable schemas are <ygot-example>
+	[--gnmi-yang-dir <directory>]       	validate gNMI paths in testvectors against the YANG modules in provided directory before running them
+											default is empty which disables validation
 `
 	fmt.Println(usage)
 }
@@ -1,23 +1,36 @@
 module github.com/stratum/testvectors-runner
 
-go 1.12
+go 1.24.0
 
 require (
-	github.com/golang/protobuf v1.4.0
-	github.com/google/gopacket v1.1.17
-	github.com/kylelemons/godebug v1.1.0 // indirect
-	github.com/openconfig/gnmi v0.0.0-20190716195134-52dcbf240490
-	github.com/openconfig/goyang v0.0.0-20190408185115-e8b0ed2cbb0c // indirect
-	github.com/openconfig/ygot v0.6.0
+	github.com/golang/protobuf v1.5.4
+	github.com/google/gopacket v1.1.19
+	github.com/openconfig/gnmi v0.14.1
+	github.com/openconfig/gnoi v0.8.0
+	github.com/openconfig/goyang v1.6.0
+	github.com/openconfig/ygot v0.29.20
 	github.com/p4lang/p4runtime v1.1.1-0.20200430195407-64b55baee21c
-	github.com/pkg/errors v0.8.1 // indirect
-	github.com/sirupsen/logrus v1.4.2
+	github.com/sirupsen/logrus v1.9.3
 	github.com/stratum/testvectors v0.0.0-20200612181437-5c321f9a8bd5
-	github.com/stretchr/testify v1.2.2
-	golang.org/x/net v0.0.0-20190620200207-3b0461eec859 // indirect
-	golang.org/x/sys v0.0.0-20190507160741-ecd444e8653b // indirect
-	golang.org/x/text v0.3.2 // indirect
-	google.golang.org/genproto v0.0.0-20200413115906-b5235f65be36
-	google.golang.org/grpc v1.28.1
+	github.com/stretchr/testify v1.11.1
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20251111163417-95abcf5c77ba
+	google.golang.org/grpc v1.77.0
 	gotest.tools v2.2.0+incompatible
 )
+
+require (
+	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
+	github.com/golang/glog v1.2.5 // indirect
+	github.com/google/go-cmp v0.7.0 // indirect
+	github.com/kylelemons/godebug v1.1.0 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
+	github.com/rogpeppe/go-internal v1.14.1 // indirect
+	golang.org/x/exp v0.0.0-20241009180824-f66d83c29e7c // indirect
+	golang.org/x/net v0.46.1-0.20251013234738-63d1a5100f82 // indirect
+	golang.org/x/sys v0.37.0 // indirect
+	golang.org/x/text v0.30.0 // indirect
+	google.golang.org/protobuf v1.36.10 // indirect
+	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+/*
+Package alarm implements functions to trigger alarms on the switch under test
+*/
+package alarm
+
+import (
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/logger"
+	"github.com/stratum/testvectors-runner/pkg/result"
+)
+
+var log = logger.NewLogger()
+
+//Trigger raises the alarm identified by the gNMI path on the switch under test and returns the result.
+type Trigger func(alarm *gpb.Path) *result.Result
+
+var trigger Trigger
+
+// CreateAlarmStimulus takes the alarm stimulus mode and its settings as arguments and creates the alarm trigger.
+// In "gnmi" mode the alarm is raised by a gNMI set request which sets setLeaf under the alarm path to true.
+// In "exec" mode the hook command is executed with the alarm path as argument, for targets that expose a
+// fault-injection path outside gNMI.
+func CreateAlarmStimulus(mode string, setLeaf string, hook string) {
+	switch mode {
+	case "gnmi":
+		log.Infof("Creating gNMI alarm stimulus with leaf: %s", setLeaf)
+		trigger = createGnmiTrigger(setLeaf)
+	case "exec":
+		log.Infof("Creating exec alarm stimulus with hook: %s", hook)
+		trigger = createExecTrigger(hook)
+	default:
+		log.Fatalf("Unknown alarm stimulus mode: %s", mode)
+	}
+}
+
+//SetTrigger replaces the alarm trigger, e.g. with a fault-injection function in Go based tests
+func SetTrigger(t Trigger) {
+	trigger = t
+}
+
+//ProcessAlarmStimulus raises the alarm identified by the gNMI path
+func ProcessAlarmStimulus(alarm *gpb.Path) *result.Result {
+	log.Debug("In ProcessAlarmStimulus")
+	if trigger == nil {
+		log.Error("alarm stimulus does not exist")
+		return result.Failf("alarm stimulus does not exist")
+	}
+	if alarm == nil {
+		log.Error("No alarm path specified")
+		return result.Failf("no alarm path specified")
+	}
+	return trigger(alarm)
+}
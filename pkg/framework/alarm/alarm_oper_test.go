@@ -0,0 +1,109 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package alarm
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/result"
+)
+
+var alarmPath = &gpb.Path{
+	Elem: []*gpb.PathElem{
+		{Name: "components"},
+		{Name: "component", Key: map[string]string{"name": "psu-1"}},
+	},
+}
+
+func TestGetSetPath(t *testing.T) {
+	tests := []struct {
+		name string
+		leaf *gpb.Path
+		want *gpb.Path
+	}{
+		{
+			name: "Empty Leaf",
+			leaf: &gpb.Path{},
+			want: alarmPath,
+		},
+		{
+			name: "Relative Leaf",
+			leaf: &gpb.Path{Elem: []*gpb.PathElem{{Name: "config"}, {Name: "fault"}}},
+			want: &gpb.Path{
+				Elem: []*gpb.PathElem{
+					{Name: "components"},
+					{Name: "component", Key: map[string]string{"name": "psu-1"}},
+					{Name: "config"},
+					{Name: "fault"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getSetPath(alarmPath, tt.leaf); !proto.Equal(got, tt.want) {
+				t.Errorf("getSetPath() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessAlarmStimulus(t *testing.T) {
+	defer SetTrigger(nil)
+	tests := []struct {
+		name    string
+		trigger Trigger
+		alarm   *gpb.Path
+		want    bool
+	}{
+		{
+			name:    "No Trigger",
+			trigger: nil,
+			alarm:   alarmPath,
+			want:    false,
+		},
+		{
+			name:    "Empty Alarm",
+			trigger: func(*gpb.Path) *result.Result { return result.Pass() },
+			alarm:   nil,
+			want:    false,
+		},
+		{
+			name: "Hook Trigger",
+			trigger: func(p *gpb.Path) *result.Result {
+				if !proto.Equal(p, alarmPath) {
+					return result.Failf("unexpected alarm %s", p)
+				}
+				return result.Pass()
+			},
+			alarm: alarmPath,
+			want:  true,
+		},
+		{
+			name:    "Exec Trigger",
+			trigger: createExecTrigger("true"),
+			alarm:   alarmPath,
+			want:    true,
+		},
+		{
+			name:    "Failing Exec Trigger",
+			trigger: createExecTrigger("false"),
+			alarm:   alarmPath,
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetTrigger(tt.trigger)
+			if got := ProcessAlarmStimulus(tt.alarm); got.Passed() != tt.want {
+				t.Errorf("ProcessAlarmStimulus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
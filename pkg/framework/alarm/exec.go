@@ -0,0 +1,38 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package alarm
+
+import (
+	"os/exec"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/stratum/testvectors-runner/pkg/result"
+)
+
+//createExecTrigger returns a Trigger which runs the hook command with the alarm path string as its only argument.
+//The alarm is considered raised if the command exits with status 0.
+func createExecTrigger(hook string) Trigger {
+	if hook == "" {
+		log.Fatal("No alarm hook specified")
+	}
+	return func(alarm *gpb.Path) *result.Result {
+		path, err := ygot.PathToString(alarm)
+		if err != nil {
+			log.Errorf("Invalid alarm path %s: %v", alarm, err)
+			return result.Failf("invalid alarm path %s: %v", alarm, err)
+		}
+		log.Infof("Raising alarm %s with hook %s", path, hook)
+		out, err := exec.Command(hook, path).CombinedOutput()
+		if err != nil {
+			log.Errorf("Error running alarm hook %s: %v %s", hook, err, out)
+			return result.Failf("alarm hook %s failed to raise alarm %s: %v %s", hook, path, err, out)
+		}
+		log.Debugf("Alarm hook output: %s", out)
+		return result.Pass()
+	}
+}
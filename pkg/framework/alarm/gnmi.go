@@ -0,0 +1,36 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package alarm
+
+import (
+	"github.com/golang/protobuf/proto"
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/stratum/testvectors-runner/pkg/framework/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/result"
+)
+
+//createGnmiTrigger returns a Trigger which sets setLeaf, relative to the alarm path, to true using a gNMI set request.
+//An empty setLeaf sets the alarm path itself.
+func createGnmiTrigger(setLeaf string) Trigger {
+	leaf, err := ygot.StringToStructuredPath(setLeaf)
+	if err != nil {
+		log.Fatalf("Invalid alarm set leaf %s: %v", setLeaf, err)
+	}
+	return func(alarm *gpb.Path) *result.Result {
+		path := getSetPath(alarm, leaf)
+		log.Infof("Raising alarm by setting %s to true", path)
+		return gnmi.ProcessUpdate(path, &gpb.TypedValue{Value: &gpb.TypedValue_BoolVal{BoolVal: true}})
+	}
+}
+
+//getSetPath returns a copy of the alarm path extended with the elements of leaf
+func getSetPath(alarm *gpb.Path, leaf *gpb.Path) *gpb.Path {
+	path := proto.Clone(alarm).(*gpb.Path)
+	path.Elem = append(path.Elem, leaf.GetElem()...)
+	return path
+}
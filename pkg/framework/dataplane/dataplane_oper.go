@@ -10,7 +10,11 @@ Package dataplane implements packet send/receive functions
 package dataplane
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/stratum/testvectors-runner/pkg/logger"
+	"github.com/stratum/testvectors-runner/pkg/result"
 	pm "github.com/stratum/testvectors/proto/portmap"
 )
 
@@ -31,8 +35,8 @@ type dataPlane interface {
 	capture() bool
 	// send packets to a specific port
 	send(pkts [][]byte, port uint32) bool
-	// verify packets captured on ports
-	verify(pkts [][]byte, ports []uint32) bool
+	// verify packets captured on ports, the result has the result of each port checked as children
+	verify(pkts [][]byte, ports []uint32) *result.Result
 	// stop packet capturing
 	stop() bool
 }
@@ -79,25 +83,62 @@ func getPortMapEntryByPortNumber(portmap *pm.PortMap, portNumber uint32) *pm.Ent
 }
 
 //ProcessTrafficStimulus sends packets to specific ports
-func ProcessTrafficStimulus(pkts [][]byte, port uint32) bool {
+func ProcessTrafficStimulus(pkts [][]byte, port uint32) *result.Result {
 	log.Debug("In ProcessTrafficStimulus")
 	if dp == nil {
 		log.Error("data plane does not exist")
-		return false
+		return result.Failf("data plane does not exist")
 	}
-	return dp.send(pkts, port)
+	if !dp.send(pkts, port) {
+		return result.Failf("failed to send %d packets to port %d", len(pkts), port)
+	}
+	return result.Pass()
 }
 
 //ProcessTrafficExpectation verifies that packets arrived at specific ports
-func ProcessTrafficExpectation(pkts [][]byte, ports []uint32) bool {
+func ProcessTrafficExpectation(pkts [][]byte, ports []uint32) *result.Result {
 	log.Debug("In ProcessTrafficExpectation")
 	if dp == nil {
 		log.Error("data plane does not exist")
-		return false
+		return result.Failf("data plane does not exist")
 	}
 	return dp.verify(pkts, ports)
 }
 
+//portResult returns the result of the packets captured on a port, with the expected and captured packets if they don't match
+func portResult(port uint32, ok bool, expected, captured [][]byte) *result.Result {
+	if ok {
+		return result.Pass()
+	}
+	return result.Mismatch(fmt.Sprintf("packets captured on port %d don't match %d expected packets", port, len(expected)),
+		packetsString(expected), packetsString(captured))
+}
+
+//anyPort returns a passed result if the packets matched on one of the ports, as traffic expectations accept any of their ports.
+//Otherwise the result fails with the result of each port as children.
+func anyPort(pkts [][]byte, ports []uint32, portResults []*result.Result) *result.Result {
+	for _, res := range portResults {
+		if res.Passed() {
+			return result.Pass()
+		}
+	}
+	res := result.Failf("packets captured on ports %v don't match %d expected packets", ports, len(pkts))
+	res.Add(portResults...)
+	return res
+}
+
+//packetsString returns the packets in hex, one packet per line
+func packetsString(pkts [][]byte) string {
+	if len(pkts) == 0 {
+		return "no packets"
+	}
+	s := make([]string, len(pkts))
+	for i, pkt := range pkts {
+		s[i] = fmt.Sprintf("\n  #%d: % x", i+1, pkt)
+	}
+	return fmt.Sprintf("%d packet(s)%s", len(pkts), strings.Join(s, ""))
+}
+
 //Capture starts packet capturing
 func Capture() bool {
 	log.Debug("In Capture")
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package dataplane
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stratum/testvectors-runner/pkg/result"
+)
+
+//fakeDataPlane verifies expected packets against fixed captured packets per port, as the direct data plane does with exact match
+type fakeDataPlane struct {
+	captured map[uint32][][]byte
+}
+
+func (f fakeDataPlane) capture() bool                        { return true }
+func (f fakeDataPlane) send(pkts [][]byte, port uint32) bool { return true }
+func (f fakeDataPlane) stop() bool                           { return true }
+
+func (f fakeDataPlane) verify(pkts [][]byte, ports []uint32) *result.Result {
+	var portResults []*result.Result
+	for _, port := range ports {
+		ok := len(pkts) == len(f.captured[port])
+		for i := 0; ok && i < len(pkts); i++ {
+			ok = bytes.Equal(pkts[i], f.captured[port][i])
+		}
+		portResults = append(portResults, portResult(port, ok, pkts, f.captured[port]))
+		if ok {
+			break
+		}
+	}
+	return anyPort(pkts, ports, portResults)
+}
+
+func TestProcessTrafficExpectation(t *testing.T) {
+	defer func() { dp = nil }()
+	dp = fakeDataPlane{captured: map[uint32][][]byte{1: {{0x01, 0x02}}, 2: {{0x01, 0x03}, {0x04}}}}
+	tests := []struct {
+		name     string
+		pkts     [][]byte
+		ports    []uint32
+		want     bool
+		wantDiff []string
+	}{
+		{name: "Match", pkts: [][]byte{{0x01, 0x02}}, ports: []uint32{1}, want: true},
+		{name: "Match On Second Port", pkts: [][]byte{{0x01, 0x03}, {0x04}}, ports: []uint32{1, 2}, want: true},
+		{
+			name:     "Mismatch",
+			pkts:     [][]byte{{0x01, 0x04}},
+			ports:    []uint32{1, 2},
+			wantDiff: []string{"port 1", "port 2", "Expected: 1 packet(s)", "#1: 01 04", "Actual  : 1 packet(s)", "#1: 01 02", "Actual  : 2 packet(s)", "#2: 04"},
+		},
+		{name: "Nothing Captured", pkts: [][]byte{{0x01}}, ports: []uint32{3}, wantDiff: []string{"Actual  : no packets"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ProcessTrafficExpectation(tt.pkts, tt.ports)
+			if got.Passed() != tt.want {
+				t.Errorf("ProcessTrafficExpectation() = %s, want passed %v", got, tt.want)
+			}
+			report := got.String()
+			for _, diff := range tt.wantDiff {
+				if !strings.Contains(report, diff) {
+					t.Errorf("ProcessTrafficExpectation() = %s, want it to contain %q", report, diff)
+				}
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/google/gopacket/layers"
 	"github.com/google/gopacket/pcap"
 	"github.com/google/gopacket/pcapgo"
+	"github.com/stratum/testvectors-runner/pkg/result"
 	pm "github.com/stratum/testvectors/proto/portmap"
 )
 
@@ -156,13 +157,15 @@ func (ddp *directDataPlane) sendOnInterface(iface string, pkt []byte) bool {
 // the same as pkts including the order. Otherwise it returns false.
 // When "In" is used as match type, it returns true if packets captured contain pkts.
 // Otherwise if returns false.
-func (ddp *directDataPlane) verifyOnInterface(iface string, pkts [][]byte) bool {
+// It also returns the packets captured on the interface by the last check.
+func (ddp *directDataPlane) verifyOnInterface(iface string, pkts [][]byte) (bool, [][]byte) {
 	timer := time.After(ddp.pktCheckTimeout)
 	// TODO: read packets from buffer instead of file
 	// Also see TODO in captureOnInterface()
 	pcapFile := fmt.Sprintf("%s%s.pcap", ddp.pcapPath, iface)
 	log.Debugf("Expecting %d packets captured on interface %s", len(pkts), iface)
 	result := false
+	var captured [][]byte
 	for {
 		// Sleep between checks in the loop
 	recheck:
@@ -175,14 +178,15 @@ func (ddp *directDataPlane) verifyOnInterface(iface string, pkts [][]byte) bool
 			} else {
 				log.Errorf("Packet check failed on interface %s...", iface)
 			}
-			return result
+			return result, captured
 		default:
 			result = true
+			captured = nil
 			// Open pcap file
 			handle, err := pcap.OpenOffline(pcapFile)
 			if err != nil {
 				log.Error(err)
-				return false
+				return false, captured
 			}
 			log.Debugf("Reading packet from %s...", pcapFile)
 			packetSource := gopacket.NewPacketSource(handle, handle.LinkType())
@@ -206,6 +210,7 @@ func (ddp *directDataPlane) verifyOnInterface(iface string, pkts [][]byte) bool
 					goto recheck
 				}
 				capturedNum++
+				captured = append(captured, packet.Data())
 				if !bytes.Equal(pkt, packet.Data()) {
 					switch ddp.match {
 					case Exact:
@@ -213,8 +218,11 @@ func (ddp *directDataPlane) verifyOnInterface(iface string, pkts [][]byte) bool
 						log.Errorf("Payloads of packet #%d don't match", capturedNum)
 						log.Debugf("\nExpected payload: % x\nCaptured payload: % x", pkt, packet.Data())
 						// No need for recheck in this case
+						for packet := range packetSource.Packets() {
+							captured = append(captured, packet.Data())
+						}
 						handle.Close()
-						return false
+						return false, captured
 					case In:
 						// Packets don't match, ignore it
 						log.Debugf("Ingoring unmached packet: % x", packet.Data())
@@ -232,16 +240,17 @@ func (ddp *directDataPlane) verifyOnInterface(iface string, pkts [][]byte) bool
 				for packet := range packetSource.Packets() {
 					log.Debugf("Unexpected packet on interface %s: %s", iface, packet)
 					capturedNum++
+					captured = append(captured, packet.Data())
 				}
 				if capturedNum > len(pkts) {
 					log.Errorf("Expecting %d packets but captured %d on interface %s...", len(pkts), capturedNum, iface)
 					// No need for recheck in this case
 					handle.Close()
-					return false
+					return false, captured
 				}
 			case In:
 				handle.Close()
-				return true
+				return true, captured
 			}
 			handle.Close()
 		}
@@ -305,9 +314,10 @@ func (ddp *directDataPlane) send(pkts [][]byte, port uint32) bool {
 	return result
 }
 
-//verify finds the ports in the port map and calls verifyOnInterface for each port.
-func (ddp *directDataPlane) verify(pkts [][]byte, ports []uint32) bool {
-	result := false
+//verify finds the ports in the port map and calls verifyOnInterface for each port until the packets match on one of them.
+//The result has the expected and captured packets of each port checked.
+func (ddp *directDataPlane) verify(pkts [][]byte, ports []uint32) *result.Result {
+	var portResults []*result.Result
 	for _, port := range ports {
 		log.Infof("Checking packets on port %d", port)
 		entry := getPortMapEntryByPortNumber(ddp.portmap, port)
@@ -324,10 +334,14 @@ func (ddp *directDataPlane) verify(pkts [][]byte, ports []uint32) bool {
 			if intf == "" {
 				log.Fatalf("No interface specified for port %d", port)
 			}
-			result = result || ddp.verifyOnInterface(intf, pkts)
+			ok, captured := ddp.verifyOnInterface(intf, pkts)
+			portResults = append(portResults, portResult(port, ok, pkts, captured))
+			if ok {
+				break
+			}
 		} else {
 			log.Fatalf("Failed to find portmap entry that has port number %d", port)
 		}
 	}
-	return result
+	return anyPort(pkts, ports, portResults)
 }
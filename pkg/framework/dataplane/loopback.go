@@ -7,13 +7,15 @@
 package dataplane
 
 import (
+	"fmt"
 	"time"
 
 	pm "github.com/stratum/testvectors/proto/portmap"
 
 	v1 "github.com/p4lang/p4runtime/go/p4/v1"
 	"github.com/stratum/testvectors-runner/pkg/framework/p4rt"
-	"github.com/stratum/testvectors-runner/pkg/utils/common"
+	"github.com/stratum/testvectors-runner/pkg/result"
+	"github.com/stratum/testvectors-runner/pkg/utils/p4info"
 )
 
 type loopbackDataPlane struct {
@@ -21,6 +23,8 @@ type loopbackDataPlane struct {
 	match   Match
 	// Maximum duration for packet capturing
 	maxTimeout time.Duration
+	// Packet-out and packet-in metadata carrying the data plane ports
+	metadata LoopbackMetadata
 }
 
 // createLoopbackDataPlane creates a data plane instance which utilizes packet-out/packet-in to
@@ -30,6 +34,9 @@ func createLoopbackDataPlane(portmap *pm.PortMap, match Match) *loopbackDataPlan
 	ldp.portmap = portmap
 	ldp.match = match
 	ldp.maxTimeout = 1 * time.Hour
+	ldp.metadata = resolveLoopbackMetadata(loopbackMetadata, p4info.Get())
+	log.Infof("Loopback metadata: %+v", ldp.metadata)
+	p4rt.SetIngressPortMetadataID(ldp.metadata.PacketInPort.ID)
 	return &ldp
 }
 
@@ -40,8 +47,8 @@ func createLoopbackDataPlane(portmap *pm.PortMap, match Match) *loopbackDataPlan
 func (ldp *loopbackDataPlane) sendOnPort(port uint32, pkt []byte) bool {
 	log.Infof("Sending packet to port %d\n", port)
 	log.Debugf("Packet info: % x\n", pkt)
-	po := convertToPktOut(port, pkt)
-	return p4rt.ProcessPacketOutOperation(po)
+	po := ldp.convertToPktOut(port, pkt)
+	return p4rt.ProcessPacketOutOperation(po).Passed()
 }
 
 // verifyOnPort verifies if packets captured on sepcific port are as expected.
@@ -49,17 +56,26 @@ func (ldp *loopbackDataPlane) sendOnPort(port uint32, pkt []byte) bool {
 // represented by a slice of bytes.
 // It verifies that the packets captured on specified port match the ones specified in
 // pkts. When pkts is empty it verifies that no packet has been received.
-func (ldp *loopbackDataPlane) verifyOnPort(port uint32, pkts [][]byte) bool {
+// The result has the packet-in results of the port as children, which carry the expected and received packets.
+func (ldp *loopbackDataPlane) verifyOnPort(port uint32, pkts [][]byte) *result.Result {
 	log.Debugf("Expecting %d packets captured on port %d", len(pkts), port)
-	result := true
+	res := result.Pass()
 	for _, pkt := range pkts {
-		pi := convertToPktIn(port, pkt)
-		result = result && p4rt.ProcessPacketIn(pi)
+		pi := ldp.convertToPktIn(port, pkt)
+		res.Add(p4rt.ProcessPacketIn(pi))
+		if res.Failed() {
+			break
+		}
 	}
 	// Still need to check for unexpected packets
-	pi := convertToPktIn(port, nil)
-	result = result && p4rt.ProcessPacketIn(pi)
-	return result
+	if res.Passed() {
+		pi := ldp.convertToPktIn(port, nil)
+		res.Add(p4rt.ProcessPacketIn(pi))
+	}
+	if res.Failed() {
+		res.Err = fmt.Errorf("packets received on port %d don't match %d expected packets", port, len(pkts))
+	}
+	return res
 }
 
 //stop stops all captures
@@ -93,9 +109,9 @@ func (ldp *loopbackDataPlane) send(pkts [][]byte, port uint32) bool {
 	return result
 }
 
-//verify calls verifyOnPort for each port
-func (ldp *loopbackDataPlane) verify(pkts [][]byte, ports []uint32) bool {
-	result := false
+//verify calls verifyOnPort for each port until the packets match on one of them
+func (ldp *loopbackDataPlane) verify(pkts [][]byte, ports []uint32) *result.Result {
+	var portResults []*result.Result
 	for _, port := range ports {
 		log.Infof("Checking packets on port %d\n", port)
 		entry := getPortMapEntryByPortNumber(ldp.portmap, port)
@@ -108,30 +124,39 @@ func (ldp *loopbackDataPlane) verify(pkts [][]byte, ports []uint32) bool {
 				 // We shouldn't capture packets on this port
 				 log.Fatalf("Port %d could only be used as ingress to switch", port)
 			 }*/
-			result = result || ldp.verifyOnPort(port, pkts)
+			res := ldp.verifyOnPort(port, pkts)
+			portResults = append(portResults, res)
+			if res.Passed() {
+				break
+			}
 		} else {
 			log.Fatalf("Failed to find portmap entry that has port number %d", port)
 		}
 	}
-	return result
+	return anyPort(pkts, ports, portResults)
 }
 
-func convertToPktOut(port uint32, pkt []byte) *v1.PacketOut {
+//convertToPktOut builds a PacketOut which sends pkt to port, with the egress port and padding metadata from ldp.metadata
+func (ldp *loopbackDataPlane) convertToPktOut(port uint32, pkt []byte) *v1.PacketOut {
 	po := &v1.PacketOut{}
 	po.Payload = pkt
-	//MetadataId 1 represents egress_physical_port of packet_out controller_packet_metadata based on the P4 program
-	//TODO: make the metadata id configurable to allow loopback for any P4 program
-	po.Metadata = []*v1.PacketMetadata{{MetadataId: 1, Value: common.GetUint16(port)}}
+	po.Metadata = getPacketMetadata(ldp.metadata.PacketOutPort, ldp.metadata.PacketOutPadding, port)
 	return po
 }
 
-func convertToPktIn(port uint32, pkt []byte) *v1.PacketIn {
+//convertToPktIn builds the PacketIn expected for pkt received on port, with the ingress port and padding metadata from ldp.metadata
+func (ldp *loopbackDataPlane) convertToPktIn(port uint32, pkt []byte) *v1.PacketIn {
 	pi := &v1.PacketIn{}
 	pi.Payload = pkt
-	pi.Metadata = []*v1.PacketMetadata{
-		//MetadataId 1 represents ingress_physical_port of packet_in controller_packet_metadata based on the P4 program
-		//TODO: make the metadata id configurable to allow loopback for any P4 program
-		{MetadataId: 1, Value: common.GetUint16(port)},
-	}
+	pi.Metadata = getPacketMetadata(ldp.metadata.PacketInPort, ldp.metadata.PacketInPadding, port)
 	return pi
 }
+
+//getPacketMetadata returns the port metadata with the given port number followed by the padding metadata set to zero
+func getPacketMetadata(portMetadata PacketMetadata, padding []PacketMetadata, port uint32) []*v1.PacketMetadata {
+	metadata := []*v1.PacketMetadata{{MetadataId: portMetadata.ID, Value: portMetadata.encode(port)}}
+	for _, pad := range padding {
+		metadata = append(metadata, &v1.PacketMetadata{MetadataId: pad.ID, Value: pad.encode(0)})
+	}
+	return metadata
+}
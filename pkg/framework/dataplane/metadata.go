@@ -0,0 +1,143 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+/*
+Package dataplane implements packet send/receive functions
+*/
+package dataplane
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	config "github.com/p4lang/p4runtime/go/p4/config/v1"
+)
+
+//PacketMetadata identifies a packet-out or packet-in metadata field by its ID and bit width
+type PacketMetadata struct {
+	ID       uint32
+	BitWidth int32
+}
+
+//LoopbackMetadata maps data plane ports to packet-out and packet-in metadata in loopback mode
+type LoopbackMetadata struct {
+	//PacketOutPort carries the egress port of packet-outs
+	PacketOutPort PacketMetadata
+	//PacketInPort carries the ingress port of packet-ins
+	PacketInPort PacketMetadata
+	//PacketOutPadding and PacketInPadding are the other metadata of packet-outs and packet-ins, which are set to zero
+	PacketOutPadding []PacketMetadata
+	PacketInPadding  []PacketMetadata
+}
+
+//defaultPortMetadata is the port metadata of the P4 programs loopback mode was first written for
+var defaultPortMetadata = PacketMetadata{ID: 1, BitWidth: 16}
+
+//loopbackMetadata is set by SetLoopbackMetadata, zero values are resolved when the loopback data plane is created
+var loopbackMetadata LoopbackMetadata
+
+//SetLoopbackMetadata sets the packet-out and packet-in metadata used by loopback mode.
+//Port metadata with ID 0 and nil padding are derived from the controller_packet_metadata of the P4Info if one is loaded.
+//Otherwise port metadata default to ID 1 with 16 bits and no padding is added.
+func SetLoopbackMetadata(metadata LoopbackMetadata) {
+	log.Debugf("Loopback metadata: %+v", metadata)
+	loopbackMetadata = metadata
+}
+
+//ParsePacketMetadata parses a comma separated list of metadata in the form <id>:<bitwidth>, e.g. "1:9,2:7".
+//It returns nil for an empty string, which lets SetLoopbackMetadata derive the metadata, and an empty list for "none".
+func ParsePacketMetadata(s string) ([]PacketMetadata, error) {
+	switch s {
+	case "":
+		return nil, nil
+	case "none":
+		return []PacketMetadata{}, nil
+	}
+	var metadata []PacketMetadata
+	for _, field := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(field), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid packet metadata %q, expected <id>:<bitwidth>", field)
+		}
+		id, err := strconv.ParseUint(parts[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid packet metadata ID in %q, %v", field, err)
+		}
+		bitWidth, err := strconv.ParseInt(parts[1], 10, 32)
+		if err != nil || bitWidth <= 0 {
+			return nil, fmt.Errorf("invalid packet metadata bit width in %q", field)
+		}
+		metadata = append(metadata, PacketMetadata{ID: uint32(id), BitWidth: int32(bitWidth)})
+	}
+	return metadata, nil
+}
+
+//encode returns value as a big-endian byte string wide enough for the bit width of the metadata
+func (m PacketMetadata) encode(value uint32) []byte {
+	n := int(m.BitWidth+7) / 8
+	b := make([]byte, n)
+	for i := n - 1; i >= 0 && value > 0; i-- {
+		b[i] = byte(value)
+		value >>= 8
+	}
+	return b
+}
+
+//resolveLoopbackMetadata fills the zero values of metadata from the P4Info, or from the defaults if info is nil
+func resolveLoopbackMetadata(metadata LoopbackMetadata, info *config.P4Info) LoopbackMetadata {
+	outPort, outPadding := derivePacketMetadata(info, "packet_out", "egress_port", "egress_physical_port")
+	inPort, inPadding := derivePacketMetadata(info, "packet_in", "ingress_port", "ingress_physical_port")
+	if metadata.PacketOutPort.ID == 0 {
+		metadata.PacketOutPort = outPort
+	}
+	if metadata.PacketInPort.ID == 0 {
+		metadata.PacketInPort = inPort
+	}
+	if metadata.PacketOutPadding == nil {
+		metadata.PacketOutPadding = outPadding
+	}
+	if metadata.PacketInPadding == nil {
+		metadata.PacketInPadding = inPadding
+	}
+	return metadata
+}
+
+//derivePacketMetadata looks up the controller packet metadata with the given name in the P4Info and returns
+//the first metadata matching one of the port names and all the other metadata as padding.
+//It returns the default port metadata and no padding if the P4Info or the port metadata is not found.
+func derivePacketMetadata(info *config.P4Info, cpmName string, portNames ...string) (PacketMetadata, []PacketMetadata) {
+	for _, cpm := range info.GetControllerPacketMetadata() {
+		if cpm.GetPreamble().GetName() != cpmName {
+			continue
+		}
+		var port *PacketMetadata
+		var padding []PacketMetadata
+		for _, md := range cpm.GetMetadata() {
+			m := PacketMetadata{ID: md.GetId(), BitWidth: md.GetBitwidth()}
+			if port == nil && isOneOf(md.GetName(), portNames) {
+				port = &m
+				continue
+			}
+			padding = append(padding, m)
+		}
+		if port == nil {
+			log.Errorf("None of the metadata %v found in %s controller packet metadata, using default %+v", portNames, cpmName, defaultPortMetadata)
+			return defaultPortMetadata, nil
+		}
+		return *port, padding
+	}
+	return defaultPortMetadata, nil
+}
+
+func isOneOf(name string, names []string) bool {
+	for _, n := range names {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
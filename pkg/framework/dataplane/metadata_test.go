@@ -0,0 +1,123 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package dataplane
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	config "github.com/p4lang/p4runtime/go/p4/config/v1"
+)
+
+func TestParsePacketMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []PacketMetadata
+		wantErr bool
+	}{
+		{name: "Empty", s: "", want: nil},
+		{name: "None", s: "none", want: []PacketMetadata{}},
+		{name: "Single", s: "1:9", want: []PacketMetadata{{ID: 1, BitWidth: 9}}},
+		{name: "Multiple", s: "1:9, 2:7", want: []PacketMetadata{{ID: 1, BitWidth: 9}, {ID: 2, BitWidth: 7}}},
+		{name: "Missing bit width", s: "1", wantErr: true},
+		{name: "Invalid ID", s: "a:9", wantErr: true},
+		{name: "Zero bit width", s: "1:0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePacketMetadata(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParsePacketMetadata() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePacketMetadata() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name  string
+		md    PacketMetadata
+		value uint32
+		want  []byte
+	}{
+		{name: "16 bits", md: PacketMetadata{ID: 1, BitWidth: 16}, value: 1, want: []byte{0, 1}},
+		{name: "9 bits", md: PacketMetadata{ID: 1, BitWidth: 9}, value: 260, want: []byte{1, 4}},
+		{name: "7 bits zero", md: PacketMetadata{ID: 2, BitWidth: 7}, value: 0, want: []byte{0}},
+		{name: "32 bits", md: PacketMetadata{ID: 1, BitWidth: 32}, value: 0x01020304, want: []byte{1, 2, 3, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.md.encode(tt.value); !bytes.Equal(got, tt.want) {
+				t.Errorf("encode() = % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveLoopbackMetadata(t *testing.T) {
+	fabric := &config.P4Info{
+		ControllerPacketMetadata: []*config.ControllerPacketMetadata{
+			{
+				Preamble: &config.Preamble{Name: "packet_in"},
+				Metadata: []*config.ControllerPacketMetadata_Metadata{{Id: 1, Name: "ingress_port", Bitwidth: 9}, {Id: 2, Name: "_pad", Bitwidth: 7}},
+			},
+			{
+				Preamble: &config.Preamble{Name: "packet_out"},
+				Metadata: []*config.ControllerPacketMetadata_Metadata{{Id: 1, Name: "egress_port", Bitwidth: 9}, {Id: 2, Name: "_pad", Bitwidth: 7}},
+			},
+		},
+	}
+	tests := []struct {
+		name     string
+		metadata LoopbackMetadata
+		info     *config.P4Info
+		want     LoopbackMetadata
+	}{
+		{
+			name: "Defaults without P4Info",
+			want: LoopbackMetadata{PacketOutPort: defaultPortMetadata, PacketInPort: defaultPortMetadata},
+		},
+		{
+			name: "Derived from P4Info",
+			info: fabric,
+			want: LoopbackMetadata{
+				PacketOutPort:    PacketMetadata{ID: 1, BitWidth: 9},
+				PacketInPort:     PacketMetadata{ID: 1, BitWidth: 9},
+				PacketOutPadding: []PacketMetadata{{ID: 2, BitWidth: 7}},
+				PacketInPadding:  []PacketMetadata{{ID: 2, BitWidth: 7}},
+			},
+		},
+		{
+			name: "Configured values override P4Info",
+			metadata: LoopbackMetadata{
+				PacketOutPort:    PacketMetadata{ID: 3, BitWidth: 32},
+				PacketInPadding:  []PacketMetadata{},
+				PacketOutPadding: nil,
+			},
+			info: fabric,
+			want: LoopbackMetadata{
+				PacketOutPort:    PacketMetadata{ID: 3, BitWidth: 32},
+				PacketInPort:     PacketMetadata{ID: 1, BitWidth: 9},
+				PacketOutPadding: []PacketMetadata{{ID: 2, BitWidth: 7}},
+				PacketInPadding:  []PacketMetadata{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveLoopbackMetadata(tt.metadata, tt.info); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveLoopbackMetadata() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package gnmi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/result"
+)
+
+//path wildcards of expected paths
+const (
+	//anyElem matches exactly one path element, or any value as key value
+	anyElem = "*"
+	//anyElems matches any number of path elements, including none
+	anyElems = "..."
+)
+
+//identityref matches an identityref value qualified by its module name, e.g. "openconfig-if-ethernet:SPEED_10GB"
+var identityref = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*:([A-Za-z_][A-Za-z0-9_.-]*)$`)
+
+//notificationPath is an update or delete of notifications, with the prefix of its notification joined to its path
+type notificationPath struct {
+	path *gnmi.Path
+	val  *gnmi.TypedValue
+}
+
+//verifyNotifications compares the updates and deletes of actual notifications with the expected ones by full path,
+//regardless of prefixes and of how they are split across notifications. Timestamps are ignored.
+//Expected paths may contain wildcards: an element "*" matches one element, an element "..." matches any number of elements
+//and a key value "*" matches any value. All the actual updates matching a wildcard path must have the expected value,
+//and at least one must exist. Expected values may be predicates, and JSON encoded values equal scalar values they decode to.
+//A JSON encoded container expected at a path which has no actual update equals the actual scalar updates below the path.
+//All the differences are reported, one line per path.
+func verifyNotifications(expected, actual []*gnmi.Notification) *result.Result {
+	expUpdates, expDeletes := indexNotifications(expected)
+	actUpdates, actDeletes := indexNotifications(actual)
+	var diff []string
+	covered := make(map[string]bool)
+	for _, key := range sortedKeys(expUpdates) {
+		exp := expUpdates[key]
+		matches := matchingPaths(exp.path, actUpdates)
+		if len(matches) == 0 {
+			leaves := descendantPaths(exp.path, actUpdates)
+			if len(leaves) == 0 || !isJSON(exp.val) || hasWildcards(exp.path) {
+				diff = append(diff, fmt.Sprintf("Missing   : update of %s", key))
+				continue
+			}
+			for _, leaf := range leaves {
+				covered[leaf] = true
+			}
+			if err := matchContainer(key, exp, leaves, actUpdates); err != nil {
+				diff = append(diff, fmt.Sprintf("Mismatch  : %v", err))
+			}
+			continue
+		}
+		for _, act := range matches {
+			covered[act] = true
+			if err := matchValue(act, exp.val, actUpdates[act].val); err != nil {
+				diff = append(diff, fmt.Sprintf("Mismatch  : %v", err))
+			}
+		}
+	}
+	for _, key := range sortedKeys(actUpdates) {
+		if !covered[key] {
+			diff = append(diff, fmt.Sprintf("Unexpected: update of %s", key))
+		}
+	}
+	for _, key := range sortedKeys(expDeletes) {
+		matches := matchingPaths(expDeletes[key].path, actDeletes)
+		if len(matches) == 0 {
+			diff = append(diff, fmt.Sprintf("Missing   : delete of %s", key))
+		}
+		for _, act := range matches {
+			covered[act] = true
+		}
+	}
+	for _, key := range sortedKeys(actDeletes) {
+		if !covered[key] {
+			diff = append(diff, fmt.Sprintf("Unexpected: delete of %s", key))
+		}
+	}
+	if len(diff) == 0 {
+		log.Info("Notifications are equal")
+		return result.Pass()
+	}
+	log.Warnf("Notifications are unequal\n%s", strings.Join(diff, "\n"))
+	r := result.Failf("%d differences between expected and actual notifications", len(diff))
+	r.Diff = strings.Join(diff, "\n")
+	return r
+}
+
+//indexNotifications returns the updates and the deletes of notifications by full path
+func indexNotifications(notifications []*gnmi.Notification) (map[string]notificationPath, map[string]notificationPath) {
+	updates, deletes := make(map[string]notificationPath), make(map[string]notificationPath)
+	for _, n := range notifications {
+		for _, p := range n.GetDelete() {
+			path := joinPath(n.GetPrefix(), p)
+			deletes[pathString(path)] = notificationPath{path: path}
+		}
+		for _, u := range n.GetUpdate() {
+			path := joinPath(n.GetPrefix(), u.GetPath())
+			updates[pathString(path)] = notificationPath{path: path, val: u.GetVal()}
+		}
+	}
+	return updates, deletes
+}
+
+//sortedKeys returns the paths of the index in lexical order
+func sortedKeys(m map[string]notificationPath) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//matchingPaths returns the paths of the index matching the expected path, in lexical order
+func matchingPaths(expected *gnmi.Path, index map[string]notificationPath) []string {
+	if !hasWildcards(expected) {
+		if _, ok := index[pathString(expected)]; ok {
+			return []string{pathString(expected)}
+		}
+		return nil
+	}
+	var matches []string
+	for _, key := range sortedKeys(index) {
+		if matchPath(expected.GetElem(), index[key].path.GetElem()) {
+			matches = append(matches, key)
+		}
+	}
+	return matches
+}
+
+//descendantPaths returns the paths of the index below the path, in lexical order
+func descendantPaths(path *gnmi.Path, index map[string]notificationPath) []string {
+	n := len(path.GetElem())
+	var paths []string
+	for _, key := range sortedKeys(index) {
+		elems := index[key].path.GetElem()
+		if len(elems) > n && matchPath(path.GetElem(), elems[:n]) {
+			paths = append(paths, key)
+		}
+	}
+	return paths
+}
+
+//matchContainer returns an error if the expected JSON encoded container at the path doesn't equal the actual scalar updates of leaves below it.
+//List entries are compared regardless of their order.
+func matchContainer(path string, expected notificationPath, leaves []string, index map[string]notificationPath) error {
+	exp, ok := canonicalValue(expected.val)
+	if !ok {
+		return fmt.Errorf("value of %s: invalid JSON value %s", path, expected.val)
+	}
+	act := make(map[string]interface{})
+	depth := len(expected.path.GetElem())
+	for _, leaf := range leaves {
+		val, ok := canonicalValue(index[leaf].val)
+		if !ok {
+			return fmt.Errorf("value of %s: value %s of %s is not a scalar", path, index[leaf].val, leaf)
+		}
+		if err := setLeaf(act, index[leaf].path.GetElem()[depth:], val); err != nil {
+			return fmt.Errorf("value of %s: %s %v", path, leaf, err)
+		}
+	}
+	if !reflect.DeepEqual(sortLists(exp), sortLists(act)) {
+		actJSON, _ := json.Marshal(act)
+		return fmt.Errorf("value of %s: expected %s, actual updates %s", path, expected.val, actJSON)
+	}
+	return nil
+}
+
+//setLeaf sets the value of the leaf at the relative path in a decoded JSON container.
+//List entries are objects of a JSON array, with their keys as members.
+func setLeaf(container map[string]interface{}, elems []*gnmi.PathElem, val interface{}) error {
+	e := elems[0]
+	if len(elems) == 1 {
+		if len(e.GetKey()) > 0 {
+			return fmt.Errorf("is a list entry, not a leaf")
+		}
+		container[e.GetName()] = val
+		return nil
+	}
+	if len(e.GetKey()) == 0 {
+		child, ok := container[e.GetName()].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			container[e.GetName()] = child
+		}
+		return setLeaf(child, elems[1:], val)
+	}
+	list, _ := container[e.GetName()].([]interface{})
+	for _, entry := range list {
+		if m, ok := entry.(map[string]interface{}); ok && hasKeys(m, e.GetKey()) {
+			return setLeaf(m, elems[1:], val)
+		}
+	}
+	entry := make(map[string]interface{})
+	for k, v := range e.GetKey() {
+		entry[k] = v
+	}
+	container[e.GetName()] = append(list, entry)
+	return setLeaf(entry, elems[1:], val)
+}
+
+//hasKeys returns true if the members of a list entry have the values of the keys
+func hasKeys(entry map[string]interface{}, keys map[string]string) bool {
+	for k, v := range keys {
+		if entry[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+//sortLists sorts the arrays of a decoded JSON value by the encoding of their elements, so that list entries compare regardless of order
+func sortLists(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			v[k] = sortLists(val)
+		}
+		return v
+	case []interface{}:
+		encoded := make([]string, len(v))
+		for i := range v {
+			v[i] = sortLists(v[i])
+			b, _ := json.Marshal(v[i])
+			encoded[i] = string(b)
+		}
+		sort.Sort(byEncoding{v, encoded})
+		return v
+	default:
+		return v
+	}
+}
+
+//byEncoding sorts the elements of a decoded JSON array by their encoding
+type byEncoding struct {
+	elems   []interface{}
+	encoded []string
+}
+
+func (b byEncoding) Len() int           { return len(b.elems) }
+func (b byEncoding) Less(i, j int) bool { return b.encoded[i] < b.encoded[j] }
+func (b byEncoding) Swap(i, j int) {
+	b.elems[i], b.elems[j] = b.elems[j], b.elems[i]
+	b.encoded[i], b.encoded[j] = b.encoded[j], b.encoded[i]
+}
+
+//hasWildcards returns true if the path has a wildcard element or key value
+func hasWildcards(path *gnmi.Path) bool {
+	for _, e := range path.GetElem() {
+		if e.GetName() == anyElem || e.GetName() == anyElems {
+			return true
+		}
+		for _, v := range e.GetKey() {
+			if v == anyElem {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//matchPath returns true if the path elements match the pattern elements, which may contain wildcards
+func matchPath(pattern, path []*gnmi.PathElem) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0].GetName() == anyElems {
+		for i := 0; i <= len(path); i++ {
+			if matchPath(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	return len(path) > 0 && matchElem(pattern[0], path[0]) && matchPath(pattern[1:], path[1:])
+}
+
+//matchElem returns true if the path element matches the pattern element, i.e. same name and keys, with "*" matching any name or key value
+func matchElem(pattern, elem *gnmi.PathElem) bool {
+	if pattern.GetName() != anyElem && pattern.GetName() != elem.GetName() {
+		return false
+	}
+	if len(pattern.GetKey()) != len(elem.GetKey()) {
+		return false
+	}
+	for k, v := range pattern.GetKey() {
+		actual, ok := elem.GetKey()[k]
+		if !ok || (v != anyElem && v != actual) {
+			return false
+		}
+	}
+	return true
+}
+
+//valuesEqual returns true if the typed values are equal.
+//Identityref values equal regardless of their module name, e.g. "openconfig-if-ethernet:SPEED_10GB" equals "SPEED_10GB".
+//A JSON or JSON_IETF encoded value equals another value if they decode to the same value,
+//e.g. json_ietf_val "\"10\"" equals uint_val 10 as JSON_IETF encodes 64-bit integers as strings.
+func valuesEqual(expected, actual *gnmi.TypedValue) bool {
+	if proto.Equal(expected, actual) {
+		return true
+	}
+	if exp, ok := expected.GetValue().(*gnmi.TypedValue_StringVal); ok {
+		if act, ok := actual.GetValue().(*gnmi.TypedValue_StringVal); ok {
+			return trimModule(exp.StringVal) == trimModule(act.StringVal)
+		}
+	}
+	if !isJSON(expected) && !isJSON(actual) {
+		return false
+	}
+	exp, ok := canonicalValue(expected)
+	if !ok {
+		return false
+	}
+	act, ok := canonicalValue(actual)
+	return ok && reflect.DeepEqual(exp, act)
+}
+
+//isJSON returns true if the typed value is JSON or JSON_IETF encoded
+func isJSON(tv *gnmi.TypedValue) bool {
+	switch tv.GetValue().(type) {
+	case *gnmi.TypedValue_JsonVal, *gnmi.TypedValue_JsonIetfVal:
+		return true
+	default:
+		return false
+	}
+}
+
+//canonicalValue returns a typed value in the form of a decoded JSON value.
+//Numbers become their decimal string, booleans stay booleans and module names are removed from JSON member names and identityref values.
+func canonicalValue(tv *gnmi.TypedValue) (interface{}, bool) {
+	switch v := tv.GetValue().(type) {
+	case *gnmi.TypedValue_JsonVal:
+		return decodeJSON(v.JsonVal)
+	case *gnmi.TypedValue_JsonIetfVal:
+		return decodeJSON(v.JsonIetfVal)
+	case *gnmi.TypedValue_StringVal:
+		return trimModule(v.StringVal), true
+	case *gnmi.TypedValue_AsciiVal:
+		return v.AsciiVal, true
+	case *gnmi.TypedValue_IntVal:
+		return strconv.FormatInt(v.IntVal, 10), true
+	case *gnmi.TypedValue_UintVal:
+		return strconv.FormatUint(v.UintVal, 10), true
+	case *gnmi.TypedValue_FloatVal:
+		return strconv.FormatFloat(float64(v.FloatVal), 'f', -1, 32), true
+	case *gnmi.TypedValue_DoubleVal:
+		return strconv.FormatFloat(v.DoubleVal, 'f', -1, 64), true
+	case *gnmi.TypedValue_DecimalVal:
+		return decimalString(v.DecimalVal), true
+	case *gnmi.TypedValue_BoolVal:
+		return v.BoolVal, true
+	default:
+		return nil, false
+	}
+}
+
+//decimalString returns the decimal value in the form "123.45"
+func decimalString(d *gnmi.Decimal64) string {
+	s := strconv.FormatInt(d.GetDigits(), 10)
+	precision := int(d.GetPrecision())
+	if precision == 0 {
+		return s
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	if len(s) <= precision {
+		s = strings.Repeat("0", precision-len(s)+1) + s
+	}
+	return sign + s[:len(s)-precision] + "." + s[len(s)-precision:]
+}
+
+//decodeJSON decodes a JSON value and normalizes it with normalizeJSON
+func decodeJSON(b []byte) (interface{}, bool) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		log.Warnf("Invalid JSON value %s: %v", b, err)
+		return nil, false
+	}
+	return normalizeJSON(v), true
+}
+
+//normalizeJSON turns numbers into their decimal string and removes module names from member names and identityref values
+func normalizeJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		return v.String()
+	case string:
+		return trimModule(v)
+	case map[string]interface{}:
+		m := make(map[string]interface{})
+		for k, val := range v {
+			if i := strings.LastIndex(k, ":"); i >= 0 {
+				k = k[i+1:]
+			}
+			m[k] = normalizeJSON(val)
+		}
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeJSON(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+//trimModule removes the module name of an identityref value, other values are returned unchanged
+func trimModule(s string) string {
+	if m := identityref.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+	return s
+}
@@ -0,0 +1,241 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package gnmi
+
+import (
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+//ifPath returns the path of a leaf of an interface state, or of the interfaces if name is empty
+func ifPath(name, leaf string) *gnmi.Path {
+	path := &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interfaces"}, {Name: "interface", Key: map[string]string{"name": name}}, {Name: "state"}, {Name: leaf}}}
+	if name == "" {
+		path.Elem[1].Key = nil
+	}
+	return path
+}
+
+func stringVal(s string) *gnmi.TypedValue {
+	return &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: s}}
+}
+
+func jsonIetfVal(s string) *gnmi.TypedValue {
+	return &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: []byte(s)}}
+}
+
+func notification(prefix *gnmi.Path, updates ...*gnmi.Update) *gnmi.Notification {
+	return &gnmi.Notification{Prefix: prefix, Update: updates}
+}
+
+func TestVerifyNotifications(t *testing.T) {
+	prefix := &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interfaces"}}}
+	relPath := &gnmi.Path{Elem: ifPath("eth1", "oper-status").GetElem()[1:]}
+	upEth1 := &gnmi.Update{Path: ifPath("eth1", "oper-status"), Val: stringVal("UP")}
+	upEth2 := &gnmi.Update{Path: ifPath("eth2", "oper-status"), Val: stringVal("UP")}
+	downEth2 := &gnmi.Update{Path: ifPath("eth2", "oper-status"), Val: stringVal("DOWN")}
+	mtuEth1 := &gnmi.Update{Path: ifPath("eth1", "mtu"), Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: 1500}}}
+	stateContainer := &gnmi.Path{Elem: ifPath("eth1", "").GetElem()[:3]}
+	tests := []struct {
+		name     string
+		expected []*gnmi.Notification
+		actual   []*gnmi.Notification
+		want     bool
+	}{
+		{name: "Equal", expected: []*gnmi.Notification{notification(nil, upEth1)}, actual: []*gnmi.Notification{notification(nil, upEth1)}, want: true},
+		{
+			name:     "Prefix And Full Path",
+			expected: []*gnmi.Notification{notification(prefix, &gnmi.Update{Path: relPath, Val: stringVal("UP")})},
+			actual:   []*gnmi.Notification{notification(nil, upEth1)},
+			want:     true,
+		},
+		{
+			name:     "Split Across Notifications",
+			expected: []*gnmi.Notification{notification(nil, upEth1, upEth2)},
+			actual:   []*gnmi.Notification{notification(nil, upEth2), notification(nil, upEth1)},
+			want:     true,
+		},
+		{name: "Different Value", expected: []*gnmi.Notification{notification(nil, upEth2)}, actual: []*gnmi.Notification{notification(nil, downEth2)}, want: false},
+		{name: "Missing Update", expected: []*gnmi.Notification{notification(nil, upEth1, upEth2)}, actual: []*gnmi.Notification{notification(nil, upEth1)}, want: false},
+		{name: "Unexpected Update", expected: []*gnmi.Notification{notification(nil, upEth1)}, actual: []*gnmi.Notification{notification(nil, upEth1, upEth2)}, want: false},
+		{name: "Empty Expected", expected: nil, actual: []*gnmi.Notification{notification(nil, upEth1)}, want: false},
+		{name: "Both Empty", want: true},
+		{
+			name:     "Key Wildcard",
+			expected: []*gnmi.Notification{notification(nil, &gnmi.Update{Path: ifPath("*", "oper-status"), Val: stringVal("UP")})},
+			actual:   []*gnmi.Notification{notification(nil, upEth1, upEth2)},
+			want:     true,
+		},
+		{
+			name:     "Key Wildcard Mismatch",
+			expected: []*gnmi.Notification{notification(nil, &gnmi.Update{Path: ifPath("*", "oper-status"), Val: stringVal("UP")})},
+			actual:   []*gnmi.Notification{notification(nil, upEth1, downEth2)},
+			want:     false,
+		},
+		{
+			name:     "Key Wildcard Without Match",
+			expected: []*gnmi.Notification{notification(nil, &gnmi.Update{Path: ifPath("*", "oper-status"), Val: stringVal("UP")})},
+			actual:   nil,
+			want:     false,
+		},
+		{
+			name: "Element Wildcards",
+			expected: []*gnmi.Notification{notification(nil,
+				&gnmi.Update{Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interfaces"}, {Name: "..."}, {Name: "oper-status"}}}, Val: stringVal("~any")},
+				&gnmi.Update{Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interfaces"}, {Name: "interface", Key: map[string]string{"name": "eth1"}}, {Name: "*"}, {Name: "mtu"}}}, Val: stringVal("~>= 1500")},
+			)},
+			actual: []*gnmi.Notification{notification(nil, upEth1, downEth2, mtuEth1)},
+			want:   true,
+		},
+		{
+			name:     "JSON IETF And Scalar",
+			expected: []*gnmi.Notification{notification(nil, &gnmi.Update{Path: ifPath("eth1", "mtu"), Val: jsonIetfVal(`"1500"`)}, &gnmi.Update{Path: ifPath("eth1", "oper-status"), Val: jsonIetfVal(`"UP"`)})},
+			actual:   []*gnmi.Notification{notification(nil, mtuEth1, upEth1)},
+			want:     true,
+		},
+		{
+			name:     "JSON IETF Module Names",
+			expected: []*gnmi.Notification{notification(nil, &gnmi.Update{Path: ifPath("eth1", "counters"), Val: jsonIetfVal(`{"in-pkts": 10, "out-pkts": "20"}`)})},
+			actual:   []*gnmi.Notification{notification(nil, &gnmi.Update{Path: ifPath("eth1", "counters"), Val: jsonIetfVal(`{"openconfig-interfaces:out-pkts": "20", "openconfig-interfaces:in-pkts": "10"}`)})},
+			want:     true,
+		},
+		{
+			name:     "JSON IETF Mismatch",
+			expected: []*gnmi.Notification{notification(nil, &gnmi.Update{Path: ifPath("eth1", "mtu"), Val: jsonIetfVal(`"9000"`)})},
+			actual:   []*gnmi.Notification{notification(nil, mtuEth1)},
+			want:     false,
+		},
+		{
+			name:     "JSON IETF And Double",
+			expected: []*gnmi.Notification{notification(nil, &gnmi.Update{Path: ifPath("eth1", "temperature"), Val: jsonIetfVal(`42.5`)})},
+			actual:   []*gnmi.Notification{notification(nil, &gnmi.Update{Path: ifPath("eth1", "temperature"), Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_DoubleVal{DoubleVal: 42.5}}})},
+			want:     true,
+		},
+		{
+			name:     "JSON IETF Identityref",
+			expected: []*gnmi.Notification{notification(nil, &gnmi.Update{Path: ifPath("eth1", "port-speed"), Val: jsonIetfVal(`"openconfig-if-ethernet:SPEED_10GB"`)})},
+			actual:   []*gnmi.Notification{notification(nil, &gnmi.Update{Path: ifPath("eth1", "port-speed"), Val: stringVal("SPEED_10GB")})},
+			want:     true,
+		},
+		{
+			name:     "Identityref",
+			expected: []*gnmi.Notification{notification(nil, &gnmi.Update{Path: ifPath("eth1", "port-speed"), Val: stringVal("SPEED_10GB")})},
+			actual:   []*gnmi.Notification{notification(nil, &gnmi.Update{Path: ifPath("eth1", "port-speed"), Val: stringVal("openconfig-if-ethernet:SPEED_10GB")})},
+			want:     true,
+		},
+		{
+			name:     "Identityref Mismatch",
+			expected: []*gnmi.Notification{notification(nil, &gnmi.Update{Path: ifPath("eth1", "port-speed"), Val: jsonIetfVal(`"openconfig-if-ethernet:SPEED_10GB"`)})},
+			actual:   []*gnmi.Notification{notification(nil, &gnmi.Update{Path: ifPath("eth1", "port-speed"), Val: stringVal("SPEED_100GB")})},
+			want:     false,
+		},
+		{
+			name:     "JSON IETF Container And Leaves",
+			expected: []*gnmi.Notification{notification(nil, &gnmi.Update{Path: stateContainer, Val: jsonIetfVal(`{"openconfig-interfaces:oper-status": "UP", "mtu": 1500}`)})},
+			actual:   []*gnmi.Notification{notification(nil, upEth1, mtuEth1)},
+			want:     true,
+		},
+		{
+			name:     "JSON IETF Container And Leaves Mismatch",
+			expected: []*gnmi.Notification{notification(nil, &gnmi.Update{Path: stateContainer, Val: jsonIetfVal(`{"oper-status": "DOWN", "mtu": 1500}`)})},
+			actual:   []*gnmi.Notification{notification(nil, upEth1, mtuEth1)},
+			want:     false,
+		},
+		{
+			name:     "JSON IETF Container And Extra Leaf",
+			expected: []*gnmi.Notification{notification(nil, &gnmi.Update{Path: stateContainer, Val: jsonIetfVal(`{"oper-status": "UP"}`)})},
+			actual:   []*gnmi.Notification{notification(nil, upEth1, mtuEth1)},
+			want:     false,
+		},
+		{
+			name:     "JSON IETF List And Leaves",
+			expected: []*gnmi.Notification{notification(nil, &gnmi.Update{Path: prefix, Val: jsonIetfVal(`{"interface": [{"name": "eth2", "state": {"oper-status": "UP"}}, {"name": "eth1", "state": {"oper-status": "UP"}}]}`)})},
+			actual:   []*gnmi.Notification{notification(nil, upEth1, upEth2)},
+			want:     true,
+		},
+		{
+			name:     "JSON IETF List Missing Entry",
+			expected: []*gnmi.Notification{notification(nil, &gnmi.Update{Path: prefix, Val: jsonIetfVal(`{"interface": [{"name": "eth1", "state": {"oper-status": "UP"}}]}`)})},
+			actual:   []*gnmi.Notification{notification(nil, upEth1, upEth2)},
+			want:     false,
+		},
+		{
+			name:     "Deletes",
+			expected: []*gnmi.Notification{{Prefix: prefix, Delete: []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "*"}}}}}}},
+			actual:   []*gnmi.Notification{{Delete: []*gnmi.Path{{Elem: ifPath("eth1", "").GetElem()[:2]}}}},
+			want:     true,
+		},
+		{
+			name:     "Unexpected Delete",
+			expected: nil,
+			actual:   []*gnmi.Notification{{Delete: []*gnmi.Path{ifPath("eth1", "mtu")}}},
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyNotifications(tt.expected, tt.actual); got.Passed() != tt.want {
+				t.Errorf("verifyNotifications() = %s, want passed %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	elems := func(names ...string) []*gnmi.PathElem {
+		var elems []*gnmi.PathElem
+		for _, name := range names {
+			elems = append(elems, &gnmi.PathElem{Name: name})
+		}
+		return elems
+	}
+	tests := []struct {
+		name    string
+		pattern []*gnmi.PathElem
+		path    []*gnmi.PathElem
+		want    bool
+	}{
+		{name: "Equal", pattern: elems("a", "b"), path: elems("a", "b"), want: true},
+		{name: "Different", pattern: elems("a", "b"), path: elems("a", "c"), want: false},
+		{name: "Shorter", pattern: elems("a"), path: elems("a", "b"), want: false},
+		{name: "Any Element", pattern: elems("a", "*", "c"), path: elems("a", "b", "c"), want: true},
+		{name: "Any Element Needs One", pattern: elems("a", "*", "c"), path: elems("a", "c"), want: false},
+		{name: "Any Elements None", pattern: elems("a", "...", "c"), path: elems("a", "c"), want: true},
+		{name: "Any Elements Several", pattern: elems("a", "...", "d"), path: elems("a", "b", "c", "d"), want: true},
+		{name: "Any Elements Trailing", pattern: elems("a", "..."), path: elems("a", "b", "c"), want: true},
+		{name: "Key Wildcard", pattern: ifPath("*", "mtu").GetElem(), path: ifPath("eth1", "mtu").GetElem(), want: true},
+		{name: "Missing Key", pattern: ifPath("*", "mtu").GetElem(), path: ifPath("", "mtu").GetElem(), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchPath(tt.pattern, tt.path); got != tt.want {
+				t.Errorf("matchPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalString(t *testing.T) {
+	tests := []struct {
+		name string
+		d    *gnmi.Decimal64
+		want string
+	}{
+		{name: "Integer", d: &gnmi.Decimal64{Digits: 12}, want: "12"},
+		{name: "Fraction", d: &gnmi.Decimal64{Digits: 1234, Precision: 2}, want: "12.34"},
+		{name: "Leading Zeros", d: &gnmi.Decimal64{Digits: 5, Precision: 3}, want: "0.005"},
+		{name: "Negative", d: &gnmi.Decimal64{Digits: -1234, Precision: 2}, want: "-12.34"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decimalString(tt.d); got != tt.want {
+				t.Errorf("decimalString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
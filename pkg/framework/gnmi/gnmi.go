@@ -17,6 +17,8 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/ygot/testutil"
+	"github.com/stratum/testvectors-runner/pkg/result"
+	"github.com/stratum/testvectors-runner/pkg/utils/transport"
 	tvb "github.com/stratum/testvectors/proto/target"
 	"google.golang.org/grpc"
 )
@@ -40,7 +42,11 @@ func connect(tg *tvb.Target) connection {
 	ctx := context.Background()
 	ctx, cancel := context.WithTimeout(ctx, CtxTimeout)
 	defer cancel()
-	conn, err := grpc.DialContext(ctx, tg.Address, grpc.WithInsecure())
+	dialOpt, err := transport.DialOption()
+	if err != nil {
+		return connection{connError: fmt.Errorf("cannot build transport credentials for target %s, %v", tg.Address, err)}
+	}
+	conn, err := grpc.DialContext(ctx, tg.Address, dialOpt)
 	if err != nil {
 		return connection{connError: fmt.Errorf("cannot dial target %s, %v", tg.Address, err)}
 	}
@@ -60,8 +66,8 @@ func (c *connection) Get(getReq *gnmi.GetRequest) *gnmi.GetResponse {
 	return resp
 }
 
-//Get calls gNMI client's Set RPC call and returns the SetResponse
-func (c *connection) Set(setReq *gnmi.SetRequest) *gnmi.SetResponse {
+//Set calls gNMI client's Set RPC call and returns the SetResponse, or the error returned by the switch
+func (c *connection) Set(setReq *gnmi.SetRequest) (*gnmi.SetResponse, error) {
 	log.Info("Sending set request")
 	log.Debugf("Set request: %s", setReq)
 	ctx := context.Background()
@@ -69,9 +75,9 @@ func (c *connection) Set(setReq *gnmi.SetRequest) *gnmi.SetResponse {
 	resp, err := c.client.Set(ctx, setReq)
 	if err != nil {
 		log.Errorf("Error sending set request: %v", err)
-		return nil
+		return nil, err
 	}
-	return resp
+	return resp, nil
 }
 
 //Subscribe calls gNMI client's Subscribe RPC call and returns struct with GNMI_SubscribeClient and SubscribeResponse channel
@@ -85,36 +91,30 @@ func (c *connection) Subscribe() subChan {
 	return subChan{client: client, responseChan: make(chan *gnmi.SubscribeResponse)}
 }
 
-//verifyGetResp compares two gnmi GetResponses and returns true or false
-func verifyGetResp(expected, actual *gnmi.GetResponse) bool {
+//verifyGetResp compares the notifications of two gnmi GetResponses by path and returns the result
+func verifyGetResp(expected, actual *gnmi.GetResponse) *result.Result {
 	switch {
 	case expected == nil && actual == nil:
 		log.Debug("Both get responses are empty")
-		return true
+		return result.Pass()
 	case expected == nil || actual == nil:
 		log.Warnf("Get responses are unequal\nExpected: %s\nActual  : %s\n", expected, actual)
-		return false
-	//testutil.GetResonseEqual returns true if first response is empty
-	//TODO: Add new utilities for comparing gnmi, p4rt responses
-	case testutil.GetResponseEqual(actual, expected, testutil.IgnoreTimestamp{}):
-		log.Info("Get responses are equal")
-		log.Debugf("Get response: %s\n", actual)
-		return true
+		return result.Mismatch("get responses are unequal", expected, actual)
 	default:
-		log.Warnf("Get responses are unequal\nExpected: %s\nActual  : %s\n", expected, actual)
-		return false
+		log.Debugf("Get response: %s\n", actual)
+		return verifyNotifications(expected.GetNotification(), actual.GetNotification())
 	}
 }
 
-//verifySetResp compares two gnmi SetResponses and returns true or false
-func verifySetResp(expected, actual *gnmi.SetResponse) bool {
+//verifySetResp compares two gnmi SetResponses and returns the result
+func verifySetResp(expected, actual *gnmi.SetResponse) *result.Result {
 	switch {
 	case expected == nil && actual == nil:
 		log.Debug("Both set responses are empty")
-		return true
+		return result.Pass()
 	case expected == nil || actual == nil:
 		log.Warnf("Set responses are unequal\nExpected: %s\nActual  : %s\n", expected, actual)
-		return false
+		return result.Mismatch("set responses are unequal", expected, actual)
 	default:
 		//FIXME
 		//resetting timestamp as a work around to ignore timestamp during comparison
@@ -123,35 +123,33 @@ func verifySetResp(expected, actual *gnmi.SetResponse) bool {
 		if proto.Equal(expected, actual) {
 			log.Info("Set responses are equal")
 			log.Debugf("Set response: %s", actual)
-			return true
+			return result.Pass()
 		}
 		log.Warnf("Set responses are unequal\nexpected: %s\nactual: %s\n", expected, actual)
-		return false
+		return result.Mismatch("set responses are unequal", expected, actual)
 	}
 }
 
-//verifySubResp compares two gnmi SubscribeResponses and returns true or false
-func verifySubResp(expected, actual *gnmi.SubscribeResponse) bool {
+//verifySubResp compares two gnmi SubscribeResponses and returns the result
+func verifySubResp(expected, actual *gnmi.SubscribeResponse) *result.Result {
 	switch {
 	case expected == nil && actual == nil:
 		log.Debug("Both set responses are empty")
-		return true
+		return result.Pass()
 	case expected == nil || actual == nil:
 		log.Warnf("Set responses are unequal\nExpected: %s\nActual  : %s\n", expected, actual)
-		return false
-	case actual.GetUpdate() != nil && testutil.NotificationSetEqual([]*gnmi.Notification{expected.GetUpdate()}, []*gnmi.Notification{actual.GetUpdate()}, testutil.IgnoreTimestamp{}):
-		log.Debug("In GetUpdate condition")
-		log.Info("Subscription responses are equal")
+		return result.Mismatch("subscription responses are unequal", expected, actual)
+	case expected.GetUpdate() != nil && actual.GetUpdate() != nil:
 		log.Debugf("Subscription response: %s\n", actual)
-		return true
+		return verifyNotifications([]*gnmi.Notification{expected.GetUpdate()}, []*gnmi.Notification{actual.GetUpdate()})
 	case testutil.SubscribeResponseEqual(expected, actual):
 		//continue
 		log.Info("Subscription responses are equal")
 		log.Debugf("Subscription response: %s\n", actual)
-		return true
+		return result.Pass()
 	default:
 		log.Warnf("Subscription responses are unequal expected:\n%s \nactual:\n%s", expected, actual)
-		return false
+		return result.Mismatch("subscription responses are unequal", expected, actual)
 	}
 
 }
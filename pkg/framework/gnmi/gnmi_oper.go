@@ -10,17 +10,21 @@ Package gnmi implements gnmi get, set and subscribe functions
 package gnmi
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/openconfig/gnmi/proto/gnmi"
 
 	"github.com/stratum/testvectors-runner/pkg/logger"
+	"github.com/stratum/testvectors-runner/pkg/result"
 	tg "github.com/stratum/testvectors/proto/target"
 )
 
 var (
 	log      = logger.NewLogger()
 	gnmiConn connection
+	//target of the last Init call, used by Reconnect
+	initTarget *tg.Target
 )
 
 const (
@@ -31,6 +35,7 @@ const (
 //Init starts a gNMI client connection to switch under test
 func Init(target *tg.Target) {
 	log.Debug("In gnmi_oper Init")
+	initTarget = target
 	gnmiConn = connect(target)
 	if gnmiConn.connError != nil {
 		log.Fatalf("Unable to get a gnmi client: %v", gnmiConn.connError)
@@ -43,55 +48,85 @@ func TearDown() {
 	gnmiConn.cancel()
 }
 
+//Reconnect closes the gNMI connection and opens a new one to the same target, e.g. after a switch reboot
+func Reconnect() {
+	log.Debug("In gnmi_oper Reconnect")
+	TearDown()
+	Init(initTarget)
+}
+
 //ProcessGetRequest sends a request to switch and compares the response
-func ProcessGetRequest(greq *gnmi.GetRequest, gresp *gnmi.GetResponse) bool {
+func ProcessGetRequest(greq *gnmi.GetRequest, gresp *gnmi.GetResponse) *result.Result {
 	resp := gnmiConn.Get(greq)
-	return verifyGetResp(gresp, resp)
+	res := verifyGetResp(gresp, resp)
+	recordNotifications(resp.GetNotification())
+	return res
 }
 
 //ProcessSetRequest sends a set request to switch and compares the response
-func ProcessSetRequest(sreq *gnmi.SetRequest, sresp *gnmi.SetResponse) bool {
-	resp := gnmiConn.Set(sreq)
-	return verifySetResp(sresp, resp)
+func ProcessSetRequest(sreq *gnmi.SetRequest, sresp *gnmi.SetResponse) *result.Result {
+	resp, err := gnmiConn.Set(sreq)
+	res := verifySetResp(sresp, resp)
+	if err != nil && !res.Passed() {
+		res.Err = fmt.Errorf("%v, set request failed: %v", res.Err, err)
+	}
+	return res
 }
 
-//ProcessSubscribeRequest opens a subscription channel to switch and processes the responses
-func ProcessSubscribeRequest(sreq *gnmi.SubscribeRequest, sresp []*gnmi.SubscribeResponse, firstRespChan chan struct{}, resultChan chan bool) {
+//ProcessUpdate sends a set request with a single update of the given path to switch.
+//It returns a failed result carrying the error if the switch rejected the update.
+func ProcessUpdate(path *gnmi.Path, val *gnmi.TypedValue) *result.Result {
+	sreq := &gnmi.SetRequest{Update: []*gnmi.Update{{Path: path, Val: val}}}
+	if _, err := gnmiConn.Set(sreq); err != nil {
+		return result.Failf("failed to set %s: %v", pathString(path), err)
+	}
+	return result.Pass()
+}
+
+//ProcessSubscribeRequest opens a subscription channel to switch and processes the responses.
+//ONCE subscriptions must be closed by the switch after the expected responses.
+//POLL subscriptions are polled after each sync_response which is followed by more expected responses.
+//Updates of STREAM subscriptions are verified against the SAMPLE or ON_CHANGE mode of their subscription.
+func ProcessSubscribeRequest(sreq *gnmi.SubscribeRequest, sresp []*gnmi.SubscribeResponse, firstRespChan chan struct{}, resultChan chan *result.Result) {
 	subcl := gnmiConn.Subscribe()
+	if subcl.client == nil {
+		resultChan <- result.Failf("failed to open subscription channel")
+		return
+	}
 	defer subcl.Close()
 	log.Debugf("Length of expected result: %d\n\n", len(sresp))
 	go subcl.Recv()
-	go verifySubRespList(subcl.responseChan, sresp, firstRespChan, resultChan)
+	go verifySubRespList(subcl, sreq, sresp, firstRespChan, resultChan)
 	if !subcl.Send(sreq) {
-		resultChan <- false
+		resultChan <- result.Failf("failed to send subscribe request")
 	}
 
+	//verifySubRespList times out by itself after SubTimeout, this is a backstop in case the target doesn't close a ONCE subscription
 	select {
-	case result := <-resultChan:
-		resultChan <- result
-	case <-time.After(SubTimeout):
+	case res := <-resultChan:
+		resultChan <- res
+	case <-time.After(SubTimeout + onceTimeout):
 		log.Error("Process subscribe request Timed out")
-		resultChan <- false
+		resultChan <- result.Failf("timed out after %s waiting for subscription responses", SubTimeout)
 	}
 
 }
 
-//verifySubResponses compares the responses from subscription channel with expected responses
-func verifySubRespList(actRespChan chan *gnmi.SubscribeResponse, expResp []*gnmi.SubscribeResponse, firstRespChan chan struct{}, resultChan chan bool) {
-	result, firstRespBool := true, true
+//verifySubRespList compares the responses from subscription channel with expected responses and verifies the subscription mode.
+//Responses are matched one by one in Exact match and in any order, ignoring unrelated updates, in In match.
+func verifySubRespList(subcl subChan, sreq *gnmi.SubscribeRequest, expResp []*gnmi.SubscribeResponse, firstRespChan chan struct{}, resultChan chan *result.Result) {
+	r := newSubscriptionReader(subcl.responseChan, firstRespChan)
 	if len(expResp) == 0 {
-		close(firstRespChan)
+		r.notifyFirst()
+	}
+	var res *result.Result
+	if subscribeMatch == In {
+		res = matchIn(subcl, sreq, expResp, r)
 	} else {
-		for _, exp := range expResp {
-			act := <-actRespChan
-			//Closing the first response channel to notify ProcessTelemetryExpectation to start processing actions
-			if firstRespBool {
-				firstRespBool = false
-				close(firstRespChan)
-			}
-			log.Debug("In for loop after receiving subResp")
-			result = verifySubResp(exp, act)
-		}
+		res = matchExact(subcl, sreq, expResp, r)
+	}
+	if sreq.GetSubscribe().GetMode() == gnmi.SubscriptionList_STREAM {
+		res.Add(verifyStreamUpdates(sreq, r.received))
 	}
-	resultChan <- result
+	resultChan <- res
 }
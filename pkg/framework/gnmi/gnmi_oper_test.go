@@ -12,6 +12,7 @@ import (
 
 	gpb "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/stratum/testvectors-runner/pkg/framework/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/result"
 	tg "github.com/stratum/testvectors/proto/target"
 )
 
@@ -110,7 +111,7 @@ func TestProcessGetRequest(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			gnmi.Init(tt.args.target)
-			if got := gnmi.ProcessGetRequest(tt.args.greq, tt.args.gresp); got != tt.want {
+			if got := gnmi.ProcessGetRequest(tt.args.greq, tt.args.gresp); got.Passed() != tt.want {
 				t.Errorf("ProcessGetRequest() = %v, want %v", got, tt.want)
 			}
 			gnmi.TearDown()
@@ -225,7 +226,7 @@ func TestProcessSetRequest(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := gnmi.ProcessSetRequest(tt.args.sreq, tt.args.sresp); got != tt.want {
+			if got := gnmi.ProcessSetRequest(tt.args.sreq, tt.args.sresp); got.Passed() != tt.want {
 				t.Errorf("ProcessSetRequest() = %v, want %v", got, tt.want)
 			}
 		})
@@ -234,7 +235,7 @@ func TestProcessSetRequest(t *testing.T) {
 }
 
 func TestProcessSubscribeRequest(t *testing.T) {
-	resultChan := make(chan bool)
+	resultChan := make(chan *result.Result)
 	emptySubReq := &gpb.SubscribeRequest{}
 	emptySubResp := []*gpb.SubscribeResponse{}
 	emptySetReq := &gpb.SetRequest{}
@@ -411,7 +412,7 @@ func TestProcessSubscribeRequest(t *testing.T) {
 		setreq1    *gpb.SetRequest
 		setreq2    *gpb.SetRequest
 		setresp    *gpb.SetResponse
-		resultChan chan bool
+		resultChan chan *result.Result
 	}
 	tests := []struct {
 		name string
@@ -465,10 +466,10 @@ func TestProcessSubscribeRequest(t *testing.T) {
 
 			select {
 			case <-firstRespChan:
-				if got := gnmi.ProcessSetRequest(tt.args.setreq1, tt.args.setresp) && gnmi.ProcessSetRequest(tt.args.setreq2, tt.args.setresp); got != true {
+				if got := gnmi.ProcessSetRequest(tt.args.setreq1, tt.args.setresp).Passed() && gnmi.ProcessSetRequest(tt.args.setreq2, tt.args.setresp).Passed(); got != true {
 					t.Errorf("ProcessSetRequest() = %v, want %v", got, tt.want)
 				}
-				if got := <-tt.args.resultChan; got != tt.want {
+				if got := <-tt.args.resultChan; got.Passed() != tt.want {
 					t.Errorf("ProcessSubscribeRequest() = %v, want %v", got, tt.want)
 				}
 			case <-time.After(gnmi.SubTimeout):
@@ -0,0 +1,202 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package gnmi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/result"
+)
+
+//onceTimeout for the target to close a ONCE subscription after the expected responses
+const onceTimeout = time.Second
+
+//sampleTolerance is the deviation of the interval between two samples of a path from the sample interval, relative to the sample interval
+var sampleTolerance = 0.2
+
+//SetSampleTolerance sets the deviation from the sample interval allowed between two samples of a path in SAMPLE subscriptions,
+//relative to the sample interval, e.g. 0.2 accepts samples of a 1s subscription 800ms to 1.2s apart
+func SetSampleTolerance(tolerance float64) {
+	sampleTolerance = tolerance
+}
+
+//receivedResponse is a subscription response with the time it was received
+type receivedResponse struct {
+	resp *gnmi.SubscribeResponse
+	at   time.Time
+}
+
+//sample is the last value of a path received in a STREAM subscription
+type sample struct {
+	val *gnmi.TypedValue
+	at  time.Time
+}
+
+//pollRequest returns the subscribe request triggering a poll of a POLL subscription
+func pollRequest() *gnmi.SubscribeRequest {
+	return &gnmi.SubscribeRequest{Request: &gnmi.SubscribeRequest_Poll{Poll: &gnmi.Poll{}}}
+}
+
+//needsPoll returns true if a poll has to be sent after receiving the response,
+//i.e. the subscription is in POLL mode, the response is a sync_response and more responses are expected.
+//The poll is sent right away, Test Vectors cannot choose when to poll.
+func needsPoll(sreq *gnmi.SubscribeRequest, resp *gnmi.SubscribeResponse, remaining int) bool {
+	return sreq.GetSubscribe().GetMode() == gnmi.SubscriptionList_POLL && resp.GetSyncResponse() && remaining > 0
+}
+
+//verifyOnceEnd checks that the target closes a ONCE subscription without sending more responses
+func verifyOnceEnd(respChan chan *gnmi.SubscribeResponse) *result.Result {
+	select {
+	case resp, ok := <-respChan:
+		if !ok {
+			log.Info("ONCE subscription closed by target")
+			return result.Pass()
+		}
+		log.Warnf("Unexpected response after the end of ONCE subscription: %s", resp)
+		return result.Failf("unexpected response after the expected responses of ONCE subscription: %s", resp)
+	case <-time.After(onceTimeout):
+		log.Warn("ONCE subscription not closed by target")
+		return result.Failf("ONCE subscription not closed by target %s after the expected responses", onceTimeout)
+	}
+}
+
+//verifyStreamUpdates checks the updates received on a STREAM subscription against the mode of their subscription.
+//Samples of a path in SAMPLE mode must arrive at the sample interval within the sample tolerance.
+//Updates of a path in ON_CHANGE mode, or in SAMPLE mode with suppress_redundant, must change its value, unless they are heartbeats.
+//The time of an update is the notification timestamp if set, otherwise the time it was received.
+//Only the received updates are checked, which end with the last expected response as the subscription is not read any further.
+func verifyStreamUpdates(sreq *gnmi.SubscribeRequest, received []receivedResponse) *result.Result {
+	subList := sreq.GetSubscribe()
+	if subList.GetMode() != gnmi.SubscriptionList_STREAM {
+		return result.Pass()
+	}
+	res := result.Pass()
+	last := make(map[string]sample)
+	for _, r := range received {
+		n := r.resp.GetUpdate()
+		if n == nil {
+			continue
+		}
+		at := r.at
+		if n.GetTimestamp() != 0 {
+			at = time.Unix(0, n.GetTimestamp())
+		}
+		for _, p := range n.GetDelete() {
+			delete(last, pathString(joinPath(n.GetPrefix(), p)))
+		}
+		for _, u := range n.GetUpdate() {
+			path := joinPath(n.GetPrefix(), u.GetPath())
+			sub := matchSubscription(subList, path)
+			if sub == nil {
+				continue
+			}
+			key := pathString(path)
+			prev, seen := last[key]
+			last[key] = sample{val: u.GetVal(), at: at}
+			if !seen {
+				continue
+			}
+			res.Add(verifySample(key, sub, prev, sample{val: u.GetVal(), at: at}))
+		}
+	}
+	return res
+}
+
+//verifySample checks an update of a path against the previous one and the mode of the subscription
+func verifySample(key string, sub *gnmi.Subscription, prev, cur sample) *result.Result {
+	elapsed := cur.at.Sub(prev.at)
+	switch {
+	case sub.GetMode() == gnmi.SubscriptionMode_ON_CHANGE, sub.GetMode() == gnmi.SubscriptionMode_SAMPLE && sub.GetSuppressRedundant():
+		if proto.Equal(prev.val, cur.val) && !isHeartbeat(sub, elapsed) {
+			log.Warnf("Update of %s without change of value %s", key, cur.val)
+			return result.Failf("update of %s without change of value %s", key, cur.val)
+		}
+	case sub.GetMode() == gnmi.SubscriptionMode_SAMPLE && sub.GetSampleInterval() != 0:
+		interval := time.Duration(sub.GetSampleInterval())
+		if !withinTolerance(elapsed, interval) {
+			log.Warnf("Samples of %s %s apart, expected %s", key, elapsed, interval)
+			return result.Failf("samples of %s %s apart, expected %s within %.0f%%", key, elapsed, interval, sampleTolerance*100)
+		}
+	}
+	return result.Pass()
+}
+
+//isHeartbeat returns true if an unchanged value sent after elapsed is a heartbeat of the subscription
+func isHeartbeat(sub *gnmi.Subscription, elapsed time.Duration) bool {
+	heartbeat := time.Duration(sub.GetHeartbeatInterval())
+	return heartbeat != 0 && (withinTolerance(elapsed, heartbeat) || elapsed > heartbeat)
+}
+
+//withinTolerance returns true if elapsed deviates from interval by at most the sample tolerance
+func withinTolerance(elapsed, interval time.Duration) bool {
+	deviation := elapsed - interval
+	if deviation < 0 {
+		deviation = -deviation
+	}
+	return float64(deviation) <= sampleTolerance*float64(interval)
+}
+
+//matchSubscription returns the subscription of the list covering the path, nil if there is none
+func matchSubscription(subList *gnmi.SubscriptionList, path *gnmi.Path) *gnmi.Subscription {
+	for _, sub := range subList.GetSubscription() {
+		if hasPrefix(path, joinPath(subList.GetPrefix(), sub.GetPath())) {
+			return sub
+		}
+	}
+	return nil
+}
+
+//hasPrefix returns true if the path starts with the elements of prefix.
+//Keys of prefix must be present in path with the same value or have the value "*".
+func hasPrefix(path, prefix *gnmi.Path) bool {
+	if len(prefix.GetElem()) > len(path.GetElem()) {
+		return false
+	}
+	for i, pe := range prefix.GetElem() {
+		e := path.GetElem()[i]
+		if pe.GetName() != e.GetName() && pe.GetName() != "*" {
+			return false
+		}
+		for k, v := range pe.GetKey() {
+			if v != "*" && e.GetKey()[k] != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+//joinPath returns the path with the elements of prefix prepended
+func joinPath(prefix, path *gnmi.Path) *gnmi.Path {
+	elems := append([]*gnmi.PathElem{}, prefix.GetElem()...)
+	return &gnmi.Path{Origin: prefix.GetOrigin(), Target: prefix.GetTarget(), Elem: append(elems, path.GetElem()...)}
+}
+
+//pathString returns the path in the string form /elem[key=value]/..., with keys sorted
+func pathString(path *gnmi.Path) string {
+	var sb strings.Builder
+	for _, e := range path.GetElem() {
+		sb.WriteString("/" + e.GetName())
+		var keys []string
+		for k := range e.GetKey() {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sb.WriteString(fmt.Sprintf("[%s=%s]", k, e.GetKey()[k]))
+		}
+	}
+	if sb.Len() == 0 {
+		return "/"
+	}
+	return sb.String()
+}
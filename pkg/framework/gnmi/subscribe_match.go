@@ -0,0 +1,258 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package gnmi
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/result"
+)
+
+//Match is the way subscription responses are matched against the expected responses
+type Match uint8
+
+//Match values for subscription responses
+const (
+	//Exact compares the responses one by one with the expected responses, in order
+	Exact = Match(0x1)
+	//In accepts the expected updates in any order and ignores unrelated updates
+	In = Match(0x2)
+)
+
+//subscribeMatch is the match type of telemetry expectations
+var subscribeMatch = Exact
+
+var (
+	errSubscriptionClosed  = errors.New("subscription closed by target")
+	errSubscriptionTimeout = fmt.Errorf("timed out after %s waiting for subscription responses", SubTimeout)
+)
+
+//SetSubscribeMatch sets how subscription responses are matched against the expected responses, either "exact" or "in"
+func SetSubscribeMatch(matchType string) error {
+	switch matchType {
+	case "exact":
+		subscribeMatch = Exact
+	case "in":
+		subscribeMatch = In
+	default:
+		return fmt.Errorf("unknown subscription match type: %s", matchType)
+	}
+	return nil
+}
+
+//subscriptionReader receives the responses of a subscription until the deadline and keeps them with the time they were received
+type subscriptionReader struct {
+	respChan      chan *gnmi.SubscribeResponse
+	firstRespChan chan struct{}
+	notified      bool
+	deadline      <-chan time.Time
+	received      []receivedResponse
+}
+
+func newSubscriptionReader(respChan chan *gnmi.SubscribeResponse, firstRespChan chan struct{}) *subscriptionReader {
+	return &subscriptionReader{respChan: respChan, firstRespChan: firstRespChan, deadline: time.After(SubTimeout)}
+}
+
+//notifyFirst closes the first response channel to notify ProcessTelemetryExpectation to start processing actions
+func (r *subscriptionReader) notifyFirst() {
+	if !r.notified {
+		r.notified = true
+		close(r.firstRespChan)
+	}
+}
+
+//next returns the next response of the subscription, or an error if the subscription ended or the deadline expired
+func (r *subscriptionReader) next() (*gnmi.SubscribeResponse, error) {
+	select {
+	case resp, ok := <-r.respChan:
+		r.notifyFirst()
+		if !ok {
+			return nil, errSubscriptionClosed
+		}
+		log.Debugf("Received subscription response: %s", resp)
+		r.received = append(r.received, receivedResponse{resp: resp, at: time.Now()})
+		return resp, nil
+	case <-r.deadline:
+		return nil, errSubscriptionTimeout
+	}
+}
+
+//matchExact compares the responses of the subscription one by one with the expected responses
+func matchExact(subcl subChan, sreq *gnmi.SubscribeRequest, expResp []*gnmi.SubscribeResponse, r *subscriptionReader) *result.Result {
+	res := result.Pass()
+	for i, exp := range expResp {
+		act, err := r.next()
+		if err != nil {
+			log.Warnf("%v after %d of %d expected responses", err, i, len(expResp))
+			res.Add(result.Failf("%v after %d of %d expected responses", err, i, len(expResp)))
+			return res
+		}
+		respRes := verifySubResp(exp, act)
+		if act.GetUpdate() != nil {
+			recordNotifications([]*gnmi.Notification{act.GetUpdate()})
+		}
+		res.Add(respRes)
+		if needsPoll(sreq, act, len(expResp)-i-1) && !subcl.Send(pollRequest()) {
+			res.Add(result.Failf("failed to send poll request"))
+		}
+	}
+	if sreq.GetSubscribe().GetMode() == gnmi.SubscriptionList_ONCE {
+		res.Add(verifyOnceEnd(r.respChan))
+	}
+	return res
+}
+
+//matchIn receives the responses of the subscription until all the expected updates arrived, in any order.
+//ONCE subscriptions are read until the target closes them, POLL subscriptions are polled after each sync_response while updates are missing.
+func matchIn(subcl subChan, sreq *gnmi.SubscribeRequest, expResp []*gnmi.SubscribeResponse, r *subscriptionReader) *result.Result {
+	m := newUnorderedMatcher(expResp)
+	mode := sreq.GetSubscribe().GetMode()
+	for mode == gnmi.SubscriptionList_ONCE || !m.done() {
+		act, err := r.next()
+		switch {
+		case err == errSubscriptionClosed && mode == gnmi.SubscriptionList_ONCE:
+			return m.result()
+		case err == errSubscriptionTimeout && mode == gnmi.SubscriptionList_ONCE && m.done():
+			log.Warn("ONCE subscription not closed by target")
+			return result.Failf("ONCE subscription not closed by target within %s", SubTimeout)
+		case err != nil:
+			log.Warnf("%v with %d expected updates missing", err, m.missing())
+			return result.Combine(result.Failf("%v", err), m.result())
+		}
+		m.add(act)
+		if mode == gnmi.SubscriptionList_POLL && act.GetSyncResponse() && !m.done() && !subcl.Send(pollRequest()) {
+			return result.Combine(result.Failf("failed to send poll request"), m.result())
+		}
+	}
+	return m.result()
+}
+
+//expectedUpdate is an update or delete of a path expected on a subscription
+type expectedUpdate struct {
+	path string
+	//pattern is the full path, which may contain wildcards
+	pattern *gnmi.Path
+	val     *gnmi.TypedValue
+	delete  bool
+	matched bool
+	//last value received for the path while the expected value was missing
+	last *gnmi.TypedValue
+}
+
+//unorderedMatcher matches subscription responses against the expected responses in any order, ignoring unrelated updates.
+//Expected notifications are split into their updates and deletes, which are matched one by one by path and value.
+type unorderedMatcher struct {
+	updates []*expectedUpdate
+	//expected responses other than notifications, e.g. sync_response
+	others []*gnmi.SubscribeResponse
+}
+
+func newUnorderedMatcher(expResp []*gnmi.SubscribeResponse) *unorderedMatcher {
+	m := &unorderedMatcher{}
+	for _, exp := range expResp {
+		n := exp.GetUpdate()
+		if n == nil {
+			m.others = append(m.others, exp)
+			continue
+		}
+		for _, p := range n.GetDelete() {
+			pattern := joinPath(n.GetPrefix(), p)
+			m.updates = append(m.updates, &expectedUpdate{path: pathString(pattern), pattern: pattern, delete: true})
+		}
+		for _, u := range n.GetUpdate() {
+			pattern := joinPath(n.GetPrefix(), u.GetPath())
+			m.updates = append(m.updates, &expectedUpdate{path: pathString(pattern), pattern: pattern, val: u.GetVal()})
+		}
+	}
+	return m
+}
+
+//add matches a received response against the missing expected responses
+func (m *unorderedMatcher) add(resp *gnmi.SubscribeResponse) {
+	n := resp.GetUpdate()
+	if n == nil {
+		for i, exp := range m.others {
+			if proto.Equal(exp, resp) {
+				m.others = append(m.others[:i], m.others[i+1:]...)
+				return
+			}
+		}
+		log.Debugf("Ignoring unexpected subscription response: %s", resp)
+		return
+	}
+	for _, p := range n.GetDelete() {
+		m.match(joinPath(n.GetPrefix(), p), nil, true)
+	}
+	for _, u := range n.GetUpdate() {
+		m.match(joinPath(n.GetPrefix(), u.GetPath()), u.GetVal(), false)
+	}
+}
+
+//match marks the first missing expected update or delete of the path with the value as arrived.
+//Expected paths may contain wildcards and expected values may be predicates.
+//Every value received becomes the last sample of the path, so that delta predicates apply to the change between consecutive updates.
+func (m *unorderedMatcher) match(fullPath *gnmi.Path, val *gnmi.TypedValue, delete bool) {
+	path := pathString(fullPath)
+	if !delete {
+		defer recordSample(path, val)
+	}
+	for _, exp := range m.updates {
+		if exp.matched || exp.delete != delete || !matchPath(exp.pattern.GetElem(), fullPath.GetElem()) {
+			continue
+		}
+		if delete || matchValue(path, exp.val, val) == nil {
+			log.Debugf("Expected update of %s arrived", path)
+			exp.matched = true
+			return
+		}
+		exp.last = val
+	}
+}
+
+//missing returns the number of expected updates, deletes and other responses which didn't arrive
+func (m *unorderedMatcher) missing() int {
+	missing := len(m.others)
+	for _, exp := range m.updates {
+		if !exp.matched {
+			missing++
+		}
+	}
+	return missing
+}
+
+//done returns true if all the expected responses arrived
+func (m *unorderedMatcher) done() bool {
+	return m.missing() == 0
+}
+
+//result returns a failure for each expected update, delete or other response which didn't arrive
+func (m *unorderedMatcher) result() *result.Result {
+	res := result.Pass()
+	for _, exp := range m.updates {
+		switch {
+		case exp.matched:
+		case exp.delete:
+			log.Warnf("Expected delete of %s never arrived", exp.path)
+			res.Add(result.Failf("expected delete of %s never arrived", exp.path))
+		case exp.last != nil:
+			log.Warnf("Expected update of %s never arrived\nexpected: %s\nlast: %s", exp.path, exp.val, exp.last)
+			res.Add(result.Mismatch(fmt.Sprintf("expected update of %s never arrived", exp.path), exp.val, exp.last))
+		default:
+			log.Warnf("Expected update of %s to %s never arrived", exp.path, exp.val)
+			res.Add(result.Failf("expected update of %s to %s never arrived", exp.path, exp.val))
+		}
+	}
+	for _, exp := range m.others {
+		log.Warnf("Expected response %s never arrived", exp)
+		res.Add(result.Failf("expected response %s never arrived", exp))
+	}
+	return res
+}
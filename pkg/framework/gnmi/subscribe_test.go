@@ -0,0 +1,314 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package gnmi
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/result"
+	"google.golang.org/grpc"
+)
+
+//counterPath returns a new path of an interface counter, not shared with messages marshaled by the fake target
+func counterPath() *gnmi.Path {
+	return &gnmi.Path{Elem: []*gnmi.PathElem{
+		{Name: "interfaces"},
+		{Name: "interface", Key: map[string]string{"name": "veth1"}},
+		{Name: "state"},
+		{Name: "counters"},
+		{Name: "in-unicast-pkts"},
+	}}
+}
+
+//counterUpdate returns a response with an update of the counter path
+func counterUpdate(value uint64, timestamp int64) *gnmi.SubscribeResponse {
+	return &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: &gnmi.Notification{
+		Timestamp: timestamp,
+		Update:    []*gnmi.Update{{Path: counterPath(), Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: value}}}},
+	}}}
+}
+
+func syncResponse() *gnmi.SubscribeResponse {
+	return &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true}}
+}
+
+func subscribeRequest(mode gnmi.SubscriptionList_Mode, sub *gnmi.Subscription) *gnmi.SubscribeRequest {
+	sub.Path = &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interfaces"}, {Name: "interface", Key: map[string]string{"name": "*"}}}}
+	return &gnmi.SubscribeRequest{Request: &gnmi.SubscribeRequest_Subscribe{Subscribe: &gnmi.SubscriptionList{
+		Mode:         mode,
+		Subscription: []*gnmi.Subscription{sub},
+	}}}
+}
+
+//fakeTarget answers subscriptions with the counter value, incremented on each poll.
+//ONCE subscriptions are closed after the sync_response unless keepOpen is set.
+type fakeTarget struct {
+	gnmi.UnimplementedGNMIServer
+	keepOpen bool
+}
+
+func (f *fakeTarget) Subscribe(stream gnmi.GNMI_SubscribeServer) error {
+	var value uint64
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		value++
+		if err := stream.Send(counterUpdate(value, 0)); err != nil {
+			return err
+		}
+		if err := stream.Send(syncResponse()); err != nil {
+			return err
+		}
+		if req.GetSubscribe().GetMode() == gnmi.SubscriptionList_ONCE && !f.keepOpen {
+			return nil
+		}
+	}
+}
+
+func TestProcessSubscribeModes(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      gnmi.SubscriptionList_Mode
+		matchType string
+		keepOpen  bool
+		expected  []*gnmi.SubscribeResponse
+		want      bool
+	}{
+		{name: "Once", mode: gnmi.SubscriptionList_ONCE, expected: []*gnmi.SubscribeResponse{counterUpdate(1, 0), syncResponse()}, want: true},
+		{name: "Once Not Closed", mode: gnmi.SubscriptionList_ONCE, keepOpen: true, expected: []*gnmi.SubscribeResponse{counterUpdate(1, 0), syncResponse()}, want: false},
+		{name: "Once Missing Sync", mode: gnmi.SubscriptionList_ONCE, expected: []*gnmi.SubscribeResponse{counterUpdate(1, 0)}, want: false},
+		{name: "Once Too Many Expected", mode: gnmi.SubscriptionList_ONCE, expected: []*gnmi.SubscribeResponse{counterUpdate(1, 0), syncResponse(), counterUpdate(2, 0)}, want: false},
+		{
+			name:     "Poll",
+			mode:     gnmi.SubscriptionList_POLL,
+			expected: []*gnmi.SubscribeResponse{counterUpdate(1, 0), syncResponse(), counterUpdate(2, 0), syncResponse(), counterUpdate(3, 0), syncResponse()},
+			want:     true,
+		},
+		{name: "Poll Wrong Value", mode: gnmi.SubscriptionList_POLL, expected: []*gnmi.SubscribeResponse{counterUpdate(1, 0), syncResponse(), counterUpdate(1, 0), syncResponse()}, want: false},
+		{name: "Once In", mode: gnmi.SubscriptionList_ONCE, matchType: "in", expected: []*gnmi.SubscribeResponse{syncResponse(), counterUpdate(1, 0)}, want: true},
+		{name: "Once In Not Closed", mode: gnmi.SubscriptionList_ONCE, matchType: "in", keepOpen: true, expected: []*gnmi.SubscribeResponse{counterUpdate(1, 0)}, want: false},
+		{name: "Once In Missing", mode: gnmi.SubscriptionList_ONCE, matchType: "in", expected: []*gnmi.SubscribeResponse{counterUpdate(2, 0)}, want: false},
+		{name: "Poll In", mode: gnmi.SubscriptionList_POLL, matchType: "in", expected: []*gnmi.SubscribeResponse{counterUpdate(3, 0)}, want: true},
+	}
+	defer func(conn connection) { gnmiConn = conn }(gnmiConn)
+	defer SetSubscribeMatch("exact")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := grpc.NewServer()
+			gnmi.RegisterGNMIServer(server, &fakeTarget{keepOpen: tt.keepOpen})
+			go server.Serve(lis)
+			defer server.Stop()
+			conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			gnmiConn = connection{client: gnmi.NewGNMIClient(conn)}
+			matchType := "exact"
+			if tt.matchType != "" {
+				matchType = tt.matchType
+			}
+			if err := SetSubscribeMatch(matchType); err != nil {
+				t.Fatal(err)
+			}
+
+			resultChan := make(chan *result.Result, 1)
+			sreq := subscribeRequest(tt.mode, &gnmi.Subscription{})
+			ProcessSubscribeRequest(sreq, tt.expected, make(chan struct{}), resultChan)
+			if got := <-resultChan; got.Passed() != tt.want {
+				t.Errorf("ProcessSubscribeRequest() = %s, want passed %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyStreamUpdates(t *testing.T) {
+	second := int64(time.Second)
+	sampleSub := &gnmi.Subscription{Mode: gnmi.SubscriptionMode_SAMPLE, SampleInterval: uint64(second)}
+	tests := []struct {
+		name     string
+		mode     gnmi.SubscriptionList_Mode
+		sub      *gnmi.Subscription
+		received []*gnmi.SubscribeResponse
+		want     bool
+	}{
+		{
+			name:     "Sample In Interval",
+			sub:      sampleSub,
+			received: []*gnmi.SubscribeResponse{counterUpdate(1, second), syncResponse(), counterUpdate(1, 2*second+second/10), counterUpdate(2, 3*second)},
+			want:     true,
+		},
+		{
+			name:     "Sample Too Early",
+			sub:      sampleSub,
+			received: []*gnmi.SubscribeResponse{counterUpdate(1, second), syncResponse(), counterUpdate(2, second+second/2)},
+			want:     false,
+		},
+		{
+			name:     "Sample Too Late",
+			sub:      sampleSub,
+			received: []*gnmi.SubscribeResponse{counterUpdate(1, second), syncResponse(), counterUpdate(2, 3*second)},
+			want:     false,
+		},
+		{
+			name:     "Sample Suppress Redundant",
+			sub:      &gnmi.Subscription{Mode: gnmi.SubscriptionMode_SAMPLE, SampleInterval: uint64(second), SuppressRedundant: true},
+			received: []*gnmi.SubscribeResponse{counterUpdate(1, second), counterUpdate(1, 2*second)},
+			want:     false,
+		},
+		{
+			name:     "On Change",
+			sub:      &gnmi.Subscription{Mode: gnmi.SubscriptionMode_ON_CHANGE},
+			received: []*gnmi.SubscribeResponse{counterUpdate(1, second), syncResponse(), counterUpdate(2, 2*second), counterUpdate(3, 2*second)},
+			want:     true,
+		},
+		{
+			name:     "On Change Without Change",
+			sub:      &gnmi.Subscription{Mode: gnmi.SubscriptionMode_ON_CHANGE},
+			received: []*gnmi.SubscribeResponse{counterUpdate(1, second), syncResponse(), counterUpdate(1, 2*second)},
+			want:     false,
+		},
+		{
+			name:     "On Change Heartbeat",
+			sub:      &gnmi.Subscription{Mode: gnmi.SubscriptionMode_ON_CHANGE, HeartbeatInterval: uint64(10 * second)},
+			received: []*gnmi.SubscribeResponse{counterUpdate(1, second), syncResponse(), counterUpdate(1, 11*second)},
+			want:     true,
+		},
+		{
+			name:     "Target Defined",
+			sub:      &gnmi.Subscription{Mode: gnmi.SubscriptionMode_TARGET_DEFINED},
+			received: []*gnmi.SubscribeResponse{counterUpdate(1, second), counterUpdate(1, second)},
+			want:     true,
+		},
+		{
+			name:     "Poll Mode",
+			mode:     gnmi.SubscriptionList_POLL,
+			sub:      &gnmi.Subscription{Mode: gnmi.SubscriptionMode_ON_CHANGE},
+			received: []*gnmi.SubscribeResponse{counterUpdate(1, second), syncResponse(), counterUpdate(1, 2*second)},
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received []receivedResponse
+			for _, resp := range tt.received {
+				received = append(received, receivedResponse{resp: resp, at: time.Now()})
+			}
+			if got := verifyStreamUpdates(subscribeRequest(tt.mode, tt.sub), received); got.Passed() != tt.want {
+				t.Errorf("verifyStreamUpdates() = %s, want passed %v", got, tt.want)
+			}
+		})
+	}
+}
+
+//otherUpdate returns a response with an update of a path other than the counter path
+func otherUpdate(value string) *gnmi.SubscribeResponse {
+	path := &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "system"}, {Name: "state"}, {Name: "hostname"}}}
+	return &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: &gnmi.Notification{
+		Update: []*gnmi.Update{{Path: path, Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: value}}}},
+	}}}
+}
+
+func TestUnorderedMatcher(t *testing.T) {
+	deleteResp := &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: &gnmi.Notification{
+		Prefix: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interfaces"}}},
+		Delete: []*gnmi.Path{{Elem: counterPath().GetElem()[1:]}},
+	}}}
+	tests := []struct {
+		name        string
+		expected    []*gnmi.SubscribeResponse
+		received    []*gnmi.SubscribeResponse
+		wantMissing int
+	}{
+		{
+			name:        "Any Order",
+			expected:    []*gnmi.SubscribeResponse{counterUpdate(1, 0), otherUpdate("switch1"), syncResponse()},
+			received:    []*gnmi.SubscribeResponse{syncResponse(), otherUpdate("switch1"), counterUpdate(1, 0)},
+			wantMissing: 0,
+		},
+		{
+			name:        "Extra Updates",
+			expected:    []*gnmi.SubscribeResponse{counterUpdate(2, 0)},
+			received:    []*gnmi.SubscribeResponse{otherUpdate("switch1"), counterUpdate(1, 0), syncResponse(), counterUpdate(2, 0)},
+			wantMissing: 0,
+		},
+		{
+			name:        "Repeated Update",
+			expected:    []*gnmi.SubscribeResponse{counterUpdate(1, 0), counterUpdate(1, 0)},
+			received:    []*gnmi.SubscribeResponse{counterUpdate(1, 0)},
+			wantMissing: 1,
+		},
+		{
+			name:        "Missing",
+			expected:    []*gnmi.SubscribeResponse{counterUpdate(2, 0), otherUpdate("switch1"), syncResponse()},
+			received:    []*gnmi.SubscribeResponse{counterUpdate(1, 0)},
+			wantMissing: 3,
+		},
+		{
+			name: "Wildcard",
+			expected: []*gnmi.SubscribeResponse{{Response: &gnmi.SubscribeResponse_Update{Update: &gnmi.Notification{
+				Update: []*gnmi.Update{{Path: ifPath("*", "oper-status"), Val: stringVal("UP")}},
+			}}}},
+			received: []*gnmi.SubscribeResponse{{Response: &gnmi.SubscribeResponse_Update{Update: &gnmi.Notification{
+				Update: []*gnmi.Update{{Path: ifPath("eth1", "oper-status"), Val: stringVal("DOWN")}, {Path: ifPath("eth2", "oper-status"), Val: stringVal("UP")}},
+			}}}},
+			wantMissing: 0,
+		},
+		{
+			name:        "Delete With Prefix",
+			expected:    []*gnmi.SubscribeResponse{deleteResp},
+			received:    []*gnmi.SubscribeResponse{{Response: &gnmi.SubscribeResponse_Update{Update: &gnmi.Notification{Delete: []*gnmi.Path{counterPath()}}}}},
+			wantMissing: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newUnorderedMatcher(tt.expected)
+			for _, resp := range tt.received {
+				m.add(resp)
+			}
+			if got := m.missing(); got != tt.wantMissing {
+				t.Errorf("missing() = %d, want %d", got, tt.wantMissing)
+			}
+			if got := m.result(); got.Passed() != (tt.wantMissing == 0) {
+				t.Errorf("result() = %s, want passed %v", got, tt.wantMissing == 0)
+			}
+		})
+	}
+}
+
+func TestPathString(t *testing.T) {
+	tests := []struct {
+		name string
+		path *gnmi.Path
+		want string
+	}{
+		{name: "Nil", path: nil, want: "/"},
+		{name: "Counter", path: counterPath(), want: "/interfaces/interface[name=veth1]/state/counters/in-unicast-pkts"},
+		{
+			name: "Sorted Keys",
+			path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "a", Key: map[string]string{"z": "1", "b": "2"}}}},
+			want: "/a[b=2][z=1]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pathString(tt.path); got != tt.want {
+				t.Errorf("pathString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
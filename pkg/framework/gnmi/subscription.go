@@ -29,8 +29,9 @@ func (s subChan) Close() {
 }
 
 //Recv runs a loop to continuously receive subscription responses from client and sends to specified channel.
-//This method is called as go routine.
+//The channel is closed when the subscription ends. This method is called as go routine.
 func (s subChan) Recv() {
+	defer close(s.responseChan)
 	for {
 		log.Debug("In Recv for loop")
 		subResp, err := s.client.Recv()
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package gnmi
+
+import (
+	"fmt"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/utils/predicate"
+)
+
+//getPredicate returns the predicate of an expected value, i.e. a string value starting with predicate.Prefix.
+//It returns nil if the value is an exact value.
+func getPredicate(tv *gnmi.TypedValue) (*predicate.Predicate, error) {
+	s, ok := tv.GetValue().(*gnmi.TypedValue_StringVal)
+	if !ok || !predicate.IsPredicate(s.StringVal) {
+		return nil, nil
+	}
+	return predicate.Parse(s.StringVal)
+}
+
+//hasPredicates returns true if any update of the notifications has a predicate as value
+func hasPredicates(notifications []*gnmi.Notification) bool {
+	for _, n := range notifications {
+		for _, u := range n.GetUpdate() {
+			if p, err := getPredicate(u.GetVal()); p != nil || err != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//predicateValue converts a scalar typed value to a value predicates are evaluated on
+func predicateValue(tv *gnmi.TypedValue) (predicate.Value, bool) {
+	switch v := tv.GetValue().(type) {
+	case *gnmi.TypedValue_IntVal:
+		return predicate.Number(float64(v.IntVal)), true
+	case *gnmi.TypedValue_UintVal:
+		return predicate.Number(float64(v.UintVal)), true
+	case *gnmi.TypedValue_FloatVal:
+		return predicate.Number(float64(v.FloatVal)), true
+	case *gnmi.TypedValue_DoubleVal:
+		return predicate.Number(v.DoubleVal), true
+	case *gnmi.TypedValue_DecimalVal:
+		return predicate.Number(float64(v.DecimalVal.GetDigits()) / pow10(v.DecimalVal.GetPrecision())), true
+	case *gnmi.TypedValue_StringVal:
+		return predicate.String(v.StringVal), true
+	case *gnmi.TypedValue_AsciiVal:
+		return predicate.String(v.AsciiVal), true
+	case *gnmi.TypedValue_BoolVal:
+		return predicate.String(fmt.Sprint(v.BoolVal)), true
+	default:
+		return predicate.Value{}, false
+	}
+}
+
+//pow10 returns 10 to the power of n
+func pow10(n uint32) float64 {
+	f := 1.0
+	for i := uint32(0); i < n; i++ {
+		f *= 10
+	}
+	return f
+}
+
+//sampleKey returns the key of the samples of a path used by delta predicates
+func sampleKey(path string) string {
+	return "gnmi " + path
+}
+
+//recordSample keeps a scalar value received for the path as its last sample, used by later delta predicates
+func recordSample(path string, actual *gnmi.TypedValue) {
+	if v, ok := predicateValue(actual); ok {
+		predicate.Record(sampleKey(path), v)
+	}
+}
+
+//recordNotifications keeps the scalar values of the updates of received notifications as samples
+func recordNotifications(notifications []*gnmi.Notification) {
+	for _, n := range notifications {
+		for _, u := range n.GetUpdate() {
+			recordSample(pathString(joinPath(n.GetPrefix(), u.GetPath())), u.GetVal())
+		}
+	}
+}
+
+//matchValue returns an error if the actual value of the path doesn't equal the expected value or satisfy its predicate.
+//Delta predicates are evaluated against the last sample of the path.
+func matchValue(path string, expected, actual *gnmi.TypedValue) error {
+	p, err := getPredicate(expected)
+	if err != nil {
+		return err
+	}
+	if p != nil {
+		v, ok := predicateValue(actual)
+		if !ok {
+			return fmt.Errorf("value %s of %s is not supported by predicate %s", actual, path, p)
+		}
+		var prev *predicate.Value
+		if last, ok := predicate.Last(sampleKey(path)); ok {
+			prev = &last
+		}
+		if err := p.Match(v, prev); err != nil {
+			return fmt.Errorf("value of %s: %v", path, err)
+		}
+		return nil
+	}
+	if !valuesEqual(expected, actual) {
+		return fmt.Errorf("value of %s: expected %s, actual %s", path, expected, actual)
+	}
+	return nil
+}
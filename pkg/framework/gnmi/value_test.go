@@ -0,0 +1,101 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package gnmi
+
+import (
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/utils/predicate"
+)
+
+//counterPredicate returns a response expecting the counter path to satisfy the predicate
+func counterPredicate(p string) *gnmi.SubscribeResponse {
+	return &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: &gnmi.Notification{
+		Update: []*gnmi.Update{{Path: counterPath(), Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: p}}}},
+	}}}
+}
+
+func TestVerifyGetRespPredicates(t *testing.T) {
+	defer predicate.Reset()
+	getResp := func(resps ...*gnmi.SubscribeResponse) *gnmi.GetResponse {
+		gr := &gnmi.GetResponse{}
+		for _, resp := range resps {
+			gr.Notification = append(gr.Notification, resp.GetUpdate())
+		}
+		return gr
+	}
+	//steps run in order, as delta predicates depend on the samples recorded by the previous steps
+	tests := []struct {
+		name     string
+		expected *gnmi.GetResponse
+		actual   *gnmi.GetResponse
+		want     bool
+	}{
+		{name: "Any", expected: getResp(counterPredicate("~any")), actual: getResp(counterUpdate(100, 1)), want: true},
+		{name: "Delta", expected: getResp(counterPredicate("~delta >= 10")), actual: getResp(counterUpdate(110, 2)), want: true},
+		{name: "Delta Too Small", expected: getResp(counterPredicate("~delta >= 10")), actual: getResp(counterUpdate(115, 3)), want: false},
+		{name: "Delta From Last Sample", expected: getResp(counterPredicate("~delta == 5")), actual: getResp(counterUpdate(120, 3)), want: true},
+		{name: "Exact Value Records Sample", expected: getResp(counterUpdate(120, 0)), actual: getResp(counterUpdate(120, 4)), want: true},
+		{name: "Delta After Exact Value", expected: getResp(counterPredicate("~delta == 5")), actual: getResp(counterUpdate(125, 5)), want: true},
+		{name: "Mixed With Exact Value", expected: getResp(counterPredicate("~> 0"), otherUpdate("switch1")), actual: getResp(counterUpdate(125, 6), otherUpdate("switch1")), want: true},
+		{name: "Exact Value Mismatch", expected: getResp(counterPredicate("~> 0"), otherUpdate("switch1")), actual: getResp(counterUpdate(125, 7), otherUpdate("switch2")), want: false},
+		{name: "Missing Update", expected: getResp(counterPredicate("~any"), otherUpdate("switch1")), actual: getResp(counterUpdate(125, 8)), want: false},
+		{name: "Unexpected Update", expected: getResp(counterPredicate("~any")), actual: getResp(counterUpdate(125, 9), otherUpdate("switch1")), want: false},
+		{name: "Regex", expected: getResp(counterPredicate("~any"), otherUpdate("~regex ^switch[0-9]+$")), actual: getResp(counterUpdate(125, 10), otherUpdate("switch12")), want: true},
+		{name: "Invalid Predicate", expected: getResp(counterPredicate("~more 10")), actual: getResp(counterUpdate(125, 11)), want: false},
+	}
+	for _, tt := range tests {
+		got := verifyGetResp(tt.expected, tt.actual)
+		if got.Passed() != tt.want {
+			t.Errorf("%s: verifyGetResp() = %s, want passed %v", tt.name, got, tt.want)
+		}
+		//as ProcessGetRequest does
+		recordNotifications(tt.actual.GetNotification())
+	}
+}
+
+func TestUnorderedMatcherPredicates(t *testing.T) {
+	defer predicate.Reset()
+	predicate.Record(sampleKey(pathString(counterPath())), predicate.Number(100))
+	m := newUnorderedMatcher([]*gnmi.SubscribeResponse{counterPredicate("~delta >= 10")})
+	//the delta applies to consecutive updates, as every update received becomes the last sample
+	for _, value := range []uint64{105, 114, 120} {
+		m.add(counterUpdate(value, 0))
+		if m.done() {
+			t.Fatalf("done() = true after %d, want false", value)
+		}
+	}
+	m.add(counterUpdate(130, 0))
+	if !m.done() {
+		t.Errorf("done() = false after 130, want true")
+	}
+	m.add(counterUpdate(131, 0))
+	if last, _ := predicate.Last(sampleKey(pathString(counterPath()))); last != predicate.Number(131) {
+		t.Errorf("last sample = %s, want 131", last)
+	}
+}
+
+func TestPredicateValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		val    *gnmi.TypedValue
+		want   predicate.Value
+		wantOK bool
+	}{
+		{name: "Uint", val: &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: 10}}, want: predicate.Number(10), wantOK: true},
+		{name: "Double", val: &gnmi.TypedValue{Value: &gnmi.TypedValue_DoubleVal{DoubleVal: 2.5}}, want: predicate.Number(2.5), wantOK: true},
+		{name: "Decimal", val: &gnmi.TypedValue{Value: &gnmi.TypedValue_DecimalVal{DecimalVal: &gnmi.Decimal64{Digits: 25, Precision: 1}}}, want: predicate.Number(2.5), wantOK: true},
+		{name: "String", val: &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "UP"}}, want: predicate.String("UP"), wantOK: true},
+		{name: "Bytes", val: &gnmi.TypedValue{Value: &gnmi.TypedValue_BytesVal{BytesVal: []byte{1}}}},
+	}
+	for _, tt := range tests {
+		if got, ok := predicateValue(tt.val); got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: predicateValue() = %s, %v, want %s, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+/*
+Package gnoi implements gnoi connection and target management functions
+*/
+package gnoi
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/openconfig/gnoi/cert"
+	"github.com/openconfig/gnoi/file"
+	"github.com/openconfig/gnoi/system"
+	"github.com/openconfig/gnoi/types"
+	"github.com/stratum/testvectors-runner/pkg/utils/transport"
+	tvb "github.com/stratum/testvectors/proto/target"
+	"google.golang.org/grpc"
+)
+
+//chunkSize is the size of the file contents sent in each put request
+const chunkSize = 64 * 1024
+
+//Connection struct stores the gNOI client connection, service clients and cancel function.
+type connection struct {
+	conn      *grpc.ClientConn
+	system    system.SystemClient
+	file      file.FileClient
+	cert      cert.CertificateManagementClient
+	connError error
+	cancel    context.CancelFunc
+}
+
+//connect starts a gRPC connection to the target specified.
+//It returns connection struct with gRPC client connection, gNOI service clients, close function
+//If an error is encountered during opening the connection, it is returned.
+func connect(tg *tvb.Target) connection {
+	log.Debug("In gnoi connect")
+	if tg.Address == "" {
+		return connection{connError: errors.New("an address must be specified")}
+	}
+	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(ctx, CtxTimeout)
+	defer cancel()
+	dialOpt, err := transport.DialOption()
+	if err != nil {
+		return connection{connError: fmt.Errorf("cannot build transport credentials for target %s, %v", tg.Address, err)}
+	}
+	conn, err := grpc.DialContext(ctx, tg.Address, dialOpt)
+	if err != nil {
+		return connection{connError: fmt.Errorf("cannot dial target %s, %v", tg.Address, err)}
+	}
+	return connection{
+		conn:   conn,
+		system: system.NewSystemClient(conn),
+		file:   file.NewFileClient(conn),
+		cert:   cert.NewCertificateManagementClient(conn),
+		cancel: func() { conn.Close() },
+	}
+}
+
+//Time calls gNOI System client's Time RPC and returns the time of the target
+func (c *connection) Time() (*system.TimeResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CtxTimeout)
+	defer cancel()
+	return c.system.Time(ctx, &system.TimeRequest{})
+}
+
+//Reboot calls gNOI System client's Reboot RPC
+func (c *connection) Reboot(req *system.RebootRequest) error {
+	log.Info("Sending reboot request")
+	log.Debugf("Reboot request: %s", req)
+	ctx, cancel := context.WithTimeout(context.Background(), CtxTimeout)
+	defer cancel()
+	_, err := c.system.Reboot(ctx, req)
+	return err
+}
+
+//RebootStatus calls gNOI System client's RebootStatus RPC and returns the status of the pending reboot
+func (c *connection) RebootStatus() (*system.RebootStatusResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CtxTimeout)
+	defer cancel()
+	return c.system.RebootStatus(ctx, &system.RebootStatusRequest{})
+}
+
+//Get calls gNOI File client's Get RPC and returns the contents of the remote file.
+//If the target sends a hash of the contents, it is verified.
+func (c *connection) Get(remoteFile string) ([]byte, error) {
+	log.Infof("Getting file %s", remoteFile)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.file.Get(ctx, &file.GetRequest{RemoteFile: remoteFile})
+	if err != nil {
+		return nil, err
+	}
+	var contents bytes.Buffer
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return contents.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if resp.GetHash() == nil {
+			contents.Write(resp.GetContents())
+			continue
+		}
+		h, err := newHash(resp.GetHash().GetMethod())
+		if err != nil {
+			return nil, err
+		}
+		h.Write(contents.Bytes())
+		if !bytes.Equal(h.Sum(nil), resp.GetHash().GetHash()) {
+			return nil, fmt.Errorf("%s hash of file %s does not match its contents", resp.GetHash().GetMethod(), remoteFile)
+		}
+	}
+}
+
+//Put calls gNOI File client's Put RPC to write the contents to the remote file with the given permissions
+func (c *connection) Put(remoteFile string, permissions uint32, contents []byte) error {
+	log.Infof("Putting file %s", remoteFile)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.file.Put(ctx)
+	if err != nil {
+		return err
+	}
+	open := &file.PutRequest_Open{Open: &file.PutRequest_Details{RemoteFile: remoteFile, Permissions: permissions}}
+	if err := stream.Send(&file.PutRequest{Request: open}); err != nil {
+		return err
+	}
+	for start := 0; start < len(contents); start += chunkSize {
+		end := start + chunkSize
+		if end > len(contents) {
+			end = len(contents)
+		}
+		if err := stream.Send(&file.PutRequest{Request: &file.PutRequest_Contents{Contents: contents[start:end]}}); err != nil {
+			return err
+		}
+	}
+	sum := md5.Sum(contents)
+	hashType := &types.HashType{Method: types.HashType_MD5, Hash: sum[:]}
+	if err := stream.Send(&file.PutRequest{Request: &file.PutRequest_Hash{Hash: hashType}}); err != nil {
+		return err
+	}
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+//Stat calls gNOI File client's Stat RPC and returns the information of the files in path
+func (c *connection) Stat(path string) ([]*file.StatInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CtxTimeout)
+	defer cancel()
+	resp, err := c.file.Stat(ctx, &file.StatRequest{Path: path})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetStats(), nil
+}
+
+//GetCertificates calls gNOI CertificateManagement client's GetCertificates RPC and returns the installed certificates
+func (c *connection) GetCertificates() ([]*cert.CertificateInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CtxTimeout)
+	defer cancel()
+	resp, err := c.cert.GetCertificates(ctx, &cert.GetCertificatesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetCertificateInfo(), nil
+}
+
+//newHash returns the hash function of the gNOI hash method
+func newHash(method types.HashType_HashMethod) (hash.Hash, error) {
+	switch method {
+	case types.HashType_MD5:
+		return md5.New(), nil
+	case types.HashType_SHA256:
+		return sha256.New(), nil
+	case types.HashType_SHA512:
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported hash method %s", method)
+	}
+}
+
+//dialBlocking tries to open a gRPC connection to the target and blocks until it succeeds or timeout expires.
+//It returns true if the target is reachable.
+func dialBlocking(tg *tvb.Target, timeout time.Duration) bool {
+	dialOpt, err := transport.DialOption()
+	if err != nil {
+		log.Errorf("Cannot build transport credentials for target %s: %v", tg.Address, err)
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, tg.Address, dialOpt, grpc.WithBlock())
+	if err != nil {
+		log.Debugf("Target %s is not reachable: %v", tg.Address, err)
+		return false
+	}
+	conn.Close()
+	return true
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+/*
+Package gnoi implements gnoi connection and target management functions
+*/
+package gnoi
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/openconfig/gnoi/system"
+	"github.com/stratum/testvectors-runner/pkg/framework/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/framework/p4rt"
+	"github.com/stratum/testvectors-runner/pkg/logger"
+	"github.com/stratum/testvectors-runner/pkg/result"
+	tg "github.com/stratum/testvectors/proto/target"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	log      = logger.NewLogger()
+	gnoiConn connection
+	target   *tg.Target
+	//reconnectFuncs re-establish the connections of the other frameworks once the target is back
+	reconnectFuncs = []func(){gnmi.Reconnect, p4rt.Reconnect}
+)
+
+const (
+	//CtxTimeout for contexts
+	CtxTimeout = 3 * time.Second
+	//RetryInterval between attempts to reach the target
+	RetryInterval = 1 * time.Second
+	//RebootTimeout for the target to go down and come back after a reboot request
+	RebootTimeout = 5 * time.Minute
+)
+
+//Init starts a gNOI client connection with System, File and CertificateManagement clients to switch under test
+func Init(tg *tg.Target) {
+	log.Debug("In gnoi_oper Init")
+	target = tg
+	gnoiConn = connect(tg)
+	if gnoiConn.connError != nil {
+		log.Fatalf("Unable to get a gnoi client: %v", gnoiConn.connError)
+	}
+}
+
+//TearDown closes the gNOI connection
+func TearDown() {
+	log.Debug("In gnoi_oper TearDown")
+	if gnoiConn.cancel != nil {
+		gnoiConn.cancel()
+	}
+}
+
+//WaitForTarget waits until the switch under test is reachable again, e.g. after a reboot, and re-establishes
+//the gNOI, gNMI and P4Runtime connections. It returns false if the target is not back within timeout.
+func WaitForTarget(timeout time.Duration) bool {
+	log.Infof("Waiting for target %s to come back", target.GetAddress())
+	deadline := time.Now().Add(timeout)
+	for !dialBlocking(target, CtxTimeout) {
+		if time.Now().After(deadline) {
+			log.Errorf("Timed out waiting for target %s", target.GetAddress())
+			return false
+		}
+		time.Sleep(RetryInterval)
+	}
+	log.Infof("Target %s is back, reconnecting", target.GetAddress())
+	TearDown()
+	Init(target)
+	for _, reconnect := range reconnectFuncs {
+		reconnect()
+	}
+	return true
+}
+
+//ProcessRebootRequest sends a reboot request to switch, waits until it goes down and, unless the switch is
+//halted or powered down, waits until it is back and reachable over gNOI again within timeout
+func ProcessRebootRequest(req *system.RebootRequest, timeout time.Duration) *result.Result {
+	deadline := time.Now().Add(timeout)
+	//the switch may close the connection before responding
+	if err := gnoiConn.Reboot(req); err != nil && status.Code(err) != codes.Unavailable {
+		return result.Failf("reboot request failed: %v", err)
+	}
+	for {
+		if _, err := gnoiConn.Time(); err != nil {
+			log.Infof("Target %s went down: %v", target.GetAddress(), err)
+			break
+		}
+		if time.Now().After(deadline) {
+			return result.Failf("target %s did not go down within %v after reboot request", target.GetAddress(), timeout)
+		}
+		time.Sleep(RetryInterval)
+	}
+	switch req.GetMethod() {
+	case system.RebootMethod_HALT, system.RebootMethod_POWERDOWN:
+		return result.Pass()
+	}
+	if !WaitForTarget(time.Until(deadline)) {
+		return result.Failf("target %s did not come back within %v after reboot request", target.GetAddress(), timeout)
+	}
+	if _, err := gnoiConn.Time(); err != nil {
+		return result.Failf("target %s is not responding after reboot: %v", target.GetAddress(), err)
+	}
+	return result.Pass()
+}
+
+//ProcessRebootStatus checks that a reboot is pending or not on switch
+func ProcessRebootStatus(active bool) *result.Result {
+	resp, err := gnoiConn.RebootStatus()
+	if err != nil {
+		return result.Failf("reboot status request failed: %v", err)
+	}
+	if resp.GetActive() != active {
+		return result.Mismatch("reboot status mismatch", active, resp.GetActive())
+	}
+	return result.Pass()
+}
+
+//ProcessFilePut writes the contents to the remote file on switch
+func ProcessFilePut(remoteFile string, permissions uint32, contents []byte) *result.Result {
+	if err := gnoiConn.Put(remoteFile, permissions, contents); err != nil {
+		return result.Failf("failed to put file %s: %v", remoteFile, err)
+	}
+	return result.Pass()
+}
+
+//ProcessFileGet reads the remote file from switch and compares its contents
+func ProcessFileGet(remoteFile string, expected []byte) *result.Result {
+	contents, err := gnoiConn.Get(remoteFile)
+	if err != nil {
+		return result.Failf("failed to get file %s: %v", remoteFile, err)
+	}
+	if !bytes.Equal(expected, contents) {
+		return result.Mismatch("file contents mismatch", string(expected), string(contents))
+	}
+	return result.Pass()
+}
+
+//ProcessFileStat checks that the path exists on switch
+func ProcessFileStat(path string) *result.Result {
+	stats, err := gnoiConn.Stat(path)
+	if err != nil {
+		return result.Failf("failed to stat %s: %v", path, err)
+	}
+	if len(stats) == 0 {
+		return result.Failf("no file found in %s", path)
+	}
+	return result.Pass()
+}
+
+//ProcessGetCertificates checks that the certificates with the given IDs are installed on switch
+func ProcessGetCertificates(certIDs []string) *result.Result {
+	infos, err := gnoiConn.GetCertificates()
+	if err != nil {
+		return result.Failf("get certificates request failed: %v", err)
+	}
+	installed := make(map[string]bool)
+	for _, info := range infos {
+		installed[info.GetCertificateId()] = true
+	}
+	for _, id := range certIDs {
+		if !installed[id] {
+			return result.Failf("certificate %s is not installed", id)
+		}
+	}
+	return result.Pass()
+}
@@ -0,0 +1,27 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package gnoi_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stratum/testvectors-runner/pkg/framework/gnoi"
+	tg "github.com/stratum/testvectors/proto/target"
+)
+
+var (
+	InvalidTestTarget = &tg.Target{Address: "localhost:50012"}
+)
+
+func TestWaitForTarget(t *testing.T) {
+	gnoi.Init(InvalidTestTarget)
+	defer gnoi.TearDown()
+	if got := gnoi.WaitForTarget(2 * time.Second); got != false {
+		t.Errorf("WaitForTarget() = %v, want %v", got, false)
+	}
+}
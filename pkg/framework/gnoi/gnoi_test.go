@@ -0,0 +1,212 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package gnoi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnoi/cert"
+	"github.com/openconfig/gnoi/file"
+	"github.com/openconfig/gnoi/system"
+	"github.com/openconfig/gnoi/types"
+	tg "github.com/stratum/testvectors/proto/target"
+	"google.golang.org/grpc"
+)
+
+//fakeSwitch serves the gNOI services and restarts its server after a reboot request
+type fakeSwitch struct {
+	system.UnimplementedSystemServer
+	file.UnimplementedFileServer
+	cert.UnimplementedCertificateManagementServer
+	addr    string
+	down    time.Duration
+	mu      sync.Mutex
+	server  *grpc.Server
+	reboots int
+	files   map[string][]byte
+}
+
+func (s *fakeSwitch) start() error {
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer()
+	system.RegisterSystemServer(server, s)
+	file.RegisterFileServer(server, s)
+	cert.RegisterCertificateManagementServer(server, s)
+	s.mu.Lock()
+	s.server = server
+	s.mu.Unlock()
+	go server.Serve(lis)
+	return nil
+}
+
+func (s *fakeSwitch) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.server.Stop()
+}
+
+func (s *fakeSwitch) Time(context.Context, *system.TimeRequest) (*system.TimeResponse, error) {
+	return &system.TimeResponse{Time: uint64(time.Now().UnixNano())}, nil
+}
+
+func (s *fakeSwitch) Reboot(context.Context, *system.RebootRequest) (*system.RebootResponse, error) {
+	s.mu.Lock()
+	s.reboots++
+	s.mu.Unlock()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		s.stop()
+		time.Sleep(s.down)
+		if err := s.start(); err != nil {
+			log.Errorf("Cannot restart fake switch: %v", err)
+		}
+	}()
+	return &system.RebootResponse{}, nil
+}
+
+func (s *fakeSwitch) Put(stream file.File_PutServer) error {
+	var name string
+	var contents bytes.Buffer
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			s.mu.Lock()
+			s.files[name] = contents.Bytes()
+			s.mu.Unlock()
+			return stream.SendAndClose(&file.PutResponse{})
+		}
+		if err != nil {
+			return err
+		}
+		if req.GetOpen() != nil {
+			name = req.GetOpen().GetRemoteFile()
+		}
+		contents.Write(req.GetContents())
+	}
+}
+
+func (s *fakeSwitch) Get(req *file.GetRequest, stream file.File_GetServer) error {
+	s.mu.Lock()
+	contents := s.files[req.GetRemoteFile()]
+	s.mu.Unlock()
+	if err := stream.Send(&file.GetResponse{Response: &file.GetResponse_Contents{Contents: contents}}); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(contents)
+	hash := &types.HashType{Method: types.HashType_SHA256, Hash: sum[:]}
+	return stream.Send(&file.GetResponse{Response: &file.GetResponse_Hash{Hash: hash}})
+}
+
+func (s *fakeSwitch) Stat(_ context.Context, req *file.StatRequest) (*file.StatResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp := &file.StatResponse{}
+	if _, ok := s.files[req.GetPath()]; ok {
+		resp.Stats = append(resp.Stats, &file.StatInfo{Path: req.GetPath()})
+	}
+	return resp, nil
+}
+
+func (s *fakeSwitch) GetCertificates(context.Context, *cert.GetCertificatesRequest) (*cert.GetCertificatesResponse, error) {
+	return &cert.GetCertificatesResponse{CertificateInfo: []*cert.CertificateInfo{{CertificateId: "default"}}}, nil
+}
+
+//startFakeSwitch starts a fake switch and connects the gNOI clients to it
+func startFakeSwitch(t *testing.T, down time.Duration) *fakeSwitch {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//the fake switch comes back on the same address after a reboot
+	s := &fakeSwitch{addr: lis.Addr().String(), down: down, files: make(map[string][]byte)}
+	lis.Close()
+	if err := s.start(); err != nil {
+		t.Fatal(err)
+	}
+	Init(&tg.Target{Address: s.addr})
+	return s
+}
+
+func TestProcessRebootRequest(t *testing.T) {
+	savedReconnectFuncs := reconnectFuncs
+	defer func() { reconnectFuncs = savedReconnectFuncs }()
+	var reconnects int
+	reconnectFuncs = []func(){func() { reconnects++ }}
+
+	tests := []struct {
+		name       string
+		method     system.RebootMethod
+		down       time.Duration
+		timeout    time.Duration
+		want       bool
+		reconnects int
+	}{
+		{name: "Cold Reboot", method: system.RebootMethod_COLD, down: 2 * time.Second, timeout: 20 * time.Second, want: true, reconnects: 1},
+		{name: "Halt", method: system.RebootMethod_HALT, down: time.Second, timeout: 20 * time.Second, want: true, reconnects: 0},
+		{name: "Target Not Back", method: system.RebootMethod_COLD, down: time.Minute, timeout: 3 * time.Second, want: false, reconnects: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconnects = 0
+			s := startFakeSwitch(t, tt.down)
+			defer s.stop()
+			defer TearDown()
+			got := ProcessRebootRequest(&system.RebootRequest{Method: tt.method}, tt.timeout)
+			if got.Passed() != tt.want {
+				t.Errorf("ProcessRebootRequest() = %v, want %v", got, tt.want)
+			}
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.reboots != 1 {
+				t.Errorf("reboot requests = %d, want 1", s.reboots)
+			}
+			if reconnects != tt.reconnects {
+				t.Errorf("reconnects = %d, want %d", reconnects, tt.reconnects)
+			}
+		})
+	}
+}
+
+func TestProcessFile(t *testing.T) {
+	s := startFakeSwitch(t, 0)
+	defer s.stop()
+	defer TearDown()
+	contents := bytes.Repeat([]byte("testvectors"), chunkSize/5)
+
+	if res := ProcessFilePut("/tmp/file", 0644, contents); !res.Passed() {
+		t.Fatalf("ProcessFilePut() = %v, want passed", res)
+	}
+	tests := []struct {
+		name string
+		res  func() bool
+		want bool
+	}{
+		{name: "Get File", res: func() bool { return ProcessFileGet("/tmp/file", contents).Passed() }, want: true},
+		{name: "Get File Mismatch", res: func() bool { return ProcessFileGet("/tmp/file", contents[1:]).Passed() }, want: false},
+		{name: "Stat File", res: func() bool { return ProcessFileStat("/tmp/file").Passed() }, want: true},
+		{name: "Stat Missing File", res: func() bool { return ProcessFileStat("/tmp/missing").Passed() }, want: false},
+		{name: "Installed Certificate", res: func() bool { return ProcessGetCertificates([]string{"default"}).Passed() }, want: true},
+		{name: "Missing Certificate", res: func() bool { return ProcessGetCertificates([]string{"other"}).Passed() }, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.res(); got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package p4rt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stratum/testvectors-runner/pkg/result"
+	"github.com/stratum/testvectors-runner/pkg/utils/p4info"
+)
+
+//entity kinds handled by the state cleanup
+const (
+	tableKind = iota
+	memberKind
+	groupKind
+	multicastKind
+	cloneKind
+	meterKind
+	counterKind
+	unknownKind
+)
+
+//cleanup stages, in the order their updates are written.
+//Entities referenced by others are restored before and deleted after the entities referencing them.
+const (
+	deleteTablesStage = iota
+	restoreMembersStage
+	restoreGroupsStage
+	restoreReplicationStage
+	restoreTablesStage
+	deleteGroupsStage
+	deleteMembersStage
+	deleteReplicationStage
+	resetMetersAndCountersStage
+	numStages
+)
+
+//baseline holds the entities restored by ResetState, keyed by entityKey
+var baseline map[string]*v1.Entity
+
+//stateEntities returns the wildcard entities read to get the P4Runtime state, one per entity kind
+func stateEntities() []*v1.Entity {
+	return []*v1.Entity{
+		{Entity: &v1.Entity_TableEntry{TableEntry: &v1.TableEntry{}}},
+		{Entity: &v1.Entity_ActionProfileMember{ActionProfileMember: &v1.ActionProfileMember{}}},
+		{Entity: &v1.Entity_ActionProfileGroup{ActionProfileGroup: &v1.ActionProfileGroup{}}},
+		{Entity: &v1.Entity_PacketReplicationEngineEntry{PacketReplicationEngineEntry: &v1.PacketReplicationEngineEntry{
+			Type: &v1.PacketReplicationEngineEntry_MulticastGroupEntry{MulticastGroupEntry: &v1.MulticastGroupEntry{}},
+		}}},
+		{Entity: &v1.Entity_PacketReplicationEngineEntry{PacketReplicationEngineEntry: &v1.PacketReplicationEngineEntry{
+			Type: &v1.PacketReplicationEngineEntry_CloneSessionEntry{CloneSessionEntry: &v1.CloneSessionEntry{}},
+		}}},
+		{Entity: &v1.Entity_MeterEntry{MeterEntry: &v1.MeterEntry{}}},
+		{Entity: &v1.Entity_CounterEntry{CounterEntry: &v1.CounterEntry{}}},
+	}
+}
+
+//readState reads the table entries, action profile members and groups, multicast groups, clone sessions,
+//meter and counter entries of the device. Entity kinds which fail to be read are skipped.
+//Entries of const tables are left out if a P4Info is loaded, as they can't be modified.
+func readState(deviceID uint64) []*v1.Entity {
+	var entities []*v1.Entity
+	for _, wildcard := range stateEntities() {
+		resps := p4rtConn.Read(&v1.ReadRequest{DeviceId: deviceID, Entities: []*v1.Entity{wildcard}})
+		if resps == nil {
+			log.Warnf("Failed to read %s, skipping them in state cleanup", wildcard)
+			continue
+		}
+		for _, entity := range getEntities(resps) {
+			if !isConstTableEntry(entity) {
+				entities = append(entities, normalizeEntity(entity))
+			}
+		}
+	}
+	return entities
+}
+
+//isConstTableEntry returns true if the entity is an entry of a const table of the loaded P4Info
+func isConstTableEntry(entity *v1.Entity) bool {
+	if entity.GetTableEntry() == nil {
+		return false
+	}
+	for _, table := range p4info.Get().GetTables() {
+		if table.GetPreamble().GetId() == entity.GetTableEntry().GetTableId() {
+			return table.GetIsConstTable()
+		}
+	}
+	return false
+}
+
+//normalizeEntity removes the read-only fields of an entity, so that it can be compared and written back
+func normalizeEntity(entity *v1.Entity) *v1.Entity {
+	if entity.GetTableEntry() == nil {
+		return entity
+	}
+	entity = proto.Clone(entity).(*v1.Entity)
+	entity.GetTableEntry().CounterData = nil
+	entity.GetTableEntry().TimeSinceLastHit = nil
+	return entity
+}
+
+//entityKind returns the kind of an entity
+func entityKind(entity *v1.Entity) int {
+	switch {
+	case entity.GetTableEntry() != nil:
+		return tableKind
+	case entity.GetActionProfileMember() != nil:
+		return memberKind
+	case entity.GetActionProfileGroup() != nil:
+		return groupKind
+	case entity.GetPacketReplicationEngineEntry().GetMulticastGroupEntry() != nil:
+		return multicastKind
+	case entity.GetPacketReplicationEngineEntry().GetCloneSessionEntry() != nil:
+		return cloneKind
+	case entity.GetMeterEntry() != nil:
+		return meterKind
+	case entity.GetCounterEntry() != nil:
+		return counterKind
+	default:
+		return unknownKind
+	}
+}
+
+//entityKey returns a string identifying an entity by its key fields, e.g. the table ID, match and priority of a table entry
+func entityKey(entity *v1.Entity) string {
+	switch entityKind(entity) {
+	case tableKind:
+		te := entity.GetTableEntry()
+		var match []string
+		for _, fm := range te.GetMatch() {
+			match = append(match, proto.CompactTextString(fm))
+		}
+		sort.Strings(match)
+		return fmt.Sprintf("table %d priority %d default %t match [%s]", te.GetTableId(), te.GetPriority(), te.GetIsDefaultAction(), strings.Join(match, ", "))
+	case memberKind:
+		return fmt.Sprintf("member %d/%d", entity.GetActionProfileMember().GetActionProfileId(), entity.GetActionProfileMember().GetMemberId())
+	case groupKind:
+		return fmt.Sprintf("group %d/%d", entity.GetActionProfileGroup().GetActionProfileId(), entity.GetActionProfileGroup().GetGroupId())
+	case multicastKind:
+		return fmt.Sprintf("multicast group %d", entity.GetPacketReplicationEngineEntry().GetMulticastGroupEntry().GetMulticastGroupId())
+	case cloneKind:
+		return fmt.Sprintf("clone session %d", entity.GetPacketReplicationEngineEntry().GetCloneSessionEntry().GetSessionId())
+	case meterKind:
+		return fmt.Sprintf("meter %d/%d", entity.GetMeterEntry().GetMeterId(), entity.GetMeterEntry().GetIndex().GetIndex())
+	case counterKind:
+		return fmt.Sprintf("counter %d/%d", entity.GetCounterEntry().GetCounterId(), entity.GetCounterEntry().GetIndex().GetIndex())
+	default:
+		return proto.CompactTextString(entity)
+	}
+}
+
+//restoreStage returns the stage in which baseline entities of the kind are inserted or modified
+func restoreStage(kind int) int {
+	switch kind {
+	case memberKind:
+		return restoreMembersStage
+	case groupKind:
+		return restoreGroupsStage
+	case multicastKind, cloneKind:
+		return restoreReplicationStage
+	default:
+		return restoreTablesStage
+	}
+}
+
+//deleteStage returns the stage in which entities of the kind which are not in the baseline are deleted
+func deleteStage(kind int) int {
+	switch kind {
+	case memberKind:
+		return deleteMembersStage
+	case groupKind:
+		return deleteGroupsStage
+	case multicastKind, cloneKind:
+		return deleteReplicationStage
+	default:
+		return deleteTablesStage
+	}
+}
+
+//cleanupUpdates returns the updates which bring the current state back to the baseline, grouped by stage.
+//Entities which are not in the baseline are deleted, modified and deleted baseline entities are restored,
+//meters without baseline are reset to their default config and counters are reset to zero.
+func cleanupUpdates(current []*v1.Entity, baseline map[string]*v1.Entity) [][]*v1.Update {
+	stages := make([][]*v1.Update, numStages)
+	add := func(stage int, updateType v1.Update_Type, entity *v1.Entity) {
+		stages[stage] = append(stages[stage], &v1.Update{Type: updateType, Entity: entity})
+	}
+	found := make(map[string]bool)
+	for _, entity := range current {
+		key := entityKey(entity)
+		found[key] = true
+		base, inBaseline := baseline[key]
+		switch kind := entityKind(entity); kind {
+		case unknownKind:
+			continue
+		case counterKind:
+			ce := entity.GetCounterEntry()
+			if ce.GetData().GetPacketCount() != 0 || ce.GetData().GetByteCount() != 0 {
+				reset := &v1.CounterEntry{CounterId: ce.GetCounterId(), Index: ce.GetIndex(), Data: &v1.CounterData{}}
+				add(resetMetersAndCountersStage, v1.Update_MODIFY, &v1.Entity{Entity: &v1.Entity_CounterEntry{CounterEntry: reset}})
+			}
+		case meterKind:
+			me := entity.GetMeterEntry()
+			switch {
+			case inBaseline && !proto.Equal(base, entity):
+				add(resetMetersAndCountersStage, v1.Update_MODIFY, base)
+			case !inBaseline && me.GetConfig() != nil:
+				reset := &v1.MeterEntry{MeterId: me.GetMeterId(), Index: me.GetIndex()}
+				add(resetMetersAndCountersStage, v1.Update_MODIFY, &v1.Entity{Entity: &v1.Entity_MeterEntry{MeterEntry: reset}})
+			}
+		default:
+			switch {
+			case !inBaseline:
+				add(deleteStage(kind), v1.Update_DELETE, entity)
+			case !proto.Equal(base, entity):
+				add(restoreStage(kind), v1.Update_MODIFY, base)
+			}
+		}
+	}
+	var missing []string
+	for key, entity := range baseline {
+		if kind := entityKind(entity); !found[key] && kind != meterKind && kind != counterKind && kind != unknownKind {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	for _, key := range missing {
+		add(restoreStage(entityKind(baseline[key])), v1.Update_INSERT, baseline[key])
+	}
+	return stages
+}
+
+//SaveBaseline reads the current P4Runtime state and keeps it as the baseline which ResetState restores,
+//e.g. after a setup Test Vector installed entries shared by later Test Vectors
+func SaveBaseline() *result.Result {
+	log.Info("Saving P4Runtime state baseline")
+	baseline = make(map[string]*v1.Entity)
+	for _, entity := range readState(defaultController.DeviceID) {
+		if entityKind(entity) != counterKind {
+			baseline[entityKey(entity)] = entity
+		}
+	}
+	log.Infof("Saved %d entities as P4Runtime state baseline", len(baseline))
+	return result.Pass()
+}
+
+//ClearBaseline drops the baseline, so that ResetState deletes all entities
+func ClearBaseline() {
+	baseline = nil
+}
+
+//ResetState brings the P4Runtime state back to the baseline using the default controller identity.
+//Table entries, action profile members and groups, multicast groups and clone sessions which are not in the baseline
+//are deleted, modified or deleted baseline entities are restored, meters are reset and counters are set to zero.
+func ResetState() *result.Result {
+	if !scv.getMasterArbitrationLock(defaultController) {
+		return result.Failf("failed to get master arbitration lock for %s", defaultController)
+	}
+	res := result.Pass()
+	for stage, updates := range cleanupUpdates(readState(defaultController.DeviceID), baseline) {
+		if len(updates) == 0 {
+			continue
+		}
+		log.Infof("Resetting P4Runtime state, stage %d with %d updates", stage, len(updates))
+		req := &v1.WriteRequest{
+			DeviceId:   defaultController.DeviceID,
+			RoleId:     defaultController.Role.GetId(),
+			ElectionId: defaultController.ElectionID,
+			Updates:    updates,
+		}
+		if _, err := p4rtConn.Write(req); err != nil {
+			log.Errorf("Error resetting P4Runtime state: %v", err)
+			res.Add(result.Failf("failed to reset P4Runtime state in stage %d: %s", stage, formatWriteError(err)))
+		}
+	}
+	return res
+}
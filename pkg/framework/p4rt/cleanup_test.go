@@ -0,0 +1,159 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package p4rt
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+)
+
+func memberEntity(memberID uint32, actionID uint32) *v1.Entity {
+	return &v1.Entity{Entity: &v1.Entity_ActionProfileMember{ActionProfileMember: &v1.ActionProfileMember{
+		ActionProfileId: 1, MemberId: memberID, Action: &v1.Action{ActionId: actionID},
+	}}}
+}
+
+func groupEntity(groupID uint32, memberIDs ...uint32) *v1.Entity {
+	group := &v1.ActionProfileGroup{ActionProfileId: 1, GroupId: groupID}
+	for _, memberID := range memberIDs {
+		group.Members = append(group.Members, &v1.ActionProfileGroup_Member{MemberId: memberID, Weight: 1})
+	}
+	return &v1.Entity{Entity: &v1.Entity_ActionProfileGroup{ActionProfileGroup: group}}
+}
+
+func multicastEntity(groupID uint32) *v1.Entity {
+	return &v1.Entity{Entity: &v1.Entity_PacketReplicationEngineEntry{PacketReplicationEngineEntry: &v1.PacketReplicationEngineEntry{
+		Type: &v1.PacketReplicationEngineEntry_MulticastGroupEntry{MulticastGroupEntry: &v1.MulticastGroupEntry{MulticastGroupId: groupID}},
+	}}}
+}
+
+func meterEntity(index int64, cir int64) *v1.Entity {
+	me := &v1.MeterEntry{MeterId: 1, Index: &v1.Index{Index: index}}
+	if cir != 0 {
+		me.Config = &v1.MeterConfig{Cir: cir, Cburst: 1, Pir: cir, Pburst: 1}
+	}
+	return &v1.Entity{Entity: &v1.Entity_MeterEntry{MeterEntry: me}}
+}
+
+func TestEntityKey(t *testing.T) {
+	match1 := &v1.FieldMatch{FieldId: 1, FieldMatchType: &v1.FieldMatch_Exact_{Exact: &v1.FieldMatch_Exact{Value: []byte("\x01")}}}
+	match2 := &v1.FieldMatch{FieldId: 2, FieldMatchType: &v1.FieldMatch_Exact_{Exact: &v1.FieldMatch_Exact{Value: []byte("\x02")}}}
+	entry := func(priority int32, actionID uint32, match ...*v1.FieldMatch) *v1.Entity {
+		return &v1.Entity{Entity: &v1.Entity_TableEntry{TableEntry: &v1.TableEntry{
+			TableId: 1, Priority: priority, Match: match,
+			Action: &v1.TableAction{Type: &v1.TableAction_Action{Action: &v1.Action{ActionId: actionID}}},
+		}}}
+	}
+	tests := []struct {
+		name      string
+		e1, e2    *v1.Entity
+		wantEqual bool
+	}{
+		{name: "Same Entry Other Action", e1: entry(10, 1, match1), e2: entry(10, 2, match1), wantEqual: true},
+		{name: "Match Order", e1: entry(10, 1, match1, match2), e2: entry(10, 1, match2, match1), wantEqual: true},
+		{name: "Other Priority", e1: entry(10, 1, match1), e2: entry(20, 1, match1), wantEqual: false},
+		{name: "Other Match", e1: entry(10, 1, match1), e2: entry(10, 1, match2), wantEqual: false},
+		{name: "Member Other Action", e1: memberEntity(1, 1), e2: memberEntity(1, 2), wantEqual: true},
+		{name: "Other Member", e1: memberEntity(1, 1), e2: memberEntity(2, 1), wantEqual: false},
+		{name: "Member And Group", e1: memberEntity(1, 1), e2: groupEntity(1), wantEqual: false},
+		{name: "Meter Other Config", e1: meterEntity(1, 0), e2: meterEntity(1, 100), wantEqual: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entityKey(tt.e1) == entityKey(tt.e2); got != tt.wantEqual {
+				t.Errorf("entityKey() %q == %q is %v, want %v", entityKey(tt.e1), entityKey(tt.e2), got, tt.wantEqual)
+			}
+		})
+	}
+}
+
+func TestCleanupUpdates(t *testing.T) {
+	var (
+		entry      = tableEntity(33598026, 10)
+		otherEntry = tableEntity(33598026, 20)
+		counter    = counterEntity(302055013, 5)
+		zero       = counterEntity(302055013, 0)
+	)
+	baselineOf := func(entities ...*v1.Entity) map[string]*v1.Entity {
+		b := make(map[string]*v1.Entity)
+		for _, e := range entities {
+			b[entityKey(e)] = e
+		}
+		return b
+	}
+	update := func(updateType v1.Update_Type, entity *v1.Entity) *v1.Update {
+		return &v1.Update{Type: updateType, Entity: entity}
+	}
+	tests := []struct {
+		name     string
+		current  []*v1.Entity
+		baseline map[string]*v1.Entity
+		want     map[int][]*v1.Update
+	}{
+		{name: "Empty", want: map[int][]*v1.Update{}},
+		{
+			name:    "Delete All Without Baseline",
+			current: []*v1.Entity{entry, memberEntity(1, 1), groupEntity(1, 1), multicastEntity(1)},
+			want: map[int][]*v1.Update{
+				deleteTablesStage:      {update(v1.Update_DELETE, entry)},
+				deleteGroupsStage:      {update(v1.Update_DELETE, groupEntity(1, 1))},
+				deleteMembersStage:     {update(v1.Update_DELETE, memberEntity(1, 1))},
+				deleteReplicationStage: {update(v1.Update_DELETE, multicastEntity(1))},
+			},
+		},
+		{
+			name:     "Keep Baseline",
+			current:  []*v1.Entity{entry, otherEntry, memberEntity(1, 1)},
+			baseline: baselineOf(entry, memberEntity(1, 1)),
+			want: map[int][]*v1.Update{
+				deleteTablesStage: {update(v1.Update_DELETE, otherEntry)},
+			},
+		},
+		{
+			name:     "Restore Baseline",
+			current:  []*v1.Entity{memberEntity(1, 2), memberEntity(2, 1), groupEntity(1, 2)},
+			baseline: baselineOf(entry, memberEntity(1, 1), groupEntity(1, 1)),
+			want: map[int][]*v1.Update{
+				restoreMembersStage: {update(v1.Update_MODIFY, memberEntity(1, 1))},
+				restoreGroupsStage:  {update(v1.Update_MODIFY, groupEntity(1, 1))},
+				restoreTablesStage:  {update(v1.Update_INSERT, entry)},
+				deleteMembersStage:  {update(v1.Update_DELETE, memberEntity(2, 1))},
+			},
+		},
+		{
+			name:     "Reset Meters And Counters",
+			current:  []*v1.Entity{counter, zero, meterEntity(1, 100), meterEntity(2, 0), meterEntity(3, 200)},
+			baseline: baselineOf(meterEntity(3, 300)),
+			want: map[int][]*v1.Update{
+				resetMetersAndCountersStage: {
+					update(v1.Update_MODIFY, zero),
+					update(v1.Update_MODIFY, meterEntity(1, 0)),
+					update(v1.Update_MODIFY, meterEntity(3, 300)),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cleanupUpdates(tt.current, tt.baseline)
+			for stage, updates := range got {
+				want := tt.want[stage]
+				if len(updates) != len(want) {
+					t.Errorf("cleanupUpdates() stage %d = %v, want %v", stage, updates, want)
+					continue
+				}
+				for i := range updates {
+					if !proto.Equal(updates[i], want[i]) {
+						t.Errorf("cleanupUpdates() stage %d update %d = %v, want %v", stage, i, updates[i], want[i])
+					}
+				}
+			}
+		})
+	}
+}
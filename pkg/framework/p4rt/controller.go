@@ -0,0 +1,132 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package p4rt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	scpb "google.golang.org/genproto/googleapis/rpc/code"
+)
+
+//Controller is the identity the runner presents to the P4Runtime server during master arbitration
+type Controller struct {
+	DeviceID   uint64
+	ElectionID *v1.Uint128
+	//Role is nil for the default role, which has full pipeline access
+	Role *v1.Role
+}
+
+//defaultController is used for stream channel operations like packet-out and when the stream channel is opened
+var defaultController = Controller{DeviceID: 1, ElectionID: &v1.Uint128{High: 1, Low: 5}}
+
+//SetDefaultController sets the controller identity used for packet-out and for the initial master arbitration
+func SetDefaultController(c Controller) {
+	defaultController = c
+}
+
+//GetDefaultController returns the controller identity used for packet-out and for the initial master arbitration
+func GetDefaultController() Controller {
+	return defaultController
+}
+
+//String returns the controller identity in a readable form
+func (c Controller) String() string {
+	return fmt.Sprintf("device ID %d, election ID %d:%d, role ID %d", c.DeviceID, c.ElectionID.GetHigh(), c.ElectionID.GetLow(), c.Role.GetId())
+}
+
+//requestController returns the controller identity of a request.
+//The role config is taken from the default controller if the role IDs match.
+func requestController(deviceID, roleID uint64, electionID *v1.Uint128) Controller {
+	c := Controller{DeviceID: deviceID, ElectionID: electionID}
+	switch {
+	case roleID == defaultController.Role.GetId():
+		c.Role = defaultController.Role
+	case roleID != 0:
+		c.Role = &v1.Role{Id: roleID}
+	}
+	return c
+}
+
+//ParseElectionID parses an election ID given as <high>:<low> or <low>
+func ParseElectionID(s string) (*v1.Uint128, error) {
+	var high, low uint64
+	var err error
+	parts := strings.Split(s, ":")
+	switch len(parts) {
+	case 1:
+		low, err = strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 64)
+	case 2:
+		if high, err = strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 64); err == nil {
+			low, err = strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64)
+		}
+	default:
+		err = fmt.Errorf("expected <high>:<low> or <low>")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid election ID %q: %v", s, err)
+	}
+	return &v1.Uint128{High: high, Low: low}, nil
+}
+
+//primaryState tracks the controller identity for which a stream channel is primary, based on the arbitration updates received
+type primaryState struct {
+	mu      sync.Mutex
+	primary *Controller
+	//lastArbitration is the last identity sent in an arbitration update, used to arbitrate again on a reopened stream channel
+	lastArbitration *Controller
+}
+
+//arbitrating records the identity of an arbitration update being sent
+func (p *primaryState) arbitrating(c Controller) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastArbitration = &c
+}
+
+//last returns the identity of the last arbitration update sent, nil if there was none
+func (p *primaryState) last() *Controller {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastArbitration
+}
+
+//lost records that the stream channel broke, so that it is no longer primary
+func (p *primaryState) lost() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.primary = nil
+}
+
+//update records the arbitration update received from the server.
+//An OK status means the stream is primary for the identity in the update, any other status means it is not primary.
+func (p *primaryState) update(arb *v1.MasterArbitrationUpdate) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if arb.GetStatus().GetCode() == int32(scpb.Code_OK) {
+		p.primary = &Controller{DeviceID: arb.GetDeviceId(), ElectionID: arb.GetElectionId(), Role: arb.GetRole()}
+	} else {
+		p.primary = nil
+	}
+}
+
+//isPrimary returns true if the stream is primary for the controller identity.
+//Role configs are not compared as the server does not need to echo them.
+func (p *primaryState) isPrimary(c Controller) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.primary != nil && sameIdentity(*p.primary, c)
+}
+
+//sameIdentity returns true if both controllers have the same device ID, election ID and role ID
+func sameIdentity(c1, c2 Controller) bool {
+	return c1.DeviceID == c2.DeviceID && c1.Role.GetId() == c2.Role.GetId() && proto.Equal(c1.ElectionID, c2.ElectionID)
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package p4rt
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/genproto/googleapis/rpc/status"
+)
+
+func TestParseElectionID(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    *v1.Uint128
+		wantErr bool
+	}{
+		{name: "Low Only", s: "5", want: &v1.Uint128{Low: 5}},
+		{name: "High And Low", s: "1:5", want: &v1.Uint128{High: 1, Low: 5}},
+		{name: "Spaces", s: " 1 : 5 ", want: &v1.Uint128{High: 1, Low: 5}},
+		{name: "Empty", s: "", wantErr: true},
+		{name: "Invalid Low", s: "1:x", wantErr: true},
+		{name: "Too Many Parts", s: "1:2:3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseElectionID(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseElectionID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !proto.Equal(got, tt.want) {
+				t.Errorf("ParseElectionID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrimaryState(t *testing.T) {
+	var (
+		electionID     = &v1.Uint128{High: 1, Low: 5}
+		highElectionID = &v1.Uint128{High: 2, Low: 5}
+		role           = &v1.Role{Id: 1}
+		ok             = &status.Status{Code: 0}
+		alreadyExists  = &status.Status{Code: 6}
+	)
+	tests := []struct {
+		name    string
+		updates []*v1.MasterArbitrationUpdate
+		c       Controller
+		want    bool
+	}{
+		{name: "No Update", c: Controller{DeviceID: 1, ElectionID: electionID}, want: false},
+		{
+			name:    "Primary",
+			updates: []*v1.MasterArbitrationUpdate{{DeviceId: 1, ElectionId: electionID, Status: ok}},
+			c:       Controller{DeviceID: 1, ElectionID: electionID},
+			want:    true,
+		},
+		{
+			name:    "Primary With Role",
+			updates: []*v1.MasterArbitrationUpdate{{DeviceId: 1, ElectionId: electionID, Role: &v1.Role{Id: 1}, Status: ok}},
+			c:       Controller{DeviceID: 1, ElectionID: electionID, Role: role},
+			want:    true,
+		},
+		{
+			name:    "Other Role",
+			updates: []*v1.MasterArbitrationUpdate{{DeviceId: 1, ElectionId: electionID, Status: ok}},
+			c:       Controller{DeviceID: 1, ElectionID: electionID, Role: role},
+			want:    false,
+		},
+		{
+			name:    "Other Device",
+			updates: []*v1.MasterArbitrationUpdate{{DeviceId: 1, ElectionId: electionID, Status: ok}},
+			c:       Controller{DeviceID: 2, ElectionID: electionID},
+			want:    false,
+		},
+		{
+			name:    "Other Election ID",
+			updates: []*v1.MasterArbitrationUpdate{{DeviceId: 1, ElectionId: electionID, Status: ok}},
+			c:       Controller{DeviceID: 1, ElectionID: highElectionID},
+			want:    false,
+		},
+		{
+			name: "Mastership Lost",
+			updates: []*v1.MasterArbitrationUpdate{
+				{DeviceId: 1, ElectionId: electionID, Status: ok},
+				{DeviceId: 1, ElectionId: highElectionID, Status: alreadyExists},
+			},
+			c:    Controller{DeviceID: 1, ElectionID: electionID},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &primaryState{}
+			for _, update := range tt.updates {
+				p.update(update)
+			}
+			if got := p.isPrimary(tt.c); got != tt.want {
+				t.Errorf("isPrimary() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestController(t *testing.T) {
+	defer SetDefaultController(GetDefaultController())
+	defaultRole := &v1.Role{Id: 2}
+	SetDefaultController(Controller{DeviceID: 1, ElectionID: &v1.Uint128{Low: 1}, Role: defaultRole})
+	tests := []struct {
+		name   string
+		roleID uint64
+		want   *v1.Role
+	}{
+		{name: "Default Role", roleID: 0, want: nil},
+		{name: "Role Of Default Controller", roleID: 2, want: defaultRole},
+		{name: "Other Role", roleID: 3, want: &v1.Role{Id: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := requestController(1, tt.roleID, &v1.Uint128{Low: 4}); !proto.Equal(got.Role, tt.want) {
+				t.Errorf("requestController() role = %v, want %v", got.Role, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package p4rt
+
+import (
+	"github.com/golang/protobuf/proto"
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stratum/testvectors-runner/pkg/result"
+	"github.com/stratum/testvectors-runner/pkg/utils/predicate"
+)
+
+//ProcessCounterRead reads the counter or direct counter entries of the entity and checks their packet and byte counts
+//against predicates, e.g. "~delta >= 10" after sending 10 packets. An empty predicate skips the count.
+//Delta predicates compare with the last count of the same entry read by ProcessCounterRead or a P4Runtime read expectation.
+func ProcessCounterRead(entity *v1.Entity, packets, bytes string) *result.Result {
+	if entity.GetCounterEntry() == nil && entity.GetDirectCounterEntry() == nil {
+		return result.Failf("entity %s is not a counter or direct counter entry", entity)
+	}
+	resps := p4rtConn.Read(&v1.ReadRequest{DeviceId: defaultController.DeviceID, Entities: []*v1.Entity{entity}})
+	if resps == nil {
+		return result.Failf("failed to read counter entries of %s", entity)
+	}
+	return verifyCounters(getEntities(resps), packets, bytes)
+}
+
+//ProcessP4ReadRequestWithCounters sends the read request to switch and compares the entities read with the expected responses,
+//except for the counts of counter and direct counter entries which are checked against the packet and byte predicates instead.
+//An empty predicate skips the count.
+func ProcessP4ReadRequestWithCounters(rreq *v1.ReadRequest, rres []*v1.ReadResponse, packets, bytes string) *result.Result {
+	if rreq == nil {
+		return result.Failf("empty read request")
+	}
+	return verifyReadRespWithCounters(rres, p4rtConn.Read(rreq), packets, bytes)
+}
+
+//verifyReadRespWithCounters compares the read responses regardless of counter data and checks the counts read against the predicates
+func verifyReadRespWithCounters(expected, actual []*v1.ReadResponse, packets, bytes string) *result.Result {
+	if actual == nil {
+		return verifyReadResp(expected, actual)
+	}
+	res := verifyReadResp(withoutCounterData(expected), withoutCounterData(actual))
+	var counters []*v1.Entity
+	for _, entity := range getEntities(actual) {
+		if entity.GetCounterEntry() != nil || entity.GetDirectCounterEntry() != nil {
+			counters = append(counters, entity)
+		}
+	}
+	res.Add(verifyCounters(counters, packets, bytes))
+	return res
+}
+
+//withoutCounterData returns a copy of the read responses with the data of counter and direct counter entries cleared
+func withoutCounterData(resps []*v1.ReadResponse) []*v1.ReadResponse {
+	stripped := make([]*v1.ReadResponse, 0, len(resps))
+	for _, resp := range resps {
+		resp = proto.Clone(resp).(*v1.ReadResponse)
+		for _, entity := range resp.GetEntities() {
+			if ce := entity.GetCounterEntry(); ce != nil {
+				ce.Data = nil
+			}
+			if dce := entity.GetDirectCounterEntry(); dce != nil {
+				dce.Data = nil
+			}
+		}
+		stripped = append(stripped, resp)
+	}
+	return stripped
+}
+
+//verifyCounters checks the packet and byte counts of counter entries against predicates and records all the counts as samples
+func verifyCounters(entities []*v1.Entity, packets, bytes string) *result.Result {
+	var packetPred, bytePred *predicate.Predicate
+	var err error
+	if packets != "" {
+		if packetPred, err = predicate.Parse(packets); err != nil {
+			return result.Fail(err)
+		}
+	}
+	if bytes != "" {
+		if bytePred, err = predicate.Parse(bytes); err != nil {
+			return result.Fail(err)
+		}
+	}
+	if len(entities) == 0 {
+		log.Warn("No counter entries read")
+		return result.Failf("no counter entries read")
+	}
+	res := result.Pass()
+	for _, entity := range entities {
+		key, data := counterKey(entity), counterData(entity)
+		res.Add(checkCount(key+" packets", packetPred, data.GetPacketCount()))
+		res.Add(checkCount(key+" bytes", bytePred, data.GetByteCount()))
+	}
+	return res
+}
+
+//checkCount checks a count against the predicate, if any, and records it as the last sample of key
+func checkCount(key string, p *predicate.Predicate, count int64) *result.Result {
+	v := predicate.Number(float64(count))
+	if p == nil {
+		predicate.Record(key, v)
+		return result.Pass()
+	}
+	if err := p.Check(key, v); err != nil {
+		log.Warnf("%s: %v", key, err)
+		return result.Failf("%s: %v", key, err)
+	}
+	log.Infof("%s %d satisfies %s", key, count, p)
+	return result.Pass()
+}
+
+//recordCounters keeps the counts of the counter and direct counter entries read as samples for delta predicates
+func recordCounters(entities []*v1.Entity) {
+	for _, entity := range entities {
+		if entity.GetCounterEntry() != nil || entity.GetDirectCounterEntry() != nil {
+			key, data := counterKey(entity), counterData(entity)
+			predicate.Record(key+" packets", predicate.Number(float64(data.GetPacketCount())))
+			predicate.Record(key+" bytes", predicate.Number(float64(data.GetByteCount())))
+		}
+	}
+}
+
+//counterKey returns the key of the samples of a counter or direct counter entry
+func counterKey(entity *v1.Entity) string {
+	if dce := entity.GetDirectCounterEntry(); dce != nil {
+		return "p4rt direct counter of " + entityKey(&v1.Entity{Entity: &v1.Entity_TableEntry{TableEntry: dce.GetTableEntry()}})
+	}
+	return "p4rt " + entityKey(entity)
+}
+
+//counterData returns the data of a counter or direct counter entry
+func counterData(entity *v1.Entity) *v1.CounterData {
+	if dce := entity.GetDirectCounterEntry(); dce != nil {
+		return dce.GetData()
+	}
+	return entity.GetCounterEntry().GetData()
+}
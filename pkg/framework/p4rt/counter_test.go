@@ -0,0 +1,81 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package p4rt
+
+import (
+	"testing"
+
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stratum/testvectors-runner/pkg/utils/predicate"
+)
+
+func directCounterEntity(tableID uint32, packets, bytes int64) *v1.Entity {
+	return &v1.Entity{Entity: &v1.Entity_DirectCounterEntry{DirectCounterEntry: &v1.DirectCounterEntry{
+		TableEntry: &v1.TableEntry{TableId: tableID},
+		Data:       &v1.CounterData{PacketCount: packets, ByteCount: bytes},
+	}}}
+}
+
+func TestVerifyCounters(t *testing.T) {
+	defer predicate.Reset()
+	//steps run in order, as delta predicates depend on the samples recorded by the previous steps
+	tests := []struct {
+		name     string
+		entities []*v1.Entity
+		packets  string
+		bytes    string
+		want     bool
+	}{
+		{name: "First Read", entities: []*v1.Entity{counterEntity(1, 100), directCounterEntity(2, 5, 500)}, want: true},
+		{name: "Any", entities: []*v1.Entity{counterEntity(1, 100)}, packets: "~any", bytes: "~any", want: true},
+		{name: "Increased", entities: []*v1.Entity{counterEntity(1, 110)}, packets: "~delta >= 10", want: true},
+		{name: "Not Increased", entities: []*v1.Entity{counterEntity(1, 115)}, packets: "~delta >= 10", want: false},
+		{name: "Delta From Last Count", entities: []*v1.Entity{counterEntity(1, 121)}, packets: "~delta == 6", want: true},
+		{name: "Direct Counter Bytes", entities: []*v1.Entity{directCounterEntity(2, 6, 564)}, packets: "~delta == 1", bytes: "~delta range 60 1500", want: true},
+		{name: "Range", entities: []*v1.Entity{counterEntity(1, 121)}, packets: "~range 100 200", want: true},
+		{name: "No Entries", packets: "~any", want: false},
+		{name: "Invalid Predicate", entities: []*v1.Entity{counterEntity(1, 115)}, packets: "~increased", want: false},
+	}
+	for _, tt := range tests {
+		if got := verifyCounters(tt.entities, tt.packets, tt.bytes); got.Passed() != tt.want {
+			t.Errorf("%s: verifyCounters() = %s, want passed %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyReadRespWithCounters(t *testing.T) {
+	defer predicate.Reset()
+	readResp := func(entities ...*v1.Entity) []*v1.ReadResponse {
+		return []*v1.ReadResponse{{Entities: entities}}
+	}
+	tableEntity := &v1.Entity{Entity: &v1.Entity_TableEntry{TableEntry: &v1.TableEntry{TableId: 3}}}
+	//steps run in order, as delta predicates depend on the samples recorded by the previous steps
+	tests := []struct {
+		name     string
+		expected []*v1.ReadResponse
+		actual   []*v1.ReadResponse
+		packets  string
+		bytes    string
+		want     bool
+	}{
+		{name: "First Read", expected: readResp(counterEntity(1, 0)), actual: readResp(counterEntity(1, 100)), packets: "~any", want: true},
+		{name: "Increased", expected: readResp(counterEntity(1, 0)), actual: readResp(counterEntity(1, 103)), packets: "~delta == 3", want: true},
+		{name: "Not Increased", expected: readResp(counterEntity(1, 0)), actual: readResp(counterEntity(1, 103)), packets: "~delta == 3", want: false},
+		{name: "Delta From Failed Read", expected: readResp(counterEntity(1, 0)), actual: readResp(counterEntity(1, 106)), packets: "~delta == 3", want: true},
+		{name: "Direct Counter", expected: readResp(directCounterEntity(2, 0, 0)), actual: readResp(directCounterEntity(2, 1, 64)), bytes: "~>= 64", want: true},
+		{name: "Other Entities Compared", expected: readResp(counterEntity(1, 0), tableEntity), actual: readResp(counterEntity(1, 106), tableEntity), packets: "~any", want: true},
+		{name: "Missing Entity", expected: readResp(counterEntity(1, 0), tableEntity), actual: readResp(counterEntity(1, 106)), packets: "~any", want: false},
+		{name: "Other Counter", expected: readResp(counterEntity(1, 0)), actual: readResp(counterEntity(4, 106)), packets: "~any", want: false},
+		{name: "No Counters Read", expected: readResp(), actual: readResp(), packets: "~any", want: false},
+		{name: "No Response", expected: readResp(counterEntity(1, 0)), packets: "~any", want: false},
+	}
+	for _, tt := range tests {
+		if got := verifyReadRespWithCounters(tt.expected, tt.actual, tt.packets, tt.bytes); got.Passed() != tt.want {
+			t.Errorf("%s: verifyReadRespWithCounters() = %s, want passed %v", tt.name, got, tt.want)
+		}
+	}
+}
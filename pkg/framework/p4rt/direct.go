@@ -13,22 +13,23 @@ import (
 	"time"
 
 	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stratum/testvectors-runner/pkg/result"
 )
 
 type directPacketIn struct {
 	scv streamChannel
 }
 
-func (d directPacketIn) ProcessPacketIn(exp *v1.PacketIn) bool {
+func (d directPacketIn) ProcessPacketIn(exp *v1.PacketIn) *result.Result {
 	select {
 	case ret := <-d.scv.pktInChan:
 		log.Debug("In ProcessPacketIn Case PktInChan")
 		return verifyPacketIn(exp, ret)
 	case <-time.After(PktTimeout):
 		if exp == nil || exp.GetPayload() == nil {
-			return true
+			return result.Pass()
 		}
 		log.Error("Timed out waiting for packet in")
-		return false
+		return result.Failf("timed out waiting for packet in after %s", PktTimeout)
 	}
 }
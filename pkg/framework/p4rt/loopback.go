@@ -13,17 +13,35 @@ import (
 	"time"
 
 	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stratum/testvectors-runner/pkg/result"
 	"github.com/stratum/testvectors-runner/pkg/utils/common"
 )
 
+//ingressPortMetadataID is the ID of the packet-in metadata which carries the ingress port in loopback mode
+var ingressPortMetadataID uint32 = 1
+
+//SetIngressPortMetadataID sets the ID of the packet-in metadata used to sort packet-ins by ingress port in loopback mode
+func SetIngressPortMetadataID(id uint32) {
+	ingressPortMetadataID = id
+}
+
+//getIngressPort returns the ingress port carried by the packet-in metadata, empty if there is none
+func getIngressPort(metadata []*v1.PacketMetadata) string {
+	for _, md := range metadata {
+		if md.GetMetadataId() == ingressPortMetadataID {
+			return common.GetStr(md.GetValue())
+		}
+	}
+	return ""
+}
+
 type loopbackPacketIn struct {
 	scv      streamChannel
 	pktChans map[string]chan *v1.PacketIn
 }
 
-func (l loopbackPacketIn) ProcessPacketIn(exp *v1.PacketIn) bool {
-	//FIXME: instead of first Metadata object, find metadata that matches with ingress port id
-	ingressPort := common.GetStr(exp.GetMetadata()[0].GetValue())
+func (l loopbackPacketIn) ProcessPacketIn(exp *v1.PacketIn) *result.Result {
+	ingressPort := getIngressPort(exp.GetMetadata())
 	if _, ok := l.pktChans[ingressPort]; !ok {
 		ingressPort = "generic"
 	}
@@ -33,19 +51,18 @@ func (l loopbackPacketIn) ProcessPacketIn(exp *v1.PacketIn) bool {
 		return verifyPacketIn(exp, ret)
 	case <-time.After(PktTimeout):
 		if exp.GetPayload() == nil {
-			return true
+			return result.Pass()
 		}
 		log.Error("Timed out waiting for packet in")
-		return false
+		return result.Failf("timed out waiting for packet in after %s", PktTimeout)
 	}
 }
 
-func sort(pktInChan chan *v1.PacketIn, pktChans map[string]chan *v1.PacketIn) {
+func sortPacketIn(pktInChan chan *v1.PacketIn, pktChans map[string]chan *v1.PacketIn) {
 	for {
 		packet := <-pktInChan
 		log.Debugf("Caught packet in sort %v", packet)
-		//FIXME: instead of first Metadata object, find metadata that matches with ingress port id
-		ingressPort := common.GetStr(packet.GetMetadata()[0].GetValue())
+		ingressPort := getIngressPort(packet.GetMetadata())
 		if val, ok := pktChans[ingressPort]; ok {
 			log.Debugf("Added packet to channel with port %s", ingressPort)
 			val <- packet
@@ -15,9 +15,11 @@ import (
 	v1 "github.com/p4lang/p4runtime/go/p4/v1"
 
 	"github.com/stratum/testvectors-runner/pkg/logger"
+	"github.com/stratum/testvectors-runner/pkg/result"
 	"github.com/stratum/testvectors-runner/pkg/utils/common"
 	pm "github.com/stratum/testvectors/proto/portmap"
 	tg "github.com/stratum/testvectors/proto/target"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
 )
 
 var log = logger.NewLogger()
@@ -29,17 +31,25 @@ var (
 	s        pktInInterface
 	scv      streamChannel
 	p4rtConn connection
+	//arguments of the last Init call, used by Reconnect
+	initTarget  *tg.Target
+	initDpMode  string
+	initPortmap *pm.PortMap
 )
 
 type pktInInterface interface {
-	ProcessPacketIn(*v1.PacketIn) bool
+	ProcessPacketIn(*v1.PacketIn) *result.Result
 }
 
 //Init starts a P4Runtime client and runs go routines to send and receive stream channel messages from P4Runtime stream channel client
 func Init(target *tg.Target, dpMode string, portmap *pm.PortMap) {
 	log.Debug("In p4_oper Init")
+	initTarget, initDpMode, initPortmap = target, dpMode, portmap
 	p4rtConn = connect(target)
 	scv = getStreamChannel(p4rtConn.client)
+	if !scv.getMasterArbitrationLock(defaultController) {
+		log.Warnf("Failed to get master arbitration lock for %s when opening the stream channel", defaultController)
+	}
 
 	switch dpMode {
 	case "direct":
@@ -52,7 +62,7 @@ func Init(target *tg.Target, dpMode string, portmap *pm.PortMap) {
 		}
 		pktChans["generic"] = make(chan *v1.PacketIn)
 		s = &loopbackPacketIn{scv, pktChans}
-		go sort(scv.pktInChan, pktChans)
+		go sortPacketIn(scv.pktInChan, pktChans)
 
 	default:
 		log.Fatalf("Unknown data plane mode: %s", dpMode)
@@ -62,48 +72,131 @@ func Init(target *tg.Target, dpMode string, portmap *pm.PortMap) {
 //TearDown closes the stream channel client
 func TearDown() {
 	log.Debug("In p4_oper tear down")
+	closeSessions()
 	scv.Close()
 	p4rtConn.cancel()
 }
 
+//Reconnect closes the P4Runtime connection and stream channel and opens new ones to the same target, e.g. after a switch reboot
+func Reconnect() {
+	log.Debug("In p4_oper Reconnect")
+	TearDown()
+	Init(initTarget, initDpMode, initPortmap)
+}
+
 //ProcessP4WriteRequest sends the write request to switch
-func ProcessP4WriteRequest(wreq *v1.WriteRequest, wres *v1.WriteResponse) bool {
+func ProcessP4WriteRequest(wreq *v1.WriteRequest, wres *v1.WriteResponse) *result.Result {
 	if wreq == nil {
-		return false
+		return result.Failf("empty write request")
 	}
-	if scv.getMasterArbitrationLock(wreq.DeviceId, wreq.ElectionId) {
-		resp := p4rtConn.Write(wreq)
+	if scv.getMasterArbitrationLock(requestController(wreq.DeviceId, wreq.RoleId, wreq.ElectionId)) {
+		resp, err := p4rtConn.Write(wreq)
+		if err != nil {
+			return result.Failf("write request failed with %s", formatWriteError(err))
+		}
 		return verifyWriteResp(wres, resp)
 	}
-	return false
+	return result.Failf("failed to get master arbitration lock for device %d", wreq.DeviceId)
+}
+
+//ProcessP4WriteRequestWithStatus sends the write request to switch and verifies that it completes with the expected gRPC status,
+//e.g. INVALID_ARGUMENT, or UNKNOWN with one p4.v1.Error detail per update. A nil status expects the write to succeed.
+func ProcessP4WriteRequestWithStatus(wreq *v1.WriteRequest, expected *spb.Status) *result.Result {
+	if wreq == nil {
+		return result.Failf("empty write request")
+	}
+	if scv.getMasterArbitrationLock(requestController(wreq.DeviceId, wreq.RoleId, wreq.ElectionId)) {
+		_, err := p4rtConn.Write(wreq)
+		return verifyWriteError(expected, err)
+	}
+	return result.Failf("failed to get master arbitration lock for device %d", wreq.DeviceId)
+}
+
+//ProcessP4ReadRequest sends the read request to switch and compares the entities read with the expected responses
+func ProcessP4ReadRequest(rreq *v1.ReadRequest, rres []*v1.ReadResponse) *result.Result {
+	if rreq == nil {
+		return result.Failf("empty read request")
+	}
+	resp := p4rtConn.Read(rreq)
+	res := verifyReadResp(rres, resp)
+	recordCounters(getEntities(resp))
+	return res
 }
 
 //ProcessP4PipelineConfigOperation sends SetForwardingPipelineConfigRequest to switch
-func ProcessP4PipelineConfigOperation(req *v1.SetForwardingPipelineConfigRequest, res *v1.SetForwardingPipelineConfigResponse) bool {
+func ProcessP4PipelineConfigOperation(req *v1.SetForwardingPipelineConfigRequest, res *v1.SetForwardingPipelineConfigResponse) *result.Result {
 	if req == nil {
-		return false
+		return result.Failf("empty SetForwardingPipelineConfig request")
 	}
-	if scv.getMasterArbitrationLock(req.DeviceId, req.ElectionId) {
+	if scv.getMasterArbitrationLock(requestController(req.DeviceId, req.RoleId, req.ElectionId)) {
 		resp := p4rtConn.SetForwardingPipelineConfig(req)
 		return verifySetForwardingPipelineConfigResp(res, resp)
 	}
-	return false
+	return result.Failf("failed to get master arbitration lock for device %d", req.DeviceId)
+}
+
+//ProcessP4GetPipelineConfigRequest gets the forwarding pipeline config from switch and compares it based on the requested response type
+func ProcessP4GetPipelineConfigRequest(req *v1.GetForwardingPipelineConfigRequest, res *v1.GetForwardingPipelineConfigResponse) *result.Result {
+	if req == nil {
+		return result.Failf("empty GetForwardingPipelineConfig request")
+	}
+	resp := p4rtConn.GetForwardingPipelineConfig(req)
+	return verifyGetForwardingPipelineConfigResp(res, resp, req.GetResponseType())
 }
 
 //ProcessPacketOutOperation sends packet to stream channel client.
-func ProcessPacketOutOperation(po *v1.PacketOut) bool {
-	var deviceID uint64 = 1
-	electionID := &v1.Uint128{High: 1, Low: 5}
-	if scv.getMasterArbitrationLock(deviceID, electionID) {
+func ProcessPacketOutOperation(po *v1.PacketOut) *result.Result {
+	if scv.getMasterArbitrationLock(defaultController) {
 		log.Info("Sending packet")
 		log.Debugf("Packet info: %s", po)
 		scv.pktOutChan <- po
-		return true
+		return result.Pass()
 	}
-	return false
+	return result.Failf("failed to get master arbitration lock for %s", defaultController)
 }
 
 //ProcessPacketIn verifies if the packet received is same as expected packet.
-func ProcessPacketIn(exp *v1.PacketIn) bool {
+func ProcessPacketIn(exp *v1.PacketIn) *result.Result {
 	return s.ProcessPacketIn(exp)
 }
+
+//ClearStreamMessages discards the buffered arbitration updates, digest lists, idle timeout notifications and stream errors
+//of the default stream channel and of all sessions, so that expectations of a test case only see the messages received during it
+func ClearStreamMessages() {
+	if scv.streamMsgs != nil {
+		scv.streamMsgs.clear()
+	}
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	for _, sess := range sessions {
+		sess.scv.streamMsgs.clear()
+	}
+}
+
+//ProcessDigestList waits for the expected digest lists, in any order, and verifies them.
+//Received digest lists are acknowledged automatically.
+func ProcessDigestList(exp []*v1.DigestList) *result.Result {
+	var msgs []*v1.StreamMessageResponse
+	for _, digest := range exp {
+		msgs = append(msgs, &v1.StreamMessageResponse{Update: &v1.StreamMessageResponse_Digest{Digest: digest}})
+	}
+	return verifyStreamMessages(scv.streamMsgs, "digest lists", msgs)
+}
+
+//ProcessIdleTimeoutNotification waits for the expected idle timeout notifications, in any order, and verifies them.
+func ProcessIdleTimeoutNotification(exp []*v1.IdleTimeoutNotification) *result.Result {
+	var msgs []*v1.StreamMessageResponse
+	for _, notification := range exp {
+		msgs = append(msgs, &v1.StreamMessageResponse{Update: &v1.StreamMessageResponse_IdleTimeoutNotification{IdleTimeoutNotification: notification}})
+	}
+	return verifyStreamMessages(scv.streamMsgs, "idle timeout notifications", msgs)
+}
+
+//ProcessStreamError waits for the expected stream errors, in any order, and verifies them.
+func ProcessStreamError(exp []*v1.StreamError) *result.Result {
+	var msgs []*v1.StreamMessageResponse
+	for _, streamError := range exp {
+		msgs = append(msgs, &v1.StreamMessageResponse{Update: &v1.StreamMessageResponse_Error{Error: streamError}})
+	}
+	return verifyStreamMessages(scv.streamMsgs, "stream errors", msgs)
+}
@@ -505,7 +505,7 @@ func TestProcessP4PipelineConfigOperation(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := p4rt.ProcessP4PipelineConfigOperation(tt.args.req, tt.args.res); got != tt.want {
+			if got := p4rt.ProcessP4PipelineConfigOperation(tt.args.req, tt.args.res); got.Passed() != tt.want {
 				t.Errorf("ProcessP4PipelineConfigOperation() = %v, want %v", got, tt.want)
 			}
 		})
@@ -556,7 +556,7 @@ func TestProcessP4WriteRequest(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := p4rt.ProcessP4WriteRequest(tt.args.wreq, tt.args.wres); got != tt.want {
+			if got := p4rt.ProcessP4WriteRequest(tt.args.wreq, tt.args.wres); got.Passed() != tt.want {
 				t.Errorf("ProcessP4WriteRequest() = %v, want %v", got, tt.want)
 			}
 		})
@@ -564,6 +564,103 @@ func TestProcessP4WriteRequest(t *testing.T) {
 	//tearDownTest()
 }
 
+func TestProcessP4ReadRequest(t *testing.T) {
+	log.Info(strings.Repeat("*", 100))
+	log.Info("Start of TestProcessP4ReadRequest")
+	defer log.Info("End of TestProcessP4ReadRequest")
+	setupTest()
+	defer tearDownTest()
+	var (
+		deviceID            uint64 = 1
+		puntTableEntriesReq        = &v1.ReadRequest{
+			DeviceId: deviceID,
+			Entities: []*v1.Entity{
+				{Entity: &v1.Entity_TableEntry{TableEntry: &v1.TableEntry{TableId: 33598026}}},
+			},
+		}
+		emptyReadResponses = []*v1.ReadResponse{}
+	)
+	type args struct {
+		rreq *v1.ReadRequest
+		rres []*v1.ReadResponse
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "Empty Table Read",
+			args: args{
+				rreq: puntTableEntriesReq,
+				rres: emptyReadResponses,
+			},
+			want: true,
+		},
+		{
+			name: "Empty Read Request",
+			args: args{
+				rreq: nil,
+				rres: emptyReadResponses,
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p4rt.ProcessP4ReadRequest(tt.args.rreq, tt.args.rres); got.Passed() != tt.want {
+				t.Errorf("ProcessP4ReadRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessP4GetPipelineConfigRequest(t *testing.T) {
+	log.Info(strings.Repeat("*", 100))
+	log.Info("Start of TestProcessP4GetPipelineConfigRequest")
+	defer log.Info("End of TestProcessP4GetPipelineConfigRequest")
+	setupTest()
+	defer tearDownTest()
+	var (
+		deviceID         uint64 = 1
+		cookieOnlyReq           = &v1.GetForwardingPipelineConfigRequest{DeviceId: deviceID, ResponseType: v1.GetForwardingPipelineConfigRequest_COOKIE_ONLY}
+		emptyCookieResp         = &v1.GetForwardingPipelineConfigResponse{Config: &v1.ForwardingPipelineConfig{}}
+		invalidDeviceReq        = &v1.GetForwardingPipelineConfigRequest{DeviceId: 2, ResponseType: v1.GetForwardingPipelineConfigRequest_COOKIE_ONLY}
+	)
+	type args struct {
+		req *v1.GetForwardingPipelineConfigRequest
+		res *v1.GetForwardingPipelineConfigResponse
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "Cookie Only",
+			args: args{req: cookieOnlyReq, res: emptyCookieResp},
+			want: true,
+		},
+		{
+			name: "Invalid Device ID",
+			args: args{req: invalidDeviceReq, res: emptyCookieResp},
+			want: false,
+		},
+		{
+			name: "Empty Request",
+			args: args{req: nil, res: emptyCookieResp},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p4rt.ProcessP4GetPipelineConfigRequest(tt.args.req, tt.args.res); got.Passed() != tt.want {
+				t.Errorf("ProcessP4GetPipelineConfigRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestProcessPacketIOOperation(t *testing.T) {
 	log.Info(strings.Repeat("*", 100))
 	log.Info("Start of TestProcessPacketIOOperation")
@@ -749,16 +846,16 @@ func TestProcessPacketIOOperation(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := p4rt.ProcessP4WriteRequest(tt.args.insertWriteReq, tt.args.writeResponse); got != tt.writeWant {
+			if got := p4rt.ProcessP4WriteRequest(tt.args.insertWriteReq, tt.args.writeResponse); got.Passed() != tt.writeWant {
 				t.Errorf("Insert Write ProcessP4WriteRequest() = %v, want %v", got, tt.writeWant)
 			}
-			if got := p4rt.ProcessPacketOutOperation(tt.args.po); got != tt.poWant {
+			if got := p4rt.ProcessPacketOutOperation(tt.args.po); got.Passed() != tt.poWant {
 				t.Errorf("ProcessPacketOutOperation() = %v, want %v", got, tt.poWant)
 			}
-			if got := p4rt.ProcessPacketIn(tt.args.pi); got != tt.piWant {
+			if got := p4rt.ProcessPacketIn(tt.args.pi); got.Passed() != tt.piWant {
 				t.Errorf("ProcessPacketIn() = %v, want %v", got, tt.piWant)
 			}
-			if got := p4rt.ProcessP4WriteRequest(tt.args.deleteWriteReq, tt.args.writeResponse); got != tt.writeWant {
+			if got := p4rt.ProcessP4WriteRequest(tt.args.deleteWriteReq, tt.args.writeResponse); got.Passed() != tt.writeWant {
 				t.Errorf("Delete Write ProcessP4WriteRequest() = %v, want %v", got, tt.writeWant)
 			}
 		})
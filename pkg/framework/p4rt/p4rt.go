@@ -14,12 +14,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"regexp"
+	"strings"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	v1 "github.com/p4lang/p4runtime/go/p4/v1"
-	"github.com/stratum/testvectors-runner/pkg/utils/common"
+	"github.com/stratum/testvectors-runner/pkg/result"
+	"github.com/stratum/testvectors-runner/pkg/utils/transport"
 	tvb "github.com/stratum/testvectors/proto/target"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 //Connection struct stores the P4Runtime client connection, context and cancel function.
@@ -42,26 +50,58 @@ func connect(tg *tvb.Target) connection {
 	ctx, cancel := context.WithTimeout(ctx, CtxTimeout)
 	defer cancel()
 
-	conn, err := grpc.DialContext(ctx, tg.Address, grpc.WithInsecure())
+	dialOpt, err := transport.DialOption()
+	if err != nil {
+		return connection{connError: fmt.Errorf("cannot build transport credentials for target %s, %v", tg.Address, err)}
+	}
+	conn, err := grpc.DialContext(ctx, tg.Address, dialOpt)
 	if err != nil {
 		return connection{connError: fmt.Errorf("cannot dial target %s, %v", tg.Address, err)}
 	}
 	return connection{ctx: ctx, client: v1.NewP4RuntimeClient(conn), cancel: func() { conn.Close() }}
 }
 
-//Write calls P4RuntimeClient's Write and returns WriteResponse
-func (c connection) Write(writeReq *v1.WriteRequest) *v1.WriteResponse {
+//Write calls P4RuntimeClient's Write and returns WriteResponse and the RPC error, which carries the gRPC status of a failed write
+func (c connection) Write(writeReq *v1.WriteRequest) (*v1.WriteResponse, error) {
 	log.Info("Sending P4 write request")
 	log.Debugf("Write request: %s", writeReq)
 	ctx := context.Background()
 	resp, err := c.client.Write(ctx, writeReq)
 	if err != nil {
-		log.Errorf("Error sending P4 write request:%v", err)
-		return nil
+		log.Warnf("Error sending P4 write request:%s", formatWriteError(err))
+		return nil, err
 	}
 	log.Infof("Received P4 write response")
 	log.Debugf("P4 write response:%s", resp)
-	return resp
+	return resp, nil
+}
+
+//Read calls P4RuntimeClient's Read and returns all ReadResponses received from the stream.
+//It returns nil if the RPC fails.
+func (c connection) Read(readReq *v1.ReadRequest) []*v1.ReadResponse {
+	log.Info("Sending P4 read request")
+	log.Debugf("Read request: %s", readReq)
+	ctx := context.Background()
+	stream, err := c.client.Read(ctx, readReq)
+	if err != nil {
+		log.Errorf("Error sending P4 read request:%v", err)
+		return nil
+	}
+	resps := []*v1.ReadResponse{}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Errorf("Error receiving P4 read response:%v", err)
+			return nil
+		}
+		resps = append(resps, resp)
+	}
+	log.Infof("Received %d P4 read responses", len(resps))
+	log.Debugf("P4 read responses:%s", resps)
+	return resps
 }
 
 //SetForwardingPipelineConfig calls P4RuntimeClient's SetForwardingPipelineConfig and returns SetForwardingPipelineConfigResponse
@@ -79,8 +119,120 @@ func (c connection) SetForwardingPipelineConfig(pipelineCfg *v1.SetForwardingPip
 	return resp
 }
 
-//verifyWriteResp compares two WriteResponses and returns true or false
-func verifyWriteResp(expected, actual *v1.WriteResponse) bool {
+//GetForwardingPipelineConfig calls P4RuntimeClient's GetForwardingPipelineConfig and returns GetForwardingPipelineConfigResponse
+func (c connection) GetForwardingPipelineConfig(req *v1.GetForwardingPipelineConfigRequest) *v1.GetForwardingPipelineConfigResponse {
+	log.Info("Sending P4 get pipeline config request")
+	log.Debugf("Get pipeline config request: %s", req)
+	ctx := context.Background()
+	resp, err := c.client.GetForwardingPipelineConfig(ctx, req)
+	if err != nil {
+		log.Errorf("Error getting P4 pipeline config:%v", err)
+		return nil
+	}
+	log.Info("Received P4 get pipeline config response")
+	log.Debugf("P4 get pipeline config response:%s\n", resp)
+	return resp
+}
+
+//verifyWriteError compares the gRPC status of a write with the expected status and returns the result.
+//Messages of the status and of the p4.v1.Error details are regular expressions, empty ones match any message.
+//Details are only compared if expected, one p4.v1.Error per update as the P4Runtime spec requires. Their space and code
+//are only compared if set in the expected error.
+func verifyWriteError(expected *spb.Status, err error) *result.Result {
+	actual := status.Convert(err).Proto()
+	res := result.Pass()
+	if expected.GetCode() != actual.GetCode() {
+		res.Add(result.Mismatch("write status codes are unequal", codes.Code(expected.GetCode()), codes.Code(actual.GetCode())))
+	}
+	if !matchPattern(expected.GetMessage(), actual.GetMessage()) {
+		res.Add(result.Mismatch("write status message doesn't match", expected.GetMessage(), actual.GetMessage()))
+	}
+	if len(expected.GetDetails()) > 0 {
+		expErrs, err := getP4Errors(expected)
+		if err != nil {
+			return result.Failf("invalid expected write status details: %v", err)
+		}
+		actErrs, err := getP4Errors(actual)
+		if err != nil {
+			return result.Failf("invalid write status details: %v", err)
+		}
+		res.Add(verifyP4Errors(expErrs, actErrs))
+	}
+	if res.Passed() {
+		log.Infof("Write status is as expected: %s", codes.Code(actual.GetCode()))
+	} else {
+		log.Warnf("Write status is unexpected\nExpected: %s\nActual  : %s", expected, formatWriteError(err))
+	}
+	return res
+}
+
+//verifyP4Errors compares the per update p4.v1.Errors of a write status and returns the result
+func verifyP4Errors(expected, actual []*v1.Error) *result.Result {
+	if len(expected) != len(actual) {
+		return result.Mismatch("numbers of write error details are unequal", len(expected), len(actual))
+	}
+	var diff []string
+	for i := range expected {
+		exp, act := expected[i], actual[i]
+		switch {
+		case exp.GetCanonicalCode() != act.GetCanonicalCode():
+			diff = append(diff, fmt.Sprintf("update %d: expected canonical code %s, actual %s", i, codes.Code(exp.GetCanonicalCode()), codes.Code(act.GetCanonicalCode())))
+		case exp.GetSpace() != "" && (exp.GetSpace() != act.GetSpace() || exp.GetCode() != act.GetCode()):
+			diff = append(diff, fmt.Sprintf("update %d: expected code %d in space %q, actual %d in space %q", i, exp.GetCode(), exp.GetSpace(), act.GetCode(), act.GetSpace()))
+		case !matchPattern(exp.GetMessage(), act.GetMessage()):
+			diff = append(diff, fmt.Sprintf("update %d: expected message matching %q, actual %q", i, exp.GetMessage(), act.GetMessage()))
+		}
+	}
+	if len(diff) == 0 {
+		return result.Pass()
+	}
+	r := result.Failf("%d write error details don't match", len(diff))
+	r.Diff = strings.Join(diff, "\n")
+	return r
+}
+
+//getP4Errors unpacks the p4.v1.Error details of a status
+func getP4Errors(st *spb.Status) ([]*v1.Error, error) {
+	var errs []*v1.Error
+	for _, detail := range st.GetDetails() {
+		p4Err := &v1.Error{}
+		if err := ptypes.UnmarshalAny(detail, p4Err); err != nil {
+			return nil, err
+		}
+		errs = append(errs, p4Err)
+	}
+	return errs, nil
+}
+
+//matchPattern returns true if the message matches the regular expression pattern, or the pattern is empty
+func matchPattern(pattern, message string) bool {
+	if pattern == "" {
+		return true
+	}
+	matched, err := regexp.MatchString(pattern, message)
+	if err != nil {
+		log.Warnf("Invalid message pattern %q: %v", pattern, err)
+		return pattern == message
+	}
+	return matched
+}
+
+//formatWriteError returns the gRPC status of a write error with its p4.v1.Error details, one per line
+func formatWriteError(err error) string {
+	st := status.Convert(err).Proto()
+	s := fmt.Sprintf("%s: %s", codes.Code(st.GetCode()), st.GetMessage())
+	p4Errs, detailsErr := getP4Errors(st)
+	if detailsErr != nil {
+		return s
+	}
+	for i, p4Err := range p4Errs {
+		s += fmt.Sprintf("\n  update %d: %s", i, p4Err)
+	}
+	return s
+}
+
+//verifyWriteResp compares two WriteResponses and returns the result
+func verifyWriteResp(expected, actual *v1.WriteResponse) *result.Result {
 	//FIXME
 	//initializing expected to empty response to avoid nil pointer exception when tv doesn't have response
 	if expected == nil {
@@ -89,22 +241,83 @@ func verifyWriteResp(expected, actual *v1.WriteResponse) bool {
 	switch {
 	case expected == nil && actual == nil:
 		log.Debug("Both write responses are empty")
-		return true
+		return result.Pass()
 	case expected == nil || actual == nil:
 		log.Warnf("Write responses are unequal\nExpected: %s\nActual  : %s\n", expected, actual)
-		return false
+		return result.Mismatch("write responses are unequal", expected, actual)
 	case proto.Equal(expected, actual):
 		log.Info("Write responses are equal")
 		log.Debugf("Write response: %s\n", actual)
-		return true
+		return result.Pass()
 	default:
 		log.Warnf("Write responses are unequal\nExpected: %s\nActual  : %s\n", expected, actual)
-		return false
+		return result.Mismatch("write responses are unequal", expected, actual)
+	}
+}
+
+//verifyReadResp compares the entities from expected and actual ReadResponses and returns the result.
+//Entities are compared as a set, regardless of their order and of how they are split across ReadResponse messages.
+func verifyReadResp(expected, actual []*v1.ReadResponse) *result.Result {
+	if actual == nil {
+		log.Warn("Read responses are unequal, no response received")
+		return result.Failf("no read response received")
+	}
+	expEntities, actEntities := getEntities(expected), getEntities(actual)
+	missing, unexpected := diffEntities(expEntities, actEntities)
+	if len(missing) == 0 && len(unexpected) == 0 {
+		log.Infof("Read responses are equal, %d entities", len(actEntities))
+		log.Debugf("Read entities: %s\n", actEntities)
+		return result.Pass()
+	}
+	var diff []string
+	for _, e := range missing {
+		log.Warnf("Expected entity not found in read responses: %s", e)
+		diff = append(diff, fmt.Sprintf("Missing   : %s", e))
+	}
+	for _, e := range unexpected {
+		log.Warnf("Unexpected entity in read responses: %s", e)
+		diff = append(diff, fmt.Sprintf("Unexpected: %s", e))
+	}
+	r := result.Failf("%d expected entities not found, %d unexpected entities", len(missing), len(unexpected))
+	r.Diff = strings.Join(diff, "\n")
+	return r
+}
+
+//getEntities returns the union of entities from all ReadResponses
+func getEntities(resps []*v1.ReadResponse) []*v1.Entity {
+	var entities []*v1.Entity
+	for _, resp := range resps {
+		entities = append(entities, resp.GetEntities()...)
+	}
+	return entities
+}
+
+//diffEntities matches expected entities against actual entities regardless of order.
+//It returns the expected entities that were not found and the actual entities that were not expected.
+func diffEntities(expected, actual []*v1.Entity) (missing, unexpected []*v1.Entity) {
+	matched := make([]bool, len(actual))
+	for _, exp := range expected {
+		found := false
+		for i, act := range actual {
+			if !matched[i] && proto.Equal(exp, act) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, exp)
+		}
+	}
+	for i, act := range actual {
+		if !matched[i] {
+			unexpected = append(unexpected, act)
+		}
 	}
+	return missing, unexpected
 }
 
-//verifySetForwardingPipelineConfigResp compares two SetForwardingPipelineConfigResponse and returns true or false
-func verifySetForwardingPipelineConfigResp(expected, actual *v1.SetForwardingPipelineConfigResponse) bool {
+//verifySetForwardingPipelineConfigResp compares two SetForwardingPipelineConfigResponse and returns the result
+func verifySetForwardingPipelineConfigResp(expected, actual *v1.SetForwardingPipelineConfigResponse) *result.Result {
 	//FIXME
 	//initializing expected to empty response to avoid nil pointer exception when tv doesn't have response
 	if expected == nil {
@@ -114,59 +327,144 @@ func verifySetForwardingPipelineConfigResp(expected, actual *v1.SetForwardingPip
 	switch {
 	case expected == nil && actual == nil:
 		log.Debug("Both SetForwardingPipelineConfig responses are empty")
-		return true
+		return result.Pass()
 	case expected == nil || actual == nil:
 		log.Warnf("SetForwardingPipelineConfig responses are unequal\nExpected: %s\nActual  : %s\n", expected, actual)
-		return false
+		return result.Mismatch("SetForwardingPipelineConfig responses are unequal", expected, actual)
 	case proto.Equal(expected, actual):
 		log.Info("SetForwardingPipelineConfig responses are equal")
 		log.Debugf("SetForwardingPipelineConfig response: %s\n", actual)
-		return true
+		return result.Pass()
 	default:
 		log.Warnf("SetForwardingPipelineConfig responses are unequal\nExpected: %s\nActual  : %s\n", expected, actual)
-		return false
+		return result.Mismatch("SetForwardingPipelineConfig responses are unequal", expected, actual)
 	}
 }
 
-//verifyPacketIn compares two PacketIns and returns true or false
-func verifyPacketIn(expected, actual *v1.PacketIn) bool {
+//verifyGetForwardingPipelineConfigResp compares two GetForwardingPipelineConfigResponses and returns the result.
+//Only the parts of the pipeline config selected by responseType are compared:
+//ALL compares P4Info, device config and cookie, COOKIE_ONLY compares the cookie,
+//P4INFO_AND_COOKIE compares P4Info and cookie, DEVICE_CONFIG_AND_COOKIE compares device config and cookie.
+func verifyGetForwardingPipelineConfigResp(expected, actual *v1.GetForwardingPipelineConfigResponse, responseType v1.GetForwardingPipelineConfigRequest_ResponseType) *result.Result {
+	switch {
+	case expected == nil && actual == nil:
+		log.Debug("Both GetForwardingPipelineConfig responses are empty")
+		return result.Pass()
+	case expected == nil || actual == nil:
+		log.Warnf("GetForwardingPipelineConfig responses are unequal\nExpected: %s\nActual  : %s\n", expected, actual)
+		return result.Mismatch("GetForwardingPipelineConfig responses are unequal", expected, actual)
+	}
+	exp, act := expected.GetConfig(), actual.GetConfig()
+	res := result.Pass()
+	if !proto.Equal(exp.GetCookie(), act.GetCookie()) {
+		log.Warnf("Pipeline config cookies are unequal\nExpected: %s\nActual  : %s\n", exp.GetCookie(), act.GetCookie())
+		res.Add(result.Mismatch("pipeline config cookies are unequal", exp.GetCookie(), act.GetCookie()))
+	}
+	if responseType == v1.GetForwardingPipelineConfigRequest_ALL || responseType == v1.GetForwardingPipelineConfigRequest_P4INFO_AND_COOKIE {
+		if !proto.Equal(exp.GetP4Info(), act.GetP4Info()) {
+			log.Warnf("Pipeline config P4Infos are unequal\nExpected: %s\nActual  : %s\n", exp.GetP4Info(), act.GetP4Info())
+			res.Add(result.Mismatch("pipeline config P4Infos are unequal", exp.GetP4Info(), act.GetP4Info()))
+		}
+	}
+	if responseType == v1.GetForwardingPipelineConfigRequest_ALL || responseType == v1.GetForwardingPipelineConfigRequest_DEVICE_CONFIG_AND_COOKIE {
+		if !bytes.Equal(exp.GetP4DeviceConfig(), act.GetP4DeviceConfig()) {
+			log.Warnf("Pipeline config device configs are unequal, expected %d bytes, actual %d bytes", len(exp.GetP4DeviceConfig()), len(act.GetP4DeviceConfig()))
+			res.Add(result.Mismatch("pipeline config device configs are unequal", fmt.Sprintf("%d bytes", len(exp.GetP4DeviceConfig())), fmt.Sprintf("%d bytes", len(act.GetP4DeviceConfig()))))
+		}
+	}
+	if res.Passed() {
+		log.Infof("GetForwardingPipelineConfig responses are equal for response type %s", responseType)
+		log.Debugf("GetForwardingPipelineConfig response: %s\n", actual)
+	}
+	return res
+}
+
+//verifyPacketIn compares two PacketIns and returns the result
+func verifyPacketIn(expected, actual *v1.PacketIn) *result.Result {
+	metadataRes := verifyMetadata(expected.GetMetadata(), actual.GetMetadata())
 	switch {
 	case expected == nil && actual == nil:
 		log.Debug("Both packets are empty")
-		return true
+		return result.Pass()
 	case expected == nil || actual == nil:
 		log.Warnf("Packets don't match\nExpected: % s\nActual  : % s\n", expected, actual)
-		return false
+		return result.Mismatch("packets don't match", expected, actual)
 	case !bytes.Equal(expected.GetPayload(), actual.GetPayload()):
 		log.Warnf("Payloads don't match\nExpected: % x\nActual  : % x\n", expected.GetPayload(), actual.GetPayload())
-		return false
-	case !compareMetadata(expected.GetMetadata(), actual.GetMetadata()):
-		log.Warnf("Metadata don't match\nExpected: % v\nActual  : % v\n", expected.GetMetadata(), actual.GetMetadata())
-		return false
+		return result.Mismatch("payloads don't match", fmt.Sprintf("% x", expected.GetPayload()), fmt.Sprintf("% x", actual.GetPayload()))
+	case !metadataRes.Passed():
+		log.Warnf("Metadata don't match\n%s", metadataRes.Diff)
+		return metadataRes
 	default:
 		log.Info("PacketIns are equal")
 		log.Debugf("PacketIn: %s", actual)
-		return true
+		return result.Pass()
 	}
 }
 
-func compareMetadata(m1, m2 []*v1.PacketMetadata) bool {
-	switch {
-	case len(m1) < 1 && len(m2) < 1:
-		log.Debug("Both metadata are empty")
-		return true
-	case len(m1) < 1 || len(m2) < 1:
-		log.Debug("Metadata don't match")
-		return false
-	default:
-		//TODO: verify complete metadata instead of only one
-		//return reflect.DeepEqual(m1, m2)
-		/*for i := 0; i < len(m1); i++ {
-			if m1[i].GetMetadataId() != m2[i].GetMetadataId() || common.GetInt(m1[i].GetValue()) != common.GetInt(m2[i].GetValue()) {
-				return false
-			}
+//ignoredMetadataIDs holds the packet-in metadata IDs which are not compared, e.g. timestamps
+var ignoredMetadataIDs = make(map[uint32]bool)
+
+//SetIgnoredMetadataIDs sets the packet-in metadata IDs which are skipped when verifying packet-ins
+func SetIgnoredMetadataIDs(ids []uint32) {
+	ignoredMetadataIDs = make(map[uint32]bool)
+	for _, id := range ids {
+		ignoredMetadataIDs[id] = true
+	}
+}
+
+//verifyMetadata compares two packet metadata lists by metadata ID regardless of order.
+//Values are compared in canonical form and metadata with ignored IDs are skipped.
+func verifyMetadata(expected, actual []*v1.PacketMetadata) *result.Result {
+	expIDs, exp := getMetadataValues(expected)
+	actIDs, act := getMetadataValues(actual)
+	var diff []string
+	for _, id := range expIDs {
+		actValue, ok := act[id]
+		switch {
+		case !ok:
+			diff = append(diff, fmt.Sprintf("metadata %d: expected % x, actual missing", id, exp[id]))
+		case !bytes.Equal(exp[id], actValue):
+			diff = append(diff, fmt.Sprintf("metadata %d: expected % x, actual % x", id, exp[id], actValue))
+		}
+	}
+	for _, id := range actIDs {
+		if _, ok := exp[id]; !ok {
+			diff = append(diff, fmt.Sprintf("metadata %d: expected missing, actual % x", id, act[id]))
+		}
+	}
+	if len(diff) == 0 {
+		return result.Pass()
+	}
+	r := result.Failf("%d metadata don't match", len(diff))
+	r.Diff = strings.Join(diff, "\n")
+	return r
+}
+
+//getMetadataValues returns the metadata IDs in order of appearance and the canonical values keyed by ID.
+//Metadata with ignored IDs are left out.
+func getMetadataValues(metadata []*v1.PacketMetadata) ([]uint32, map[uint32][]byte) {
+	var ids []uint32
+	values := make(map[uint32][]byte)
+	for _, md := range metadata {
+		id := md.GetMetadataId()
+		if ignoredMetadataIDs[id] {
+			continue
+		}
+		if _, ok := values[id]; !ok {
+			ids = append(ids, id)
 		}
-		return true*/
-		return common.GetInt(m1[0].Value) == common.GetInt(m2[0].Value)
+		values[id] = canonicalBytes(md.GetValue())
+	}
+	return ids, values
+}
+
+//canonicalBytes returns the P4Runtime canonical byte string of a value, i.e. without leading zero bytes.
+//Zero is represented as a single zero byte.
+func canonicalBytes(value []byte) []byte {
+	value = bytes.TrimLeft(value, "\x00")
+	if len(value) == 0 {
+		return []byte{0}
 	}
+	return value
 }
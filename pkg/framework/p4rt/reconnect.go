@@ -0,0 +1,206 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package p4rt
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stratum/testvectors-runner/pkg/result"
+)
+
+//Backoff between attempts to reopen a broken stream channel, doubled after each failed attempt up to the maximum
+const (
+	initialReconnectBackoff = 100 * time.Millisecond
+	maxReconnectBackoff     = 5 * time.Second
+)
+
+//reconnectTimeout after which reopening a broken stream channel is given up
+var reconnectTimeout = time.Minute
+
+//SetReconnectTimeout sets how long reopening a broken stream channel is tried, 0 disables reconnecting
+func SetReconnectTimeout(timeout time.Duration) {
+	reconnectTimeout = timeout
+}
+
+//streamEvent records the loss of a stream channel, whether it was reopened and whether the controller became primary again
+type streamEvent struct {
+	time        time.Time
+	err         error
+	reconnected bool
+	downtime    time.Duration
+	//controller which arbitrated again on the reopened stream channel, nil if none had arbitrated
+	controller *Controller
+	//primary is true if the controller became primary again
+	primary bool
+}
+
+func (e streamEvent) String() string {
+	lost := fmt.Sprintf("P4Runtime stream lost at %s: %v", e.time.Format(time.RFC3339), e.err)
+	downtime := e.downtime.Round(time.Millisecond)
+	switch {
+	case !e.reconnected:
+		return fmt.Sprintf("%s, reconnect failed after %s", lost, downtime)
+	case e.controller == nil:
+		return fmt.Sprintf("%s, reconnected after %s", lost, downtime)
+	case !e.primary:
+		return fmt.Sprintf("%s, reconnected after %s but re-arbitration of %s failed", lost, downtime, e.controller)
+	default:
+		return fmt.Sprintf("%s, reconnected and re-arbitrated %s after %s", lost, e.controller, downtime)
+	}
+}
+
+//streamEvents stores the stream losses not yet reported to a test case
+type streamEvents struct {
+	mu     sync.Mutex
+	events []streamEvent
+	//pending counts the events whose re-arbitration is awaited, signalled by done when they are added
+	pending int
+	done    *sync.Cond
+}
+
+func newStreamEvents() *streamEvents {
+	se := &streamEvents{}
+	se.done = sync.NewCond(&se.mu)
+	return se
+}
+
+func (se *streamEvents) add(e streamEvent) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	se.events = append(se.events, e)
+}
+
+//begin records an event whose re-arbitration is awaited, to be added with finish
+func (se *streamEvents) begin() {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	se.pending++
+}
+
+//finish adds an event recorded by begin
+func (se *streamEvents) finish(e streamEvent) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	se.events = append(se.events, e)
+	se.pending--
+	se.done.Broadcast()
+}
+
+//take waits for the pending events, then returns and clears the stored events
+func (se *streamEvents) take() []streamEvent {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	for se.pending > 0 {
+		se.done.Wait()
+	}
+	events := se.events
+	se.events = nil
+	return events
+}
+
+//reopen opens a new stream channel with exponential backoff until it succeeds or the reconnect timeout expires.
+//It returns false if the stream channel was closed or could not be reopened.
+func (s streamChannel) reopen() bool {
+	if reconnectTimeout <= 0 {
+		return false
+	}
+	deadline := time.Now().Add(reconnectTimeout)
+	backoff := initialReconnectBackoff
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-s.ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		sc, err := s.p4rtClient.StreamChannel(s.ctx)
+		if err == nil {
+			log.Infof("Reopened P4Runtime stream channel after %d attempts", attempt)
+			s.stream.set(sc)
+			return true
+		}
+		log.Warnf("Failed to reopen P4Runtime stream channel, attempt %d: %v", attempt, err)
+		if time.Now().Add(backoff).After(deadline) {
+			return false
+		}
+		if backoff *= 2; backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+	}
+}
+
+//streamLost handles the loss of the stream channel. The stream channel is reopened and the last controller identity
+//which arbitrated on it arbitrates again. The event is stored to be reported by CheckStream, with the outcome of the arbitration.
+//It returns false if the stream channel could not be reopened.
+func (s streamChannel) streamLost(err error) bool {
+	start := time.Now()
+	log.Errorf("P4Runtime stream channel lost: %v", err)
+	s.primary.lost()
+	reconnected := s.reopen()
+	e := streamEvent{time: start, err: err, reconnected: reconnected, downtime: time.Since(start)}
+	if !reconnected {
+		log.Errorf("Failed to reopen P4Runtime stream channel within %s", reconnectTimeout)
+		s.events.add(e)
+		return false
+	}
+	c := s.primary.last()
+	if c == nil {
+		s.events.add(e)
+		return true
+	}
+	log.Infof("Arbitrating again with %s", c)
+	e.controller = c
+	//the response is received by Recv, so it is awaited in another goroutine while Recv goes on
+	s.events.begin()
+	go func() {
+		//the response may have been consumed by a concurrent arbitration, which still updates the primary state
+		e.primary = s.getMasterArbitrationLock(*c) || s.primary.isPrimary(*c)
+		if !e.primary {
+			log.Errorf("Re-arbitration of %s failed", c)
+		}
+		s.events.finish(e)
+	}()
+	return true
+}
+
+//CheckStream returns a failed result for each loss of the default or session stream channels since the last call.
+//It waits for the re-arbitrations in progress on reopened stream channels.
+func CheckStream() *result.Result {
+	res := result.Pass()
+	res.ID = "P4Runtime stream"
+	for _, e := range scv.events.take() {
+		res.Add(result.Failf("%s", e))
+	}
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	for name, sess := range sessions {
+		for _, e := range sess.scv.events.take() {
+			res.Add(result.Failf("session %s: %s", name, e))
+		}
+	}
+	return res
+}
+
+//streamClient holds the current stream channel client, which is replaced when the stream channel is reopened
+type streamClient struct {
+	mu sync.Mutex
+	sc v1.P4Runtime_StreamChannelClient
+}
+
+func (c *streamClient) get() v1.P4Runtime_StreamChannelClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sc
+}
+
+func (c *streamClient) set(sc v1.P4Runtime_StreamChannelClient) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sc = sc
+}
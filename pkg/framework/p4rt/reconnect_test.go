@@ -0,0 +1,139 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package p4rt
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+//flakyServer answers arbitration updates and breaks the first breakStreams stream channels after answering.
+//If rejectReopened is set, arbitration updates on the stream channels opened after a broken one are rejected.
+type flakyServer struct {
+	v1.UnimplementedP4RuntimeServer
+	mu             sync.Mutex
+	breakStreams   int
+	rejectReopened bool
+	streams        int
+	arbitrations   []*v1.MasterArbitrationUpdate
+}
+
+func (f *flakyServer) StreamChannel(stream v1.P4Runtime_StreamChannelServer) error {
+	f.mu.Lock()
+	breakStream := f.breakStreams > 0
+	f.breakStreams--
+	reject := f.rejectReopened && f.streams > 0
+	f.streams++
+	f.mu.Unlock()
+	code := int32(codes.OK)
+	if reject {
+		code = int32(codes.AlreadyExists)
+	}
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		arb := req.GetArbitration()
+		if arb == nil {
+			continue
+		}
+		f.mu.Lock()
+		f.arbitrations = append(f.arbitrations, arb)
+		f.mu.Unlock()
+		resp := &v1.MasterArbitrationUpdate{DeviceId: arb.GetDeviceId(), ElectionId: arb.GetElectionId(), Status: &status.Status{Code: code}}
+		if err := stream.Send(&v1.StreamMessageResponse{Update: &v1.StreamMessageResponse_Arbitration{Arbitration: resp}}); err != nil {
+			return err
+		}
+		if breakStream {
+			return grpcstatus.Error(codes.Unavailable, "switch restarting")
+		}
+	}
+}
+
+func (f *flakyServer) arbitrationCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.arbitrations)
+}
+
+func TestStreamReconnect(t *testing.T) {
+	defer SetReconnectTimeout(reconnectTimeout)
+	tests := []struct {
+		name             string
+		timeout          time.Duration
+		rejectReopened   bool
+		wantArbitrations int
+		wantReconnected  bool
+		wantPrimary      bool
+		wantEvent        string
+	}{
+		{name: "Reconnect", timeout: time.Second, wantArbitrations: 2, wantReconnected: true, wantPrimary: true, wantEvent: "reconnected and re-arbitrated"},
+		{name: "Re-arbitration Rejected", timeout: time.Second, rejectReopened: true, wantArbitrations: 2, wantReconnected: true, wantPrimary: false, wantEvent: "re-arbitration of"},
+		{name: "Reconnect Disabled", timeout: 0, wantArbitrations: 1, wantReconnected: false, wantPrimary: false, wantEvent: "reconnect failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetReconnectTimeout(tt.timeout)
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := grpc.NewServer()
+			flaky := &flakyServer{breakStreams: 1, rejectReopened: tt.rejectReopened}
+			v1.RegisterP4RuntimeServer(server, flaky)
+			go server.Serve(lis)
+			defer server.Stop()
+			conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			s := getStreamChannel(v1.NewP4RuntimeClient(conn))
+			defer s.Close()
+			c := Controller{DeviceID: 1, ElectionID: &v1.Uint128{High: 1, Low: 5}}
+			if !s.getMasterArbitrationLock(c) {
+				t.Fatal("getMasterArbitrationLock() = false, want true")
+			}
+			deadline := time.Now().Add(2 * time.Second)
+			for flaky.arbitrationCount() < tt.wantArbitrations && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			//give the stream channel time to settle before checking the state
+			time.Sleep(100 * time.Millisecond)
+			if got := flaky.arbitrationCount(); got != tt.wantArbitrations {
+				t.Errorf("arbitrations = %d, want %d", got, tt.wantArbitrations)
+			}
+			events := s.events.take()
+			if len(events) != 1 {
+				t.Fatalf("stream events = %v, want 1 event", events)
+			}
+			if events[0].reconnected != tt.wantReconnected {
+				t.Errorf("reconnected = %v, want %v", events[0].reconnected, tt.wantReconnected)
+			}
+			if events[0].primary != tt.wantPrimary {
+				t.Errorf("re-arbitrated primary = %v, want %v", events[0].primary, tt.wantPrimary)
+			}
+			if !strings.Contains(events[0].String(), tt.wantEvent) {
+				t.Errorf("stream event = %s, want it to contain %q", events[0], tt.wantEvent)
+			}
+			if got := s.primary.isPrimary(c); got != tt.wantPrimary {
+				t.Errorf("isPrimary() = %v, want %v", got, tt.wantPrimary)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package p4rt
+
+import (
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stratum/testvectors-runner/pkg/result"
+)
+
+//session is a named controller with its own stream channel, used to test arbitration between several controllers
+type session struct {
+	controller Controller
+	scv        streamChannel
+}
+
+var (
+	sessionsMu sync.Mutex
+	sessions   = make(map[string]*session)
+)
+
+//getSession returns a copy of the named session. The empty name refers to the default controller and its stream channel.
+func getSession(name string) (session, bool) {
+	if name == "" {
+		return session{controller: defaultController, scv: scv}, true
+	}
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	sess, ok := sessions[name]
+	if !ok {
+		return session{}, false
+	}
+	return *sess, true
+}
+
+//OpenSession opens a stream channel for a named controller session and sends a master arbitration update with its identity.
+//The arbitration response is not awaited, use ProcessArbitrationUpdate to verify if the session became primary or backup.
+func OpenSession(name string, c Controller) *result.Result {
+	if name == "" {
+		return result.Failf("empty session name")
+	}
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	if _, ok := sessions[name]; ok {
+		return result.Failf("session %s is already open", name)
+	}
+	log.Infof("Opening session %s with %s", name, c)
+	sess := &session{controller: c, scv: getStreamChannel(p4rtConn.client)}
+	go drainPacketIn(name, sess.scv)
+	sessions[name] = sess
+	sess.scv.sendArbitration(c)
+	return result.Pass()
+}
+
+//CloseSession closes the stream channel of a named session, e.g. to make a backup session primary
+func CloseSession(name string) *result.Result {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	sess, ok := sessions[name]
+	if !ok {
+		return result.Failf("unknown session %s", name)
+	}
+	log.Infof("Closing session %s", name)
+	sess.scv.Close()
+	delete(sessions, name)
+	return result.Pass()
+}
+
+//closeSessions closes the stream channels of all named sessions
+func closeSessions() {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	for name, sess := range sessions {
+		log.Debugf("Closing session %s", name)
+		sess.scv.Close()
+		delete(sessions, name)
+	}
+}
+
+//ArbitrateSession sends a master arbitration update for a named session with a new election ID, e.g. to take over as primary.
+//The arbitration response is not awaited, use ProcessArbitrationUpdate to verify it.
+//The default controller is arbitrated by the runner itself, use SetDefaultController to change its election ID.
+func ArbitrateSession(name string, electionID *v1.Uint128) *result.Result {
+	if name == "" {
+		return result.Failf("the default session is arbitrated by the runner, use SetDefaultController")
+	}
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	sess, ok := sessions[name]
+	if !ok {
+		return result.Failf("unknown session %s", name)
+	}
+	sess.controller.ElectionID = electionID
+	log.Infof("Arbitrating session %s with %s", name, sess.controller)
+	sess.scv.sendArbitration(sess.controller)
+	return result.Pass()
+}
+
+//ProcessArbitrationUpdate waits for the expected arbitration updates on the stream channel of a named session,
+//in any order, and verifies them. The empty name refers to the default stream channel.
+//Arbitration responses consumed when the runner takes the master lock itself are not seen here.
+func ProcessArbitrationUpdate(name string, exp []*v1.MasterArbitrationUpdate) *result.Result {
+	sess, ok := getSession(name)
+	if !ok {
+		return result.Failf("unknown session %s", name)
+	}
+	var msgs []*v1.StreamMessageResponse
+	for _, arb := range exp {
+		msgs = append(msgs, &v1.StreamMessageResponse{Update: &v1.StreamMessageResponse_Arbitration{Arbitration: arb}})
+	}
+	return verifyStreamMessages(sess.scv.streamMsgs, "arbitration updates", msgs)
+}
+
+//ProcessSessionWriteRequest sends the write request with the device ID, election ID and role ID of a named session
+//and compares the response. Unlike ProcessP4WriteRequest it does not take the master lock, so that writes from backup
+//sessions can be tested.
+func ProcessSessionWriteRequest(name string, wreq *v1.WriteRequest, wres *v1.WriteResponse) *result.Result {
+	if wreq == nil {
+		return result.Failf("empty write request")
+	}
+	sess, ok := getSession(name)
+	if !ok {
+		return result.Failf("unknown session %s", name)
+	}
+	wreq = proto.Clone(wreq).(*v1.WriteRequest)
+	wreq.DeviceId = sess.controller.DeviceID
+	wreq.ElectionId = sess.controller.ElectionID
+	wreq.RoleId = sess.controller.Role.GetId()
+	resp, err := p4rtConn.Write(wreq)
+	if err != nil {
+		return result.Failf("write request failed with %s", formatWriteError(err))
+	}
+	return verifyWriteResp(wres, resp)
+}
+
+//ProcessSessionPacketOut sends a packet-out on the stream channel of a named session without taking the master lock
+func ProcessSessionPacketOut(name string, po *v1.PacketOut) *result.Result {
+	sess, ok := getSession(name)
+	if !ok {
+		return result.Failf("unknown session %s", name)
+	}
+	log.Infof("Sending packet on session %s", name)
+	log.Debugf("Packet info: %s", po)
+	sess.scv.pktOutChan <- po
+	return result.Pass()
+}
+
+//drainPacketIn drops the packet-ins received by a named session, so that its stream channel keeps receiving.
+//It returns when the stream channel of the session is closed.
+func drainPacketIn(name string, scv streamChannel) {
+	for {
+		select {
+		case pkt := <-scv.pktInChan:
+			log.Debugf("Dropping packet in received by session %s: %s", name, pkt)
+		case <-scv.ctx.Done():
+			log.Debugf("Stopped dropping packet ins of closed session %s", name)
+			return
+		}
+	}
+}
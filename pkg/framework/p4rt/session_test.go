@@ -0,0 +1,106 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package p4rt
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+)
+
+func TestSessions(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	v1.RegisterP4RuntimeServer(server, &flakyServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	savedConn := p4rtConn
+	defer func() { p4rtConn = savedConn }()
+	p4rtConn = connection{client: v1.NewP4RuntimeClient(conn)}
+	defer closeSessions()
+
+	arbitration := func(low uint64) []*v1.MasterArbitrationUpdate {
+		return []*v1.MasterArbitrationUpdate{{DeviceId: 1, ElectionId: &v1.Uint128{Low: low}, Status: &status.Status{Code: 0}}}
+	}
+	if res := OpenSession("backup", Controller{DeviceID: 1, ElectionID: &v1.Uint128{Low: 1}}); !res.Passed() {
+		t.Fatalf("OpenSession() = %v, want passed", res)
+	}
+	if res := OpenSession("backup", Controller{DeviceID: 1, ElectionID: &v1.Uint128{Low: 1}}); res.Passed() {
+		t.Errorf("OpenSession() of open session passed, want failed")
+	}
+	if res := ProcessArbitrationUpdate("backup", arbitration(1)); !res.Passed() {
+		t.Errorf("ProcessArbitrationUpdate() = %v, want passed", res)
+	}
+
+	tests := []struct {
+		name       string
+		session    string
+		electionID uint64
+		want       bool
+	}{
+		{name: "Named Session", session: "backup", electionID: 7, want: true},
+		{name: "Default Session", session: "", electionID: 8, want: false},
+		{name: "Unknown Session", session: "primary", electionID: 9, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ArbitrateSession(tt.session, &v1.Uint128{Low: tt.electionID}); got.Passed() != tt.want {
+				t.Fatalf("ArbitrateSession() = %v, want %v", got, tt.want)
+			}
+			if !tt.want {
+				return
+			}
+			if res := ProcessArbitrationUpdate(tt.session, arbitration(tt.electionID)); !res.Passed() {
+				t.Errorf("ProcessArbitrationUpdate() = %v, want passed", res)
+			}
+			if sess, _ := getSession(tt.session); sess.controller.ElectionID.GetLow() != tt.electionID {
+				t.Errorf("session election ID = %d, want %d", sess.controller.ElectionID.GetLow(), tt.electionID)
+			}
+		})
+	}
+
+	if res := CloseSession("backup"); !res.Passed() {
+		t.Errorf("CloseSession() = %v, want passed", res)
+	}
+	if res := ArbitrateSession("backup", &v1.Uint128{Low: 10}); res.Passed() {
+		t.Errorf("ArbitrateSession() of closed session passed, want failed")
+	}
+}
+
+func TestDrainPacketIn(t *testing.T) {
+	scv := streamChannel{pktInChan: make(chan *v1.PacketIn)}
+	scv.ctx, scv.cancel = context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		drainPacketIn("backup", scv)
+		close(done)
+	}()
+	select {
+	case scv.pktInChan <- &v1.PacketIn{Payload: []byte{1}}:
+	case <-time.After(time.Second):
+		t.Fatal("packet in not drained")
+	}
+	scv.cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("drainPacketIn() still running after the stream channel was closed")
+	}
+}
@@ -20,20 +20,26 @@ const CtxTimeout = 3 * time.Second
 
 //streamChannel struct stores stream channel client, cancel function and channels to receive stream messages
 type streamChannel struct {
-	sc                                   v1.P4Runtime_StreamChannelClient
-	scError                              error
-	cancel                               context.CancelFunc
-	masterArbRecvChan, masterArbSendChan chan *v1.MasterArbitrationUpdate
-	pktInChan                            chan *v1.PacketIn
-	pktOutChan                           chan *v1.PacketOut
-	genericStreamMessageChannel          chan *v1.StreamMessageResponse
+	stream            *streamClient
+	scError           error
+	p4rtClient        v1.P4RuntimeClient
+	ctx               context.Context
+	cancel            context.CancelFunc
+	masterArbRecvChan chan *v1.StreamMessageResponse
+	masterArbSendChan chan *v1.MasterArbitrationUpdate
+	pktInChan         chan *v1.PacketIn
+	pktOutChan        chan *v1.PacketOut
+	digestAckChan     chan *v1.DigestListAck
+	streamMsgs        *streamMessages
+	primary           *primaryState
+	events            *streamEvents
 }
 
 //Close P4Runtime_StreamChannelClient
 func (s streamChannel) Close() {
 	s.cancel()
-	if s.sc != nil {
-		err := s.sc.CloseSend()
+	if sc := s.stream.get(); sc != nil {
+		err := sc.CloseSend()
 		if err != nil {
 			log.Warn("Error closing the stream channel:", err)
 		}
@@ -43,14 +49,20 @@ func (s streamChannel) Close() {
 //GetStreamChannel gets a new P4Runtime stream channel client, starts Recv() and Send() goroutines
 func getStreamChannel(p4rtClient v1.P4RuntimeClient) streamChannel {
 	scv := streamChannel{}
-	scv.masterArbRecvChan = make(chan *v1.MasterArbitrationUpdate)
+	scv.masterArbRecvChan = make(chan *v1.StreamMessageResponse, 1)
 	scv.masterArbSendChan = make(chan *v1.MasterArbitrationUpdate)
 	scv.pktInChan = make(chan *v1.PacketIn)
 	scv.pktOutChan = make(chan *v1.PacketOut)
-	scv.genericStreamMessageChannel = make(chan *v1.StreamMessageResponse)
-	scContext := context.Background()
-	scContext, scv.cancel = context.WithCancel(scContext)
-	scv.sc, scv.scError = p4rtClient.StreamChannel(scContext)
+	scv.digestAckChan = make(chan *v1.DigestListAck)
+	scv.streamMsgs = newStreamMessages()
+	scv.primary = &primaryState{}
+	scv.events = newStreamEvents()
+	scv.p4rtClient = p4rtClient
+	scv.ctx, scv.cancel = context.WithCancel(context.Background())
+	scv.stream = &streamClient{}
+	sc, err := p4rtClient.StreamChannel(scv.ctx)
+	scv.stream.set(sc)
+	scv.scError = err
 	if scv.scError != nil {
 		log.Error(scv.scError)
 		log.Fatal("Unable to get a stream channel")
@@ -61,17 +73,25 @@ func getStreamChannel(p4rtClient v1.P4RuntimeClient) streamChannel {
 }
 
 //Recv runs a loop to continuously monitor stream channel client and sorts received messages to appropriate channels
+//If the stream channel breaks, it is reopened and the loop continues.
 //This method is called as go routine.
 func (s streamChannel) Recv() {
 	for {
-		if s.sc == nil {
+		sc := s.stream.get()
+		if sc == nil {
 			log.Debugf("Stream channel is nil or closed")
 			return
 		}
-		smr, err := s.sc.Recv()
+		smr, err := sc.Recv()
 		if err != nil {
-			log.Debugf("Failed to receive a message : %v\n", err)
-			return
+			if s.ctx.Err() != nil {
+				log.Debugf("Stream channel closed: %v", err)
+				return
+			}
+			if !s.streamLost(err) {
+				return
+			}
+			continue
 		}
 
 		switch {
@@ -79,14 +99,39 @@ func (s streamChannel) Recv() {
 			log.Debug("Empty message received")
 		case smr.GetPacket() != nil:
 			log.Debug("Packet Received")
-			s.pktInChan <- smr.GetPacket()
+			select {
+			case s.pktInChan <- smr.GetPacket():
+			case <-s.ctx.Done():
+				log.Debug("Dropping packet in of closed stream")
+				return
+			}
 		case smr.GetArbitration() != nil:
-			log.Debug("Arbitration lock")
-			s.masterArbRecvChan <- smr.GetArbitration()
+			log.Debug("Arbitration update received")
+			s.primary.update(smr.GetArbitration())
+			s.streamMsgs.add(smr)
+			select {
+			case s.masterArbRecvChan <- smr:
+			default:
+				log.Debug("Dropping arbitration update nobody waits for")
+			}
+		case smr.GetDigest() != nil:
+			log.Debug("Digest list received")
+			s.streamMsgs.add(smr)
+			//Send may have stopped with the stream, so the ack is dropped once the stream is closed
+			select {
+			case s.digestAckChan <- &v1.DigestListAck{DigestId: smr.GetDigest().GetDigestId(), ListId: smr.GetDigest().GetListId()}:
+			case <-s.ctx.Done():
+				log.Debug("Dropping digest list ack of closed stream")
+				return
+			}
+		case smr.GetIdleTimeoutNotification() != nil:
+			log.Debug("Idle timeout notification received")
+			s.streamMsgs.add(smr)
+		case smr.GetError() != nil:
+			log.Warnf("Stream error received: %s", smr.GetError())
+			s.streamMsgs.add(smr)
 		default:
-			s.genericStreamMessageChannel <- smr
-			log.Debug("In Process packet in else block")
-			log.Debugf("%T\n", smr)
+			log.Debugf("Ignoring stream message %T", smr.GetUpdate())
 			log.Debug(smr)
 		}
 	}
@@ -97,18 +142,28 @@ func (s streamChannel) Recv() {
 func (s streamChannel) Send() {
 	for {
 		select {
+		case <-s.ctx.Done():
+			log.Debug("Stream channel closed, stop sending")
+			return
 		case pktOut := <-s.pktOutChan:
 			log.Debug("In Send Stream Packet Out")
 			smr := &v1.StreamMessageRequest{Update: &v1.StreamMessageRequest_Packet{Packet: pktOut}}
-			sendErr := s.sc.Send(smr)
+			sendErr := s.stream.get().Send(smr)
 			if sendErr != nil {
 				log.Errorf("send err:%s\n", sendErr)
 			}
 			log.Debug("sent packet")
+		case digestAck := <-s.digestAckChan:
+			log.Debug("In Send Stream Digest List Ack")
+			smr := &v1.StreamMessageRequest{Update: &v1.StreamMessageRequest_DigestAck{DigestAck: digestAck}}
+			sendErr := s.stream.get().Send(smr)
+			if sendErr != nil {
+				log.Errorf("send err:%s\n", sendErr)
+			}
 		case masterArbitrationReq := <-s.masterArbSendChan:
 			log.Debug("In Send Stream Master Arbitration")
 			smr := &v1.StreamMessageRequest{Update: &v1.StreamMessageRequest_Arbitration{Arbitration: masterArbitrationReq}}
-			sendErr := s.sc.Send(smr)
+			sendErr := s.stream.get().Send(smr)
 			if sendErr != nil {
 				log.Debugf("send err:%s\n", sendErr)
 			}
@@ -116,22 +171,46 @@ func (s streamChannel) Send() {
 	}
 }
 
-//getMasterArbitrationLock sends master arbitration request to stream channel with provided deviceID and electionID
+//sendArbitration sends a master arbitration update with the provided controller identity without waiting for the response
+func (s streamChannel) sendArbitration(c Controller) {
+	s.primary.arbitrating(c)
+	arb := &v1.MasterArbitrationUpdate{}
+	arb.DeviceId = c.DeviceID
+	arb.ElectionId = c.ElectionID
+	arb.Role = c.Role
+	select {
+	case s.masterArbSendChan <- arb:
+	case <-s.ctx.Done():
+		log.Debugf("Dropping arbitration update of closed stream for %s", c)
+	}
+}
+
+//getMasterArbitrationLock sends master arbitration request to stream channel with provided controller identity
+//unless the stream is already primary for it.
 //returns true if master lock is achieved, false in case of error or timeout
-func (s streamChannel) getMasterArbitrationLock(deviceID uint64, electionID *v1.Uint128) bool {
+func (s streamChannel) getMasterArbitrationLock(c Controller) bool {
+	if s.primary.isPrimary(c) {
+		log.Debugf("Already master for %s", c)
+		return true
+	}
 	lockAchieved := false
 
-	arb := &v1.MasterArbitrationUpdate{}
-	arb.DeviceId = deviceID
-	arb.ElectionId = electionID
-	s.masterArbSendChan <- arb
+	//drop stale updates so that the response to this request is read
+	select {
+	case <-s.masterArbRecvChan:
+	default:
+	}
+	s.sendArbitration(c)
 	select {
 	case ret := <-s.masterArbRecvChan:
-		if ret.Status.Code == int32(scpb.Code_OK) {
-			log.Debug("Master lock achieved")
+		//the response is consumed here, arbitration expectations only see the other updates
+		s.streamMsgs.discard(ret)
+		arb := ret.GetArbitration()
+		if arb.GetStatus().GetCode() == int32(scpb.Code_OK) && sameIdentity(Controller{DeviceID: arb.GetDeviceId(), ElectionID: arb.GetElectionId(), Role: arb.GetRole()}, c) {
+			log.Debugf("Master lock achieved for %s", c)
 			lockAchieved = true
 		} else {
-			log.Errorf("Error getting master lock: %v", ret.Status)
+			log.Errorf("Error getting master lock for %s: %v", c, ret.GetArbitration().GetStatus())
 		}
 	case <-time.After(CtxTimeout):
 		log.Error("Timed out waiting for master arbitration response")
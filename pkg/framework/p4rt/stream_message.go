@@ -0,0 +1,228 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package p4rt
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stratum/testvectors-runner/pkg/result"
+)
+
+//StreamMsgTimeout for receiving all expected arbitration updates, digest lists, idle timeout notifications and stream errors
+const StreamMsgTimeout = 3 * time.Second
+
+//maxStreamMessages is the number of buffered stream messages above which the oldest ones are dropped
+const maxStreamMessages = 1000
+
+//streamMessages buffers the stream messages other than packet-ins until an expectation consumes them
+type streamMessages struct {
+	mu   sync.Mutex
+	msgs []*v1.StreamMessageResponse
+	//added is closed and replaced whenever a message is added
+	added chan struct{}
+}
+
+func newStreamMessages() *streamMessages {
+	return &streamMessages{added: make(chan struct{})}
+}
+
+//add appends a message to the buffer and wakes up waiting expectations.
+//If the buffer is full the oldest message is dropped.
+func (sm *streamMessages) add(msg *v1.StreamMessageResponse) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if len(sm.msgs) >= maxStreamMessages {
+		log.Warnf("Stream message buffer is full, dropping %s", sm.msgs[0])
+		sm.msgs = sm.msgs[1:]
+	}
+	sm.msgs = append(sm.msgs, msg)
+	close(sm.added)
+	sm.added = make(chan struct{})
+}
+
+//clear removes all the buffered messages, e.g. the messages no expectation of the previous test case consumed
+func (sm *streamMessages) clear() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if len(sm.msgs) > 0 {
+		log.Debugf("Discarding %d unconsumed stream messages", len(sm.msgs))
+	}
+	sm.msgs = nil
+}
+
+//discard removes the given message from the buffer, e.g. an arbitration response consumed by an arbitration request
+func (sm *streamMessages) discard(msg *v1.StreamMessageResponse) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for i, m := range sm.msgs {
+		if m == msg {
+			sm.msgs = append(sm.msgs[:i], sm.msgs[i+1:]...)
+			return
+		}
+	}
+}
+
+//expect waits until every expected message matches a distinct buffered message, in any order, or the timeout expires.
+//Matched messages are removed from the buffer. It returns the expected messages which were not matched
+//and the buffered messages of the same kinds which are left.
+func (sm *streamMessages) expect(expected []*v1.StreamMessageResponse, timeout time.Duration) (missing, unmatched []*v1.StreamMessageResponse) {
+	deadline := time.After(timeout)
+	missing = expected
+	for {
+		sm.mu.Lock()
+		missing = sm.match(missing)
+		added := sm.added
+		if len(missing) == 0 {
+			sm.mu.Unlock()
+			return nil, nil
+		}
+		sm.mu.Unlock()
+		select {
+		case <-added:
+		case <-deadline:
+			sm.mu.Lock()
+			defer sm.mu.Unlock()
+			for _, msg := range sm.msgs {
+				for _, exp := range missing {
+					if sameKind(exp, msg) {
+						unmatched = append(unmatched, msg)
+						break
+					}
+				}
+			}
+			return missing, unmatched
+		}
+	}
+}
+
+//match removes the buffered messages matching the expected ones and returns the expected messages left.
+//It must be called with the lock held.
+func (sm *streamMessages) match(expected []*v1.StreamMessageResponse) []*v1.StreamMessageResponse {
+	var missing []*v1.StreamMessageResponse
+	for _, exp := range expected {
+		found := false
+		for i, msg := range sm.msgs {
+			if equalStreamMessage(exp, msg) {
+				sm.msgs = append(sm.msgs[:i], sm.msgs[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, exp)
+		}
+	}
+	return missing
+}
+
+//sameKind returns true if both stream messages carry the same kind of update
+func sameKind(m1, m2 *v1.StreamMessageResponse) bool {
+	switch {
+	case m1.GetArbitration() != nil:
+		return m2.GetArbitration() != nil
+	case m1.GetDigest() != nil:
+		return m2.GetDigest() != nil
+	case m1.GetIdleTimeoutNotification() != nil:
+		return m2.GetIdleTimeoutNotification() != nil
+	case m1.GetError() != nil:
+		return m2.GetError() != nil
+	default:
+		return false
+	}
+}
+
+//equalStreamMessage compares an expected stream message with a received one.
+//Digest list IDs and timestamps are ignored, as are status and stream error messages when none is expected.
+//Arbitration election IDs are only compared when expected.
+//Digest data and idle timeout table entries are compared regardless of order.
+func equalStreamMessage(expected, actual *v1.StreamMessageResponse) bool {
+	switch {
+	case expected.GetArbitration() != nil && actual.GetArbitration() != nil:
+		exp, act := expected.GetArbitration(), actual.GetArbitration()
+		return exp.GetDeviceId() == act.GetDeviceId() && exp.GetRole().GetId() == act.GetRole().GetId() &&
+			(exp.GetElectionId() == nil || proto.Equal(exp.GetElectionId(), act.GetElectionId())) &&
+			exp.GetStatus().GetCode() == act.GetStatus().GetCode() &&
+			(exp.GetStatus().GetMessage() == "" || exp.GetStatus().GetMessage() == act.GetStatus().GetMessage())
+	case expected.GetDigest() != nil && actual.GetDigest() != nil:
+		exp, act := expected.GetDigest(), actual.GetDigest()
+		return exp.GetDigestId() == act.GetDigestId() && equalUnordered(p4DataMessages(exp.GetData()), p4DataMessages(act.GetData()))
+	case expected.GetIdleTimeoutNotification() != nil && actual.GetIdleTimeoutNotification() != nil:
+		exp, act := expected.GetIdleTimeoutNotification(), actual.GetIdleTimeoutNotification()
+		return equalUnordered(tableEntryMessages(exp.GetTableEntry()), tableEntryMessages(act.GetTableEntry()))
+	case expected.GetError() != nil && actual.GetError() != nil:
+		exp := proto.Clone(expected.GetError()).(*v1.StreamError)
+		act := proto.Clone(actual.GetError()).(*v1.StreamError)
+		if exp.GetMessage() == "" {
+			act.Message = ""
+		}
+		return proto.Equal(exp, act)
+	default:
+		return false
+	}
+}
+
+//equalUnordered returns true if both lists hold the same messages regardless of order
+func equalUnordered(expected, actual []proto.Message) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	matched := make([]bool, len(actual))
+	for _, exp := range expected {
+		found := false
+		for i, act := range actual {
+			if !matched[i] && proto.Equal(exp, act) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func p4DataMessages(data []*v1.P4Data) []proto.Message {
+	msgs := make([]proto.Message, len(data))
+	for i, d := range data {
+		msgs[i] = d
+	}
+	return msgs
+}
+
+func tableEntryMessages(entries []*v1.TableEntry) []proto.Message {
+	msgs := make([]proto.Message, len(entries))
+	for i, e := range entries {
+		msgs[i] = e
+	}
+	return msgs
+}
+
+//verifyStreamMessages waits for the expected stream messages and returns the result
+func verifyStreamMessages(sm *streamMessages, kind string, expected []*v1.StreamMessageResponse) *result.Result {
+	missing, unmatched := sm.expect(expected, StreamMsgTimeout)
+	if len(missing) == 0 {
+		log.Infof("All %d expected %s received", len(expected), kind)
+		return result.Pass()
+	}
+	log.Warnf("%d expected %s not received within %s", len(missing), kind, StreamMsgTimeout)
+	var diff []string
+	for _, msg := range missing {
+		diff = append(diff, fmt.Sprintf("missing: %s", msg))
+	}
+	for _, msg := range unmatched {
+		diff = append(diff, fmt.Sprintf("received: %s", msg))
+	}
+	r := result.Failf("%d expected %s not received within %s", len(missing), kind, StreamMsgTimeout)
+	r.Diff = strings.Join(diff, "\n")
+	return r
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package p4rt
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/genproto/googleapis/rpc/status"
+)
+
+func digestMsg(digestID uint32, listID uint64, values ...string) *v1.StreamMessageResponse {
+	digest := &v1.DigestList{DigestId: digestID, ListId: listID, Timestamp: time.Now().UnixNano()}
+	for _, value := range values {
+		digest.Data = append(digest.Data, &v1.P4Data{Data: &v1.P4Data_Bitstring{Bitstring: []byte(value)}})
+	}
+	return &v1.StreamMessageResponse{Update: &v1.StreamMessageResponse_Digest{Digest: digest}}
+}
+
+func idleTimeoutMsg(tableIDs ...uint32) *v1.StreamMessageResponse {
+	notification := &v1.IdleTimeoutNotification{Timestamp: time.Now().UnixNano()}
+	for _, tableID := range tableIDs {
+		notification.TableEntry = append(notification.TableEntry, &v1.TableEntry{TableId: tableID})
+	}
+	return &v1.StreamMessageResponse{Update: &v1.StreamMessageResponse_IdleTimeoutNotification{IdleTimeoutNotification: notification}}
+}
+
+func streamErrorMsg(code int32, message string) *v1.StreamMessageResponse {
+	return &v1.StreamMessageResponse{Update: &v1.StreamMessageResponse_Error{Error: &v1.StreamError{CanonicalCode: code, Message: message}}}
+}
+
+func arbitrationMsg(electionLow uint64, code int32) *v1.StreamMessageResponse {
+	arb := &v1.MasterArbitrationUpdate{DeviceId: 1, Status: &status.Status{Code: code}}
+	if electionLow != 0 {
+		arb.ElectionId = &v1.Uint128{Low: electionLow}
+	}
+	return &v1.StreamMessageResponse{Update: &v1.StreamMessageResponse_Arbitration{Arbitration: arb}}
+}
+
+func TestStreamMessagesExpect(t *testing.T) {
+	tests := []struct {
+		name          string
+		buffered      []*v1.StreamMessageResponse
+		delayed       []*v1.StreamMessageResponse
+		expected      []*v1.StreamMessageResponse
+		wantMissing   int
+		wantUnmatched int
+		wantLeft      int
+	}{
+		{
+			name:     "Nothing Expected",
+			buffered: []*v1.StreamMessageResponse{digestMsg(1, 1, "\x01")},
+			wantLeft: 1,
+		},
+		{
+			name:     "Digest Lists Any Order",
+			buffered: []*v1.StreamMessageResponse{digestMsg(1, 7, "\x02"), digestMsg(1, 8, "\x01", "\x03")},
+			expected: []*v1.StreamMessageResponse{digestMsg(1, 0, "\x03", "\x01"), digestMsg(1, 0, "\x02")},
+		},
+		{
+			name:     "Delayed Digest List",
+			delayed:  []*v1.StreamMessageResponse{digestMsg(1, 1, "\x01")},
+			expected: []*v1.StreamMessageResponse{digestMsg(1, 0, "\x01")},
+		},
+		{
+			name:          "Digest Data Mismatch",
+			buffered:      []*v1.StreamMessageResponse{digestMsg(1, 1, "\x01"), idleTimeoutMsg(5)},
+			expected:      []*v1.StreamMessageResponse{digestMsg(1, 0, "\x02")},
+			wantMissing:   1,
+			wantUnmatched: 1,
+			wantLeft:      2,
+		},
+		{
+			name:     "Idle Timeout Notification",
+			buffered: []*v1.StreamMessageResponse{digestMsg(1, 1, "\x01"), idleTimeoutMsg(5, 6)},
+			expected: []*v1.StreamMessageResponse{idleTimeoutMsg(6, 5)},
+			wantLeft: 1,
+		},
+		{
+			name:     "Stream Error Without Message",
+			buffered: []*v1.StreamMessageResponse{streamErrorMsg(3, "invalid packet out")},
+			expected: []*v1.StreamMessageResponse{streamErrorMsg(3, "")},
+		},
+		{
+			name:          "Stream Error Message Mismatch",
+			buffered:      []*v1.StreamMessageResponse{streamErrorMsg(3, "invalid packet out")},
+			expected:      []*v1.StreamMessageResponse{streamErrorMsg(3, "invalid digest ack")},
+			wantMissing:   1,
+			wantUnmatched: 1,
+			wantLeft:      1,
+		},
+		{
+			name:     "Arbitration Demotion",
+			buffered: []*v1.StreamMessageResponse{arbitrationMsg(5, 0), arbitrationMsg(6, 6)},
+			expected: []*v1.StreamMessageResponse{arbitrationMsg(6, 6)},
+			wantLeft: 1,
+		},
+		{
+			name:     "Arbitration Any Election ID",
+			buffered: []*v1.StreamMessageResponse{arbitrationMsg(6, 6)},
+			expected: []*v1.StreamMessageResponse{arbitrationMsg(0, 6)},
+		},
+		{
+			name:          "Arbitration Status Mismatch",
+			buffered:      []*v1.StreamMessageResponse{arbitrationMsg(5, 6)},
+			expected:      []*v1.StreamMessageResponse{arbitrationMsg(5, 0)},
+			wantMissing:   1,
+			wantUnmatched: 1,
+			wantLeft:      1,
+		},
+		{
+			name:        "Missing Second Message",
+			buffered:    []*v1.StreamMessageResponse{idleTimeoutMsg(5)},
+			expected:    []*v1.StreamMessageResponse{idleTimeoutMsg(5), idleTimeoutMsg(5)},
+			wantMissing: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newStreamMessages()
+			for _, msg := range tt.buffered {
+				sm.add(msg)
+			}
+			go func(delayed []*v1.StreamMessageResponse) {
+				time.Sleep(10 * time.Millisecond)
+				for _, msg := range delayed {
+					sm.add(msg)
+				}
+			}(tt.delayed)
+			missing, unmatched := sm.expect(tt.expected, 100*time.Millisecond)
+			if len(missing) != tt.wantMissing {
+				t.Errorf("expect() missing = %v, want %d", missing, tt.wantMissing)
+			}
+			if len(unmatched) != tt.wantUnmatched {
+				t.Errorf("expect() unmatched = %v, want %d", unmatched, tt.wantUnmatched)
+			}
+			if len(sm.msgs) != tt.wantLeft {
+				t.Errorf("buffered messages = %v, want %d", sm.msgs, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestStreamMessagesLimit(t *testing.T) {
+	sm := newStreamMessages()
+	for i := 0; i < maxStreamMessages+10; i++ {
+		sm.add(idleTimeoutMsg(uint32(i)))
+	}
+	if len(sm.msgs) != maxStreamMessages {
+		t.Fatalf("buffered messages = %d, want %d", len(sm.msgs), maxStreamMessages)
+	}
+	if missing, _ := sm.expect([]*v1.StreamMessageResponse{idleTimeoutMsg(9)}, 0); len(missing) != 1 {
+		t.Errorf("expect() found dropped message")
+	}
+	if missing, _ := sm.expect([]*v1.StreamMessageResponse{idleTimeoutMsg(10)}, 0); len(missing) != 0 {
+		t.Errorf("expect() missing = %v, want oldest kept message", missing)
+	}
+	sm.clear()
+	if len(sm.msgs) != 0 {
+		t.Errorf("buffered messages after clear() = %d, want 0", len(sm.msgs))
+	}
+}
@@ -0,0 +1,369 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package p4rt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	config "github.com/p4lang/p4runtime/go/p4/config/v1"
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func tableEntity(tableID uint32, priority int32) *v1.Entity {
+	return &v1.Entity{Entity: &v1.Entity_TableEntry{TableEntry: &v1.TableEntry{TableId: tableID, Priority: priority}}}
+}
+
+func counterEntity(counterID uint32, packets int64) *v1.Entity {
+	return &v1.Entity{Entity: &v1.Entity_CounterEntry{CounterEntry: &v1.CounterEntry{
+		CounterId: counterID,
+		Data:      &v1.CounterData{PacketCount: packets},
+	}}}
+}
+
+func TestVerifyReadResp(t *testing.T) {
+	var (
+		entry1   = tableEntity(33598026, 10)
+		entry2   = tableEntity(33598026, 20)
+		counter1 = counterEntity(302055013, 5)
+	)
+	type args struct {
+		expected []*v1.ReadResponse
+		actual   []*v1.ReadResponse
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "No Response",
+			args: args{expected: []*v1.ReadResponse{}, actual: nil},
+			want: false,
+		},
+		{
+			name: "Both Empty",
+			args: args{expected: nil, actual: []*v1.ReadResponse{}},
+			want: true,
+		},
+		{
+			name: "Same Order",
+			args: args{
+				expected: []*v1.ReadResponse{{Entities: []*v1.Entity{entry1, entry2, counter1}}},
+				actual:   []*v1.ReadResponse{{Entities: []*v1.Entity{entry1, entry2, counter1}}},
+			},
+			want: true,
+		},
+		{
+			name: "Different Order And Split",
+			args: args{
+				expected: []*v1.ReadResponse{{Entities: []*v1.Entity{entry1, entry2, counter1}}},
+				actual:   []*v1.ReadResponse{{Entities: []*v1.Entity{counter1}}, {Entities: []*v1.Entity{entry2, entry1}}},
+			},
+			want: true,
+		},
+		{
+			name: "Missing Entity",
+			args: args{
+				expected: []*v1.ReadResponse{{Entities: []*v1.Entity{entry1, entry2}}},
+				actual:   []*v1.ReadResponse{{Entities: []*v1.Entity{entry1}}},
+			},
+			want: false,
+		},
+		{
+			name: "Unexpected Entity",
+			args: args{
+				expected: []*v1.ReadResponse{{Entities: []*v1.Entity{entry1}}},
+				actual:   []*v1.ReadResponse{{Entities: []*v1.Entity{entry1, entry2}}},
+			},
+			want: false,
+		},
+		{
+			name: "Duplicate Entity",
+			args: args{
+				expected: []*v1.ReadResponse{{Entities: []*v1.Entity{entry1, entry1}}},
+				actual:   []*v1.ReadResponse{{Entities: []*v1.Entity{entry1, entry2}}},
+			},
+			want: false,
+		},
+		{
+			name: "Different Counter Data",
+			args: args{
+				expected: []*v1.ReadResponse{{Entities: []*v1.Entity{counter1}}},
+				actual:   []*v1.ReadResponse{{Entities: []*v1.Entity{counterEntity(302055013, 6)}}},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyReadResp(tt.args.expected, tt.args.actual); got.Passed() != tt.want {
+				t.Errorf("verifyReadResp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyGetForwardingPipelineConfigResp(t *testing.T) {
+	var (
+		p4Info      = &config.P4Info{PkgInfo: &config.PkgInfo{Arch: "v1model"}}
+		otherP4Info = &config.P4Info{PkgInfo: &config.PkgInfo{Arch: "tna"}}
+		pipeline    = &v1.GetForwardingPipelineConfigResponse{Config: &v1.ForwardingPipelineConfig{
+			P4Info:         p4Info,
+			P4DeviceConfig: []byte("\x01\x02"),
+			Cookie:         &v1.ForwardingPipelineConfig_Cookie{Cookie: 42},
+		}}
+		otherCookie = &v1.GetForwardingPipelineConfigResponse{Config: &v1.ForwardingPipelineConfig{
+			P4Info:         p4Info,
+			P4DeviceConfig: []byte("\x01\x02"),
+			Cookie:         &v1.ForwardingPipelineConfig_Cookie{Cookie: 43},
+		}}
+		otherInfo = &v1.GetForwardingPipelineConfigResponse{Config: &v1.ForwardingPipelineConfig{
+			P4Info:         otherP4Info,
+			P4DeviceConfig: []byte("\x01\x02"),
+			Cookie:         &v1.ForwardingPipelineConfig_Cookie{Cookie: 42},
+		}}
+		otherDeviceConfig = &v1.GetForwardingPipelineConfigResponse{Config: &v1.ForwardingPipelineConfig{
+			P4Info:         p4Info,
+			P4DeviceConfig: []byte("\x01\x03"),
+			Cookie:         &v1.ForwardingPipelineConfig_Cookie{Cookie: 42},
+		}}
+		cookieOnly = &v1.GetForwardingPipelineConfigResponse{Config: &v1.ForwardingPipelineConfig{
+			Cookie: &v1.ForwardingPipelineConfig_Cookie{Cookie: 42},
+		}}
+	)
+	type args struct {
+		expected     *v1.GetForwardingPipelineConfigResponse
+		actual       *v1.GetForwardingPipelineConfigResponse
+		responseType v1.GetForwardingPipelineConfigRequest_ResponseType
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "No Response",
+			args: args{expected: pipeline, actual: nil, responseType: v1.GetForwardingPipelineConfigRequest_ALL},
+			want: false,
+		},
+		{
+			name: "All Equal",
+			args: args{expected: pipeline, actual: pipeline, responseType: v1.GetForwardingPipelineConfigRequest_ALL},
+			want: true,
+		},
+		{
+			name: "All Different Device Config",
+			args: args{expected: pipeline, actual: otherDeviceConfig, responseType: v1.GetForwardingPipelineConfigRequest_ALL},
+			want: false,
+		},
+		{
+			name: "Cookie Only",
+			args: args{expected: pipeline, actual: cookieOnly, responseType: v1.GetForwardingPipelineConfigRequest_COOKIE_ONLY},
+			want: true,
+		},
+		{
+			name: "Cookie Only Different Cookie",
+			args: args{expected: pipeline, actual: otherCookie, responseType: v1.GetForwardingPipelineConfigRequest_COOKIE_ONLY},
+			want: false,
+		},
+		{
+			name: "P4Info And Cookie Ignores Device Config",
+			args: args{expected: pipeline, actual: otherDeviceConfig, responseType: v1.GetForwardingPipelineConfigRequest_P4INFO_AND_COOKIE},
+			want: true,
+		},
+		{
+			name: "P4Info And Cookie Different P4Info",
+			args: args{expected: pipeline, actual: otherInfo, responseType: v1.GetForwardingPipelineConfigRequest_P4INFO_AND_COOKIE},
+			want: false,
+		},
+		{
+			name: "Device Config And Cookie Ignores P4Info",
+			args: args{expected: pipeline, actual: otherInfo, responseType: v1.GetForwardingPipelineConfigRequest_DEVICE_CONFIG_AND_COOKIE},
+			want: true,
+		},
+		{
+			name: "Device Config And Cookie Different Device Config",
+			args: args{expected: pipeline, actual: otherDeviceConfig, responseType: v1.GetForwardingPipelineConfigRequest_DEVICE_CONFIG_AND_COOKIE},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyGetForwardingPipelineConfigResp(tt.args.expected, tt.args.actual, tt.args.responseType); got.Passed() != tt.want {
+				t.Errorf("verifyGetForwardingPipelineConfigResp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyMetadata(t *testing.T) {
+	md := func(id uint32, value string) *v1.PacketMetadata {
+		return &v1.PacketMetadata{MetadataId: id, Value: []byte(value)}
+	}
+	type args struct {
+		expected []*v1.PacketMetadata
+		actual   []*v1.PacketMetadata
+		ignored  []uint32
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     bool
+		wantDiff string
+	}{
+		{name: "Both Empty", args: args{}, want: true},
+		{
+			name: "Equal",
+			args: args{expected: []*v1.PacketMetadata{md(1, "\x01"), md(2, "\x02")}, actual: []*v1.PacketMetadata{md(1, "\x01"), md(2, "\x02")}},
+			want: true,
+		},
+		{
+			name: "Different Order",
+			args: args{expected: []*v1.PacketMetadata{md(1, "\x01"), md(2, "\x02")}, actual: []*v1.PacketMetadata{md(2, "\x02"), md(1, "\x01")}},
+			want: true,
+		},
+		{
+			name: "Leading Zeros",
+			args: args{expected: []*v1.PacketMetadata{md(1, "\x00\x01"), md(2, "")}, actual: []*v1.PacketMetadata{md(1, "\x01"), md(2, "\x00\x00")}},
+			want: true,
+		},
+		{
+			name:     "Different Value",
+			args:     args{expected: []*v1.PacketMetadata{md(1, "\x01"), md(2, "\x02")}, actual: []*v1.PacketMetadata{md(1, "\x01"), md(2, "\x03")}},
+			want:     false,
+			wantDiff: "metadata 2: expected 02, actual 03",
+		},
+		{
+			name:     "Same Value Different ID",
+			args:     args{expected: []*v1.PacketMetadata{md(1, "\x01")}, actual: []*v1.PacketMetadata{md(2, "\x01")}},
+			want:     false,
+			wantDiff: "metadata 1: expected 01, actual missing\nmetadata 2: expected missing, actual 01",
+		},
+		{
+			name:     "Missing Metadata",
+			args:     args{expected: []*v1.PacketMetadata{md(1, "\x01"), md(2, "\x02")}, actual: []*v1.PacketMetadata{md(1, "\x01")}},
+			want:     false,
+			wantDiff: "metadata 2: expected 02, actual missing",
+		},
+		{
+			name: "Ignored Metadata",
+			args: args{
+				expected: []*v1.PacketMetadata{md(1, "\x01"), md(3, "\x00")},
+				actual:   []*v1.PacketMetadata{md(1, "\x01"), md(3, "\x5e\x10\xa2\x01")},
+				ignored:  []uint32{3},
+			},
+			want: true,
+		},
+		{
+			name: "Ignored Unexpected Metadata",
+			args: args{
+				expected: []*v1.PacketMetadata{md(1, "\x01")},
+				actual:   []*v1.PacketMetadata{md(1, "\x01"), md(3, "\x5e\x10\xa2\x01")},
+				ignored:  []uint32{3},
+			},
+			want: true,
+		},
+	}
+	defer SetIgnoredMetadataIDs(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetIgnoredMetadataIDs(tt.args.ignored)
+			got := verifyMetadata(tt.args.expected, tt.args.actual)
+			if got.Passed() != tt.want {
+				t.Errorf("verifyMetadata() = %v, want %v", got, tt.want)
+			}
+			if got.Diff != tt.wantDiff {
+				t.Errorf("verifyMetadata() diff = %q, want %q", got.Diff, tt.wantDiff)
+			}
+		})
+	}
+}
+
+func TestVerifyWriteError(t *testing.T) {
+	p4Err := func(code codes.Code, space string, errCode int32, message string) *v1.Error {
+		return &v1.Error{CanonicalCode: int32(code), Space: space, Code: errCode, Message: message}
+	}
+	writeErr := func(code codes.Code, message string, details ...*v1.Error) error {
+		st := status.New(code, message)
+		for _, detail := range details {
+			var err error
+			if st, err = st.WithDetails(detail); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return st.Err()
+	}
+	expStatus := func(code codes.Code, message string, details ...*v1.Error) *spb.Status {
+		st := &spb.Status{Code: int32(code), Message: message}
+		for _, detail := range details {
+			a, err := ptypes.MarshalAny(detail)
+			if err != nil {
+				t.Fatal(err)
+			}
+			st.Details = append(st.Details, a)
+		}
+		return st
+	}
+	tests := []struct {
+		name     string
+		expected *spb.Status
+		err      error
+		want     bool
+	}{
+		{name: "Success Expected", expected: nil, err: nil, want: true},
+		{name: "Unexpected Failure", expected: nil, err: writeErr(codes.InvalidArgument, "bad"), want: false},
+		{name: "Unexpected Success", expected: expStatus(codes.InvalidArgument, ""), err: nil, want: false},
+		{name: "Same Code", expected: expStatus(codes.InvalidArgument, ""), err: writeErr(codes.InvalidArgument, "bad match"), want: true},
+		{name: "Other Code", expected: expStatus(codes.InvalidArgument, ""), err: writeErr(codes.NotFound, "bad match"), want: false},
+		{name: "Message Pattern", expected: expStatus(codes.InvalidArgument, "^bad .*"), err: writeErr(codes.InvalidArgument, "bad match"), want: true},
+		{name: "Message Mismatch", expected: expStatus(codes.InvalidArgument, "^good"), err: writeErr(codes.InvalidArgument, "bad match"), want: false},
+		{name: "Non-gRPC Error", expected: expStatus(codes.Unknown, ""), err: errors.New("connection reset"), want: true},
+		{
+			name:     "Details",
+			expected: expStatus(codes.Unknown, "", p4Err(codes.OK, "", 0, ""), p4Err(codes.AlreadyExists, "", 0, "exists")),
+			err:      writeErr(codes.Unknown, "write failed", p4Err(codes.OK, "", 0, ""), p4Err(codes.AlreadyExists, "stratum", 6, "entry already exists")),
+			want:     true,
+		},
+		{
+			name:     "Details Space And Code",
+			expected: expStatus(codes.Unknown, "", p4Err(codes.AlreadyExists, "stratum", 6, "")),
+			err:      writeErr(codes.Unknown, "write failed", p4Err(codes.AlreadyExists, "stratum", 7, "")),
+			want:     false,
+		},
+		{
+			name:     "Details Canonical Code",
+			expected: expStatus(codes.Unknown, "", p4Err(codes.OK, "", 0, ""), p4Err(codes.NotFound, "", 0, "")),
+			err:      writeErr(codes.Unknown, "write failed", p4Err(codes.OK, "", 0, ""), p4Err(codes.AlreadyExists, "", 0, "")),
+			want:     false,
+		},
+		{
+			name:     "Details Count",
+			expected: expStatus(codes.Unknown, "", p4Err(codes.AlreadyExists, "", 0, "")),
+			err:      writeErr(codes.Unknown, "write failed", p4Err(codes.OK, "", 0, ""), p4Err(codes.AlreadyExists, "", 0, "")),
+			want:     false,
+		},
+		{
+			name:     "Invalid Details",
+			expected: &spb.Status{Code: int32(codes.Unknown), Details: []*any.Any{{TypeUrl: "type.googleapis.com/p4.v1.Error", Value: []byte("\xff")}}},
+			err:      writeErr(codes.Unknown, "write failed", p4Err(codes.OK, "", 0, "")),
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyWriteError(tt.expected, tt.err); got.Passed() != tt.want {
+				t.Errorf("verifyWriteError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package port
+
+import (
+	"github.com/golang/protobuf/proto"
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/framework/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/result"
+)
+
+type gnmiPortStimulus struct{}
+
+// createGnmiPortStimulus creates a port stimulus instance which utilizes gNMI set requests
+// on /interfaces/interface/config/enabled to bring switch ports up or down.
+func createGnmiPortStimulus() *gnmiPortStimulus {
+	return &gnmiPortStimulus{}
+}
+
+//getEnabledPath returns a copy of the interface path extended with config/enabled
+func getEnabledPath(intf *gpb.Path) *gpb.Path {
+	path := proto.Clone(intf).(*gpb.Path)
+	path.Elem = append(path.Elem, &gpb.PathElem{Name: "config"}, &gpb.PathElem{Name: "enabled"})
+	return path
+}
+
+//setEnabled sends a gNMI set request with the enabled leaf of the interface
+func (g *gnmiPortStimulus) setEnabled(intf *gpb.Path, enabled bool) *result.Result {
+	path := getEnabledPath(intf)
+	log.Infof("Setting %s to %t", path, enabled)
+	return gnmi.ProcessUpdate(path, &gpb.TypedValue{Value: &gpb.TypedValue_BoolVal{BoolVal: enabled}})
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package port
+
+import (
+	"os/exec"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/result"
+	"github.com/stratum/testvectors-runner/pkg/utils/common"
+	pm "github.com/stratum/testvectors/proto/portmap"
+)
+
+type linkPortStimulus struct {
+	portmap *pm.PortMap
+}
+
+// createLinkPortStimulus creates a port stimulus instance which brings links on the host up or down.
+// It is used with direct data plane mode where switch ports are connected to host interfaces, e.g. veth pairs.
+func createLinkPortStimulus(portmap *pm.PortMap) *linkPortStimulus {
+	return &linkPortStimulus{portmap: portmap}
+}
+
+//getHostInterface looks up the portmap entry matching the interface name or port number and returns its host interface name.
+//It returns empty string if none of the entries match.
+func (l *linkPortStimulus) getHostInterface(name string) string {
+	for _, entry := range l.portmap.GetEntries() {
+		if entry.GetInterfaceName() == name || common.GetStr(entry.GetPortNumber()) == name {
+			return entry.GetInterfaceName()
+		}
+	}
+	return ""
+}
+
+//setEnabled brings the host side link of the interface up or down
+func (l *linkPortStimulus) setEnabled(intf *gpb.Path, enabled bool) *result.Result {
+	name := getInterfaceName(intf)
+	iface := l.getHostInterface(name)
+	if iface == "" {
+		log.Errorf("Failed to find portmap entry for interface %s", name)
+		return result.Failf("no portmap entry for interface %s", name)
+	}
+	state := "down"
+	if enabled {
+		state = "up"
+	}
+	log.Infof("Setting link %s %s", iface, state)
+	out, err := exec.Command("ip", "link", "set", "dev", iface, state).CombinedOutput()
+	if err != nil {
+		log.Errorf("Error setting link %s %s: %v %s", iface, state, err, out)
+		return result.Failf("failed to set link %s %s: %v %s", iface, state, err, out)
+	}
+	return result.Pass()
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+/*
+Package port implements functions to bring switch ports up or down
+*/
+package port
+
+import (
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/logger"
+	"github.com/stratum/testvectors-runner/pkg/result"
+	pm "github.com/stratum/testvectors/proto/portmap"
+)
+
+var log = logger.NewLogger()
+
+// portStimulus interface implements functions to change the administrative state of ports
+type portStimulus interface {
+	// set the port identified by the gNMI interface path up or down and return the result
+	setEnabled(intf *gpb.Path, enabled bool) *result.Result
+}
+
+var ps portStimulus
+
+// CreatePortStimulus takes the port stimulus mode and portmap as arguments and creates one
+// port stimulus instance for bringing ports up or down.
+// In "gnmi" mode ports are changed through gNMI on the switch, in "link" mode the host side
+// links found in the portmap are changed.
+func CreatePortStimulus(mode string, portmap *pm.PortMap) {
+	switch mode {
+	case "gnmi":
+		log.Infof("Creating gNMI port stimulus")
+		ps = createGnmiPortStimulus()
+	case "link":
+		log.Infof("Creating link port stimulus with port map: %s\n", portmap)
+		ps = createLinkPortStimulus(portmap)
+	default:
+		log.Fatalf("Unknown port stimulus mode: %s", mode)
+	}
+}
+
+//getInterfaceName returns the value of the "name" key of the last element in the interface path.
+//It returns empty string if the path has no such key.
+func getInterfaceName(intf *gpb.Path) string {
+	elems := intf.GetElem()
+	if len(elems) == 0 {
+		return ""
+	}
+	return elems[len(elems)-1].GetKey()["name"]
+}
+
+//ProcessPortStimulus brings the port identified by the gNMI interface path up or down
+func ProcessPortStimulus(intf *gpb.Path, enabled bool) *result.Result {
+	log.Debug("In ProcessPortStimulus")
+	if ps == nil {
+		log.Error("port stimulus does not exist")
+		return result.Failf("port stimulus does not exist")
+	}
+	if getInterfaceName(intf) == "" {
+		log.Errorf("No interface name found in path %s", intf)
+		return result.Failf("no interface name found in path %s", intf)
+	}
+	return ps.setEnabled(intf, enabled)
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package port
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	pm "github.com/stratum/testvectors/proto/portmap"
+)
+
+func interfacePath(name string) *gpb.Path {
+	return &gpb.Path{
+		Elem: []*gpb.PathElem{
+			{Name: "interfaces"},
+			{Name: "interface", Key: map[string]string{"name": name}},
+		},
+	}
+}
+
+func TestGetEnabledPath(t *testing.T) {
+	intf := interfacePath("veth1")
+	want := &gpb.Path{
+		Elem: []*gpb.PathElem{
+			{Name: "interfaces"},
+			{Name: "interface", Key: map[string]string{"name": "veth1"}},
+			{Name: "config"},
+			{Name: "enabled"},
+		},
+	}
+	if got := getEnabledPath(intf); !proto.Equal(got, want) {
+		t.Errorf("getEnabledPath() = %s, want %s", got, want)
+	}
+	if len(intf.GetElem()) != 2 {
+		t.Errorf("getEnabledPath() modified the interface path: %s", intf)
+	}
+}
+
+func TestGetHostInterface(t *testing.T) {
+	portmap := &pm.PortMap{Entries: []*pm.Entry{{PortNumber: 1, InterfaceName: "veth0", PortType: pm.Entry_IN_OUT}, {PortNumber: 2, InterfaceName: "veth2", PortType: pm.Entry_IN_OUT}}}
+	l := createLinkPortStimulus(portmap)
+	tests := []struct {
+		name string
+		intf *gpb.Path
+		want string
+	}{
+		{
+			name: "Interface Name",
+			intf: interfacePath("veth2"),
+			want: "veth2",
+		},
+		{
+			name: "Port Number",
+			intf: interfacePath("1"),
+			want: "veth0",
+		},
+		{
+			name: "Unknown Interface",
+			intf: interfacePath("veth5"),
+			want: "",
+		},
+		{
+			name: "Empty Path",
+			intf: &gpb.Path{},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.getHostInterface(getInterfaceName(tt.intf)); got != tt.want {
+				t.Errorf("getHostInterface() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
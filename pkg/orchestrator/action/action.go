@@ -10,89 +10,248 @@ Package action implements functions to extract and run various actions supported
 package action
 
 import (
+	"fmt"
+	"math/rand"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/openconfig/gnoi/system"
+	"github.com/stratum/testvectors-runner/pkg/framework/alarm"
 	"github.com/stratum/testvectors-runner/pkg/framework/dataplane"
 	"github.com/stratum/testvectors-runner/pkg/framework/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/framework/gnoi"
 	"github.com/stratum/testvectors-runner/pkg/framework/p4rt"
+	"github.com/stratum/testvectors-runner/pkg/framework/port"
 	"github.com/stratum/testvectors-runner/pkg/logger"
+	"github.com/stratum/testvectors-runner/pkg/result"
 	tv "github.com/stratum/testvectors/proto/testvector"
 )
 
-var log = logger.NewLogger()
+var (
+	log = logger.NewLogger()
+	//randomSeed and rng are used to shuffle actions in randomized action groups
+	randomSeed int64
+	rng        = rand.New(rand.NewSource(randomSeed))
+	rngMu      sync.Mutex
+	//parallelOptions are applied to every parallel action group
+	parallelOptions ParallelOptions
+)
+
+//ParallelOptions controls how actions in a parallel action group are started.
+//ParallelActionGroup.Options carries no fields yet, so the options are set once for the whole run.
+type ParallelOptions struct {
+	//MaxConcurrency limits the number of actions running at the same time, 0 means no limit
+	MaxConcurrency int
+	//Barrier holds all actions until every goroutine is ready and then releases them at the same instant.
+	//When MaxConcurrency is also set, actions are released in batches of MaxConcurrency.
+	Barrier bool
+	//StartSkew spreads the starts of the actions uniformly: the i-th action of the group starts i*StartSkew after the start
+	//of the group, or later if it has to wait for a free slot or for the release of its batch
+	StartSkew time.Duration
+}
+
+//SetParallelOptions sets the options used by all parallel action groups
+func SetParallelOptions(opts ParallelOptions) {
+	log.Debugf("Parallel action group options: %+v", opts)
+	parallelOptions = opts
+}
+
+//SetRandomSeed seeds the random source used by randomized action groups.
+//If seed is 0 a new seed is generated from current time. The seed in use is logged and recorded in the results of
+//randomized action groups so that a failing order can be replayed.
+func SetRandomSeed(seed int64) {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rngMu.Lock()
+	defer rngMu.Unlock()
+	randomSeed = seed
+	rng = rand.New(rand.NewSource(seed))
+	log.Infof("Using random seed %d for randomized action groups", seed)
+}
 
 //ProcessActionGroup decodes the action group and executes actions sequentially, in parallel or randomly based on the type of underlying action group.
-func ProcessActionGroup(ag *tv.ActionGroup) bool {
+//It returns the result of the action group with one child result per action. All the actions are executed even if some of them fail.
+func ProcessActionGroup(ag *tv.ActionGroup) *result.Result {
+	return ProcessActionGroupWithPolicy(ag, false)
+}
+
+//ProcessActionGroupWithPolicy executes the action group like ProcessActionGroup.
+//If stopOnFailure is true the actions of a sequential or randomized action group after the first failed action are skipped
+//and reported as skipped. The actions of a parallel action group which haven't started when an action fails are skipped.
+func ProcessActionGroupWithPolicy(ag *tv.ActionGroup, stopOnFailure bool) *result.Result {
 	log.Debug("In ProcessActionGroup")
+	start := time.Now()
+	var res *result.Result
 	switch {
 	case ag.GetSequentialActionGroup() != nil:
 		sag := ag.GetSequentialActionGroup()
-		return processSequentialActionGroup(sag)
+		res = processSequentialActionGroup(sag, stopOnFailure)
 	case ag.GetParallelActionGroup() != nil:
 		pag := ag.GetParallelActionGroup()
-		return processParallelActionGroup(pag)
+		res = processParallelActionGroup(pag, stopOnFailure)
 	case ag.GetRandomizedActionGroup() != nil:
 		rag := ag.GetRandomizedActionGroup()
-		return processRandomizedActionGroup(rag)
+		res = processRandomizedActionGroup(rag, stopOnFailure)
 	default:
 		log.Info("Empty Action Group")
-		return false
+		res = result.Failf("empty action group")
 	}
+	return res.Since(ag.GetActionGroupId(), start)
 }
 
-//processSequentialActionGroup executes actions sequentially, combines all the results and returns a boolean value.
-func processSequentialActionGroup(sag *tv.SequentialActionGroup) bool {
-	result := true
+//processSequentialActionGroup executes actions sequentially and combines all the results.
+//With stopOnFailure the actions after the first failed action are skipped.
+func processSequentialActionGroup(sag *tv.SequentialActionGroup, stopOnFailure bool) *result.Result {
+	res := result.Pass()
 	log.Debug("In ProcessSequentialActionGroup")
-	for _, action := range sag.Actions {
-		result = processAction(action) && result
+	for i, action := range sag.Actions {
+		if stopOnFailure && res.Failed() {
+			res.Add(result.Skip(actionID(i), "previous action failed"))
+			continue
+		}
+		res.Add(processActionWithID(i, action))
+	}
+	return res
+}
+
+//parallelRun tracks the failures of the actions of a parallel action group
+type parallelRun struct {
+	stopOnFailure bool
+	failed        int32
+}
+
+//process executes the i-th action of the group. With stopOnFailure it is skipped if another action already failed.
+func (r *parallelRun) process(i int, action *tv.Action) *result.Result {
+	if r.stopOnFailure && atomic.LoadInt32(&r.failed) != 0 {
+		return result.Skip(actionID(i), "another parallel action failed")
+	}
+	res := processActionWithID(i, action)
+	if res.Failed() {
+		atomic.StoreInt32(&r.failed, 1)
 	}
-	return result
+	return res
 }
 
-//processParallelActionGroup executes actions parallelly, combines all the results and returns a boolean value.
-func processParallelActionGroup(pag *tv.ParallelActionGroup) bool {
-	result := true
+//processParallelActionGroup executes actions parallelly and combines all the results in the order of the actions.
+//Concurrency, start barrier and start skew are taken from parallelOptions. Slots and batches are handed out in the order of the actions,
+//and in both modes the start skew is waited for once an action holds its slot or its batch is released.
+//With stopOnFailure the actions which haven't started when an action fails are skipped.
+func processParallelActionGroup(pag *tv.ParallelActionGroup, stopOnFailure bool) *result.Result {
 	log.Debug("In ProcessParallelActionGroup")
-	//TODO - options
-	//pag.Options
-	var wg sync.WaitGroup
-	wg.Add(len(pag.Actions))
-	resultChan := make(chan bool, len(pag.Actions))
-	for _, action := range pag.Actions {
-		go func(action *tv.Action) {
-			defer wg.Done()
-			res := processAction(action)
-			resultChan <- res
-		}(action)
+	opts := parallelOptions
+	run := &parallelRun{stopOnFailure: stopOnFailure}
+	limit := opts.MaxConcurrency
+	if limit <= 0 || limit > len(pag.Actions) {
+		limit = len(pag.Actions)
+	}
+	results := make([]*result.Result, len(pag.Actions))
+	start := time.Now()
+	if opts.Barrier {
+		for first := 0; first < len(pag.Actions); first += limit {
+			last := first + limit
+			if last > len(pag.Actions) {
+				last = len(pag.Actions)
+			}
+			processActionsWithBarrier(run, pag.Actions[first:last], first, start, opts.StartSkew, results)
+		}
+	} else {
+		var wg sync.WaitGroup
+		wg.Add(len(pag.Actions))
+		sem := make(chan struct{}, limit)
+		for i, action := range pag.Actions {
+			sem <- struct{}{}
+			go func(i int, action *tv.Action) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				waitForStart(start, i, opts.StartSkew)
+				results[i] = run.process(i, action)
+			}(i, action)
+		}
+		wg.Wait()
 	}
-	wg.Wait()
-	close(resultChan)
-	for r := range resultChan {
-		result = result && r
+	return result.Combine(results...)
+}
+
+//waitForStart sleeps until the start of the i-th action of a parallel action group, i*skew after the start of the group
+func waitForStart(start time.Time, i int, skew time.Duration) {
+	time.Sleep(time.Until(start.Add(time.Duration(i) * skew)))
+}
+
+//processActionsWithBarrier starts one goroutine per action, waits until all of them are ready and then releases them together.
+//After the release the i-th action waits for its start skew from the start of the group. The result of the i-th action is stored in results[offset+i].
+func processActionsWithBarrier(run *parallelRun, actions []*tv.Action, offset int, start time.Time, skew time.Duration, results []*result.Result) {
+	var ready, done sync.WaitGroup
+	ready.Add(len(actions))
+	done.Add(len(actions))
+	release := make(chan struct{})
+	for i, action := range actions {
+		go func(i int, action *tv.Action) {
+			defer done.Done()
+			ready.Done()
+			<-release
+			waitForStart(start, offset+i, skew)
+			results[offset+i] = run.process(offset+i, action)
+		}(i, action)
 	}
-	return result
+	ready.Wait()
+	log.Debugf("Releasing %d parallel actions", len(actions))
+	close(release)
+	done.Wait()
 }
 
-//processRandomizedActionGroup executes actions in random order, combines all the results and returns a boolean value.
-//TODO
-func processRandomizedActionGroup(rag *tv.RandomizedActionGroup) bool {
+//processRandomizedActionGroup executes actions in random order and combines all the results in the order of execution.
+//The order is drawn from the seeded random source. The seed and the order are logged and recorded in the note of the result,
+//so the order can be replayed by running again with the same seed. With stopOnFailure the actions after the first failed action are skipped.
+func processRandomizedActionGroup(rag *tv.RandomizedActionGroup, stopOnFailure bool) *result.Result {
+	res := result.Pass()
 	log.Debug("In ProcessRandomizedActionGroup")
-	//TODO
-	return false
+	order, seed := shuffle(len(rag.Actions))
+	log.Infof("Randomized action order: %v (seed %d)", order, seed)
+	for _, i := range order {
+		if stopOnFailure && res.Failed() {
+			res.Add(result.Skip(actionID(i), "previous action failed"))
+			continue
+		}
+		res.Add(processActionWithID(i, rag.Actions[i]))
+	}
+	res.Note = fmt.Sprintf("seed %d, action order %v", seed, order)
+	if !res.Passed() {
+		log.Errorf("Randomized action group failed with action order %v (seed %d)", order, seed)
+		res.Err = fmt.Errorf("failed with action order %v (seed %d)", order, seed)
+	}
+	return res
+}
+
+//shuffle returns a random permutation of action indices [0, n) and the seed of the random source it was drawn from.
+func shuffle(n int) ([]int, int64) {
+	rngMu.Lock()
+	defer rngMu.Unlock()
+	return rng.Perm(n), randomSeed
+}
+
+//processActionWithID executes the action and identifies its result by the index of the action in its group
+func processActionWithID(i int, action *tv.Action) *result.Result {
+	start := time.Now()
+	return processAction(action).Since(actionID(i), start)
+}
+
+//actionID returns the ID of the i-th action in its group
+func actionID(i int) string {
+	return fmt.Sprintf("action %d", i)
 }
 
 //ProcessAction decodes and executes actions
-func processAction(action *tv.Action) bool {
+func processAction(action *tv.Action) *result.Result {
 	log.Debug("In processAction")
 	switch {
 	case action.GetConfigOperation() != nil:
 		co := action.GetConfigOperation()
 		return processConfigOperation(co)
 	case action.GetAlarmStimulus() != nil:
-		//TODO
 		as := action.GetAlarmStimulus()
-		_ = as
+		return processAlarmStimulus(as)
 	case action.GetControlPlaneOperation() != nil:
 		//TODO
 		cpo := action.GetControlPlaneOperation()
@@ -102,27 +261,25 @@ func processAction(action *tv.Action) bool {
 		dps := action.GetDataPlaneStimulus()
 		return processDataPlaneStimulus(dps)
 	case action.GetManagementOperation() != nil:
-		//TODO
 		mo := action.GetManagementOperation()
-		_ = mo
+		return processManagementOperation(mo)
 	case action.GetPortStimulus() != nil:
-		//TODO
 		ps := action.GetPortStimulus()
-		_ = ps
+		return processPortStimulus(ps)
 	default:
 		log.Info("Empty Action")
 	}
-	return false
+	return result.Failf("empty action")
 }
 
 //processConfigOperation extracts gnmi set and forwards to framework.
-func processConfigOperation(co *tv.ConfigOperation) bool {
+func processConfigOperation(co *tv.ConfigOperation) *result.Result {
 	log.Debug("In processConfigOperation")
 	return gnmi.ProcessSetRequest(co.GnmiSetRequest, co.GnmiSetResponse)
 }
 
 //processControlPlaneOperation extracts pipeline config, write or packet out operations and forwards to framework.
-func processControlPlaneOperation(cpo *tv.ControlPlaneOperation) bool {
+func processControlPlaneOperation(cpo *tv.ControlPlaneOperation) *result.Result {
 	log.Debug("In processControlPlaneOperation")
 	switch {
 	case cpo.GetPipelineConfigOperation() != nil:
@@ -135,11 +292,11 @@ func processControlPlaneOperation(cpo *tv.ControlPlaneOperation) bool {
 		log.Debug("In PacketOut Oper")
 		return p4rt.ProcessPacketOutOperation(cpo.GetPacketOutOperation().GetP4PacketOut())
 	}
-	return false
+	return result.Failf("empty control plane operation")
 }
 
 //ProcessDataPlaneStimulus extracts traffic stimulus and forwards to framework.
-func processDataPlaneStimulus(dps *tv.DataPlaneStimulus) bool {
+func processDataPlaneStimulus(dps *tv.DataPlaneStimulus) *result.Result {
 	log.Debug("in processDataPlaneStimulus")
 	switch {
 	case dps.GetTrafficStimulus() != nil:
@@ -153,5 +310,43 @@ func processDataPlaneStimulus(dps *tv.DataPlaneStimulus) bool {
 		}
 		return dataplane.ProcessTrafficStimulus(payloads, dps.GetTrafficStimulus().GetPort())
 	}
-	return false
+	return result.Failf("empty data plane stimulus")
+}
+
+//processAlarmStimulus extracts the alarm path and forwards it to framework.
+func processAlarmStimulus(as *tv.AlarmStimulus) *result.Result {
+	log.Debug("In processAlarmStimulus")
+	return alarm.ProcessAlarmStimulus(as.GetAlarm())
+}
+
+//processPortStimulus extracts the interface and port state and forwards them to framework.
+func processPortStimulus(ps *tv.PortStimulus) *result.Result {
+	log.Debug("In processPortStimulus")
+	switch ps.GetState() {
+	case tv.PortStimulus_STATE_UP:
+		return port.ProcessPortStimulus(ps.GetInterface(), true)
+	case tv.PortStimulus_STATE_DOWN:
+		return port.ProcessPortStimulus(ps.GetInterface(), false)
+	default:
+		log.Errorf("Unsupported port state: %s", ps.GetState())
+		return result.Failf("unsupported port state %s", ps.GetState())
+	}
+}
+
+//processManagementOperation extracts management operations and forwards to framework.
+//The management operation messages in Test Vectors don't carry any parameters yet, so a system operation is run
+//as a cold reboot of the switch, waiting until it is back. Other operations can't be built into a gNOI request.
+func processManagementOperation(mo *tv.ManagementOperation) *result.Result {
+	log.Debug("In processManagementOperation")
+	switch {
+	case mo.GetFlows() == nil:
+		log.Info("Empty Management Operation")
+		return result.Failf("empty management operation")
+	case mo.GetSystemOperation() != nil:
+		req := &system.RebootRequest{Method: system.RebootMethod_COLD, Message: "testvectors-runner system operation"}
+		return gnoi.ProcessRebootRequest(req, gnoi.RebootTimeout)
+	default:
+		log.Errorf("Management operation %T is not supported", mo.GetFlows())
+		return result.Failf("management operation %T is not supported", mo.GetFlows())
+	}
 }
@@ -7,7 +7,10 @@
 package action
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	v1 "github.com/p4lang/p4runtime/go/p4/v1"
 	pm "github.com/stratum/testvectors/proto/portmap"
@@ -112,7 +115,7 @@ func TestProcessSequentialActionGroup(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := processSequentialActionGroup(tt.args.sag); got != tt.want {
+			if got := processSequentialActionGroup(tt.args.sag, false); got.Passed() != tt.want {
 				t.Errorf("ProcessSequentialActionGroup() = %v, want %v", got, tt.want)
 			}
 		})
@@ -130,7 +133,8 @@ func TestProcessParallelActionGroup(t *testing.T) {
 		}
 	)
 	type args struct {
-		pag *tv.ParallelActionGroup
+		pag  *tv.ParallelActionGroup
+		opts ParallelOptions
 	}
 	tests := []struct {
 		name string
@@ -147,20 +151,104 @@ func TestProcessParallelActionGroup(t *testing.T) {
 			args: args{pag: validPag},
 			want: false,
 		},
+		{
+			name: "Empty Parallel Action Group With Barrier Test",
+			args: args{pag: emptyPag, opts: ParallelOptions{MaxConcurrency: 1, Barrier: true}},
+			want: true,
+		},
+		{
+			name: "Valid Parallel Action Group With Concurrency Limit Test",
+			args: args{pag: validPag, opts: ParallelOptions{MaxConcurrency: 1, StartSkew: time.Millisecond}},
+			want: false,
+		},
+		{
+			name: "Valid Parallel Action Group With Barrier Test",
+			args: args{pag: validPag, opts: ParallelOptions{Barrier: true, StartSkew: time.Millisecond}},
+			want: false,
+		},
 	}
+	defer SetParallelOptions(ParallelOptions{})
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := processParallelActionGroup(tt.args.pag); got != tt.want {
+			SetParallelOptions(tt.args.opts)
+			if got := processParallelActionGroup(tt.args.pag, false); got.Passed() != tt.want {
 				t.Errorf("ProcessParallelActionGroup() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestProcessParallelActionGroupStartSkew(t *testing.T) {
+	pag := &tv.ParallelActionGroup{Actions: []*tv.Action{emptyAction, emptyAction, emptyAction}}
+	skew := 50 * time.Millisecond
+	tests := []struct {
+		name string
+		opts ParallelOptions
+	}{
+		{name: "No Limit", opts: ParallelOptions{StartSkew: skew}},
+		{name: "Concurrency Limit", opts: ParallelOptions{MaxConcurrency: 1, StartSkew: skew}},
+		{name: "Barrier", opts: ParallelOptions{Barrier: true, StartSkew: skew}},
+		{name: "Barrier Batches", opts: ParallelOptions{MaxConcurrency: 2, Barrier: true, StartSkew: skew}},
+	}
+	defer SetParallelOptions(ParallelOptions{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetParallelOptions(tt.opts)
+			start := time.Now()
+			processParallelActionGroup(pag, false)
+			//the last action starts 2*skew after the start of the group in every mode, not 2*skew after its slot or batch
+			if elapsed := time.Since(start); elapsed < 2*skew || elapsed >= 3*skew {
+				t.Errorf("processParallelActionGroup() took %v, want between %v and %v", elapsed, 2*skew, 3*skew)
+			}
+		})
+	}
+}
+
+func TestProcessParallelActionGroupStopOnFailure(t *testing.T) {
+	pag := &tv.ParallelActionGroup{Actions: []*tv.Action{emptyAction, emptyAction, emptyAction}}
+	tests := []struct {
+		name          string
+		opts          ParallelOptions
+		stopOnFailure bool
+		wantFailed    int
+		wantSkipped   int
+	}{
+		{name: "Continue", opts: ParallelOptions{MaxConcurrency: 1}, wantFailed: 3},
+		{name: "Stop With Concurrency Limit", opts: ParallelOptions{MaxConcurrency: 1}, stopOnFailure: true, wantFailed: 1, wantSkipped: 2},
+		{name: "Stop With Barrier Batches", opts: ParallelOptions{MaxConcurrency: 1, Barrier: true}, stopOnFailure: true, wantFailed: 1, wantSkipped: 2},
+		{name: "Stop With Start Skew", opts: ParallelOptions{StartSkew: 50 * time.Millisecond}, stopOnFailure: true, wantFailed: 1, wantSkipped: 2},
+	}
+	defer SetParallelOptions(ParallelOptions{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetParallelOptions(tt.opts)
+			got := processParallelActionGroup(pag, tt.stopOnFailure)
+			var failed, skipped int
+			for _, res := range got.Children {
+				switch {
+				case res.Failed():
+					failed++
+				case res.Skipped():
+					skipped++
+				}
+			}
+			if failed != tt.wantFailed || skipped != tt.wantSkipped {
+				t.Errorf("processParallelActionGroup() failed %d and skipped %d actions, want %d and %d\n%v", failed, skipped, tt.wantFailed, tt.wantSkipped, got)
+			}
+		})
+	}
+}
+
 func TestProcessRandomizedActionGroup(t *testing.T) {
 
 	var (
 		emptyRag = &tv.RandomizedActionGroup{}
+		validRag = &tv.RandomizedActionGroup{
+			Actions: []*tv.Action{
+				emptyAction,
+				emptyAction,
+			},
+		}
 	)
 	type args struct {
 		rag *tv.RandomizedActionGroup
@@ -173,18 +261,48 @@ func TestProcessRandomizedActionGroup(t *testing.T) {
 		{
 			name: "Empty Random Action Group Test",
 			args: args{rag: emptyRag},
+			want: true,
+		},
+		{
+			name: "Valid Random Action Group Test",
+			args: args{rag: validRag},
 			want: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := processRandomizedActionGroup(tt.args.rag); got != tt.want {
+			got := processRandomizedActionGroup(tt.args.rag, false)
+			if got.Passed() != tt.want {
 				t.Errorf("ProcessRandomizedActionGroup() = %v, want %v", got, tt.want)
 			}
+			if !strings.Contains(got.Note, "seed") || !strings.Contains(got.Note, "action order") {
+				t.Errorf("ProcessRandomizedActionGroup() note = %q, want seed and action order", got.Note)
+			}
 		})
 	}
 }
 
+func TestShuffle(t *testing.T) {
+	var seed int64 = 42
+	SetRandomSeed(seed)
+	first, gotSeed := shuffle(10)
+	if gotSeed != seed {
+		t.Errorf("shuffle() seed = %v, want %v", gotSeed, seed)
+	}
+	SetRandomSeed(seed)
+	second, _ := shuffle(10)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("shuffle() with same seed = %v, want %v", second, first)
+	}
+	seen := make(map[int]bool)
+	for _, i := range first {
+		seen[i] = true
+	}
+	if len(seen) != 10 {
+		t.Errorf("shuffle() = %v, want a permutation of 10 indices", first)
+	}
+}
+
 func TestProcessAction(t *testing.T) {
 	gnmi.Init(TestTarget)
 	portmap := &pm.PortMap{Entries: []*pm.Entry{{PortNumber: 1, InterfaceName: "veth0", PortType: pm.Entry_IN_OUT}, {PortNumber: 2, InterfaceName: "veth2", PortType: pm.Entry_IN_OUT}}}
@@ -244,7 +362,7 @@ func TestProcessAction(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := processAction(tt.args.action); got != tt.want {
+			if got := processAction(tt.args.action); got.Passed() != tt.want {
 				t.Errorf("ProcessAction() = %v, want %v", got, tt.want)
 			}
 		})
@@ -10,6 +10,7 @@ Package expectation implements functions to extract and run various expectations
 package expectation
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/stratum/testvectors-runner/pkg/framework/dataplane"
@@ -17,58 +18,149 @@ import (
 	"github.com/stratum/testvectors-runner/pkg/framework/p4rt"
 	"github.com/stratum/testvectors-runner/pkg/logger"
 	"github.com/stratum/testvectors-runner/pkg/orchestrator/action"
+	"github.com/stratum/testvectors-runner/pkg/result"
+	"github.com/stratum/testvectors-runner/pkg/utils/predicate"
 	tv "github.com/stratum/testvectors/proto/testvector"
 )
 
-var log = logger.NewLogger()
+var (
+	log = logger.NewLogger()
+	//pollOptions are applied to gNMI Get, P4Runtime Read and pipeline config expectations
+	pollOptions PollOptions
+	//counterPredicates holds the counter predicates of the read expectations of the current Test Vector by expectation ID
+	counterPredicates map[string]CounterPredicates
+)
+
+//CounterPredicates are predicates on the packet and byte counts of the counter and direct counter entries of a read expectation,
+//e.g. "~delta == 3". The counts are then not compared with the expected entries. An empty predicate skips the count.
+type CounterPredicates struct {
+	Packets string
+	Bytes   string
+}
+
+//SetCounterPredicates sets the counter predicates of read expectations by expectation ID
+func SetCounterPredicates(predicates map[string]CounterPredicates) {
+	counterPredicates = predicates
+}
+
+//MinPollInterval is the shortest interval between two requests of a polled expectation
+const MinPollInterval = time.Millisecond
+
+//PollOptions controls polling of expectations on state which converges asynchronously.
+//The request of a polled expectation is re-issued every Interval until the response matches or Deadline expires.
+//Expectation.Options carries no fields yet, so the options are set once for the whole run.
+type PollOptions struct {
+	//Interval between two consecutive requests
+	Interval time.Duration
+	//Deadline for the response to match, 0 disables polling and sends the request once
+	Deadline time.Duration
+}
+
+//SetPollOptions sets the options used by all polled expectations, raising the interval to MinPollInterval if polling is enabled
+func SetPollOptions(opts PollOptions) {
+	if opts.Deadline > 0 && opts.Interval < MinPollInterval {
+		log.Warnf("Poll interval %s is too short, using %s", opts.Interval, MinPollInterval)
+		opts.Interval = MinPollInterval
+	}
+	log.Debugf("Expectation poll options: %+v", opts)
+	pollOptions = opts
+}
 
-//ProcessExpectation decodes and executes expectations
-func ProcessExpectation(exp *tv.Expectation) bool {
+//poll calls process until it returns a passed result or the deadline from pollOptions expires.
+//On timeout it returns the last failed result, which carries the diff of the last mismatching response.
+//Each attempt starts from the predicate samples taken before the first one, so that delta predicates apply to the change
+//since the previous expectation rather than since the previous attempt.
+func poll(process func() *result.Result) *result.Result {
+	opts := pollOptions
+	samples := predicate.Snapshot()
+	res := process()
+	if opts.Deadline <= 0 || res.Passed() {
+		return res
+	}
+	deadline := time.Now().Add(opts.Deadline)
+	attempts := 1
+	for time.Now().Add(opts.Interval).Before(deadline) {
+		time.Sleep(opts.Interval)
+		attempts++
+		predicate.Restore(samples)
+		res = process()
+		if res.Passed() {
+			log.Infof("Expectation met after %d attempts", attempts)
+			return res
+		}
+	}
+	log.Errorf("Expectation not met after %d attempts in %s", attempts, opts.Deadline)
+	if res != nil {
+		res.Err = fmt.Errorf("not met after %d attempts in %s, last mismatch: %v", attempts, opts.Deadline, res.Err)
+	}
+	return res
+}
+
+//ProcessExpectation decodes and executes expectations and returns the result identified by the expectation ID
+func ProcessExpectation(exp *tv.Expectation) *result.Result {
 	log.Debug("In ProcessExpectation")
+	start := time.Now()
+	var res *result.Result
 	switch {
 	case exp.GetConfigExpectation() != nil:
 		ce := exp.GetConfigExpectation()
-		return processConfigExpectation(ce)
+		res = processConfigExpectation(ce)
 	case exp.GetControlPlaneExpectation() != nil:
 		cpe := exp.GetControlPlaneExpectation()
-		return processControlPlaneExpectation(cpe)
+		res = processControlPlaneExpectation(cpe, counterPredicates[exp.GetExpectationId()])
 	case exp.GetDataPlaneExpectation() != nil:
 		dpe := exp.GetDataPlaneExpectation()
-		return processDataPlaneExpectation(dpe)
+		res = processDataPlaneExpectation(dpe)
 	case exp.GetTelemetryExpectation() != nil:
 		te := exp.GetTelemetryExpectation()
-		return processTelemetryExpectation(te)
+		res = processTelemetryExpectation(te)
 	default:
 		log.Infof("Empty expectation\n")
-		return false
+		res = result.Failf("empty expectation")
 	}
+	return res.Since(exp.GetExpectationId(), start)
 }
 
-//processConfigExpectation extracts gnmi get and forwards it to framework
-func processConfigExpectation(ce *tv.ConfigExpectation) bool {
+//processConfigExpectation extracts gnmi get and forwards it to framework, polling the switch as configured by pollOptions
+func processConfigExpectation(ce *tv.ConfigExpectation) *result.Result {
 	log.Debug("In processConfigExpectation")
-	return gnmi.ProcessGetRequest(ce.GetGnmiGetRequest(), ce.GetGnmiGetResponse())
+	return poll(func() *result.Result {
+		return gnmi.ProcessGetRequest(ce.GetGnmiGetRequest(), ce.GetGnmiGetResponse())
+	})
 }
 
 //processControlPlaneExpectation extracts get pipeline config, read or packet in expectations and forwards to framework.
-func processControlPlaneExpectation(cpe *tv.ControlPlaneExpectation) bool {
+//Read and pipeline config expectations are polled as configured by pollOptions.
+//Counter entries of read expectations are checked against the counter predicates, if any.
+func processControlPlaneExpectation(cpe *tv.ControlPlaneExpectation, counters CounterPredicates) *result.Result {
 	log.Debug("In processControlPlaneExpectation")
 	switch {
 	case cpe.GetReadExpectation() != nil:
 		log.Debug("In Get Read Expectation")
-		//TODO
+		re := cpe.GetReadExpectation()
+		if counters != (CounterPredicates{}) {
+			return poll(func() *result.Result {
+				return p4rt.ProcessP4ReadRequestWithCounters(re.GetP4ReadRequest(), re.GetP4ReadResponses(), counters.Packets, counters.Bytes)
+			})
+		}
+		return poll(func() *result.Result {
+			return p4rt.ProcessP4ReadRequest(re.GetP4ReadRequest(), re.GetP4ReadResponses())
+		})
 	case cpe.GetPacketInExpectation() != nil:
 		log.Debug("In Get Packet In Expectation")
 		return p4rt.ProcessPacketIn(cpe.GetPacketInExpectation().GetP4PacketIn())
 	case cpe.GetPipelineConfigExpectation() != nil:
 		log.Debug("In Get Pipeline Config Expectation")
-		//TODO
+		pce := cpe.GetPipelineConfigExpectation()
+		return poll(func() *result.Result {
+			return p4rt.ProcessP4GetPipelineConfigRequest(pce.GetP4GetPipelineConfigRequest(), pce.GetP4GetPipelineConfigResponse())
+		})
 	}
-	return false
+	return result.Failf("empty control plane expectation")
 }
 
 //processDataPlaneExpectation extracts packets to be sent to data plane ports and forwards to framework.
-func processDataPlaneExpectation(dpe *tv.DataPlaneExpectation) bool {
+func processDataPlaneExpectation(dpe *tv.DataPlaneExpectation) *result.Result {
 	log.Debug("In processDataPlaneExpectation")
 	switch {
 	case dpe.GetTrafficExpectation() != nil:
@@ -82,14 +174,14 @@ func processDataPlaneExpectation(dpe *tv.DataPlaneExpectation) bool {
 		}
 		return dataplane.ProcessTrafficExpectation(payloads, dpe.GetTrafficExpectation().GetPorts())
 	}
-	return false
+	return result.Failf("empty data plane expectation")
 }
 
 //processTelemetryExpectation executes subscribe expectations. These expectations contain gnmi subscribe request, set of actions to be performed after successful subscription and responses to be verfied.
-//Returns false if responses are not received with in timeout.
-func processTelemetryExpectation(tme *tv.TelemetryExpectation) bool {
+//Returns a failed result if responses are not received with in timeout.
+func processTelemetryExpectation(tme *tv.TelemetryExpectation) *result.Result {
 	log.Debug("In processTelemetryExpectation")
-	resultChan := make(chan bool, 1)
+	resultChan := make(chan *result.Result, 1)
 	firstRespChan := make(chan struct{})
 	go gnmi.ProcessSubscribeRequest(tme.GetGnmiSubscribeRequest(), tme.GetGnmiSubscribeResponse(), firstRespChan, resultChan)
 	select {
@@ -98,14 +190,14 @@ func processTelemetryExpectation(tme *tv.TelemetryExpectation) bool {
 		select {
 		case subResult := <-resultChan:
 			log.Debug("In ProcessTelemetryExpectation, Case Sub Result")
-			return subResult && actionResult
+			return result.Combine(subResult, actionResult)
 		case <-time.After(gnmi.SubTimeout):
 			log.Error("Timed out waiting for ProcessSubscribeRequest result")
-			return false
+			return result.Combine(result.Failf("timed out waiting for subscription result"), actionResult)
 		}
 	case <-time.After(gnmi.SubTimeout):
 		log.Error("Timed out waiting for first subscription response")
-		return false
+		return result.Failf("timed out waiting for first subscription response")
 	}
 
 }
@@ -8,10 +8,13 @@ package expectation
 
 import (
 	"testing"
+	"time"
 
 	gpb "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/stratum/testvectors-runner/pkg/framework/gnmi"
 	"github.com/stratum/testvectors-runner/pkg/framework/p4rt"
+	"github.com/stratum/testvectors-runner/pkg/result"
+	"github.com/stratum/testvectors-runner/pkg/utils/predicate"
 	pm "github.com/stratum/testvectors/proto/portmap"
 	tg "github.com/stratum/testvectors/proto/target"
 	tv "github.com/stratum/testvectors/proto/testvector"
@@ -125,7 +128,7 @@ func TestProcessConfigExpectation(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := processConfigExpectation(tt.args.ce); got != tt.want {
+			if got := processConfigExpectation(tt.args.ce); got.Passed() != tt.want {
 				t.Errorf("ProcessConfigExpectation() = %v, want %v", got, tt.want)
 			}
 		})
@@ -179,7 +182,7 @@ func TestProcessControlPlaneExpectation(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := processControlPlaneExpectation(tt.args.cpe); got != tt.want {
+			if got := processControlPlaneExpectation(tt.args.cpe, CounterPredicates{}); got.Passed() != tt.want {
 				t.Errorf("ProcessControlPlaneExpectation() = %v, want %v", got, tt.want)
 			}
 		})
@@ -210,7 +213,7 @@ func TestProcessDataPlaneExpectation(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := processDataPlaneExpectation(tt.args.dpe); got != tt.want {
+			if got := processDataPlaneExpectation(tt.args.dpe); got.Passed() != tt.want {
 				t.Errorf("ProcessDataPlaneExpectation() = %v, want %v", got, tt.want)
 			}
 		})
@@ -247,9 +250,118 @@ func TestProcessTelemetryExpectation(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := processTelemetryExpectation(tt.args.tme); got != tt.want {
+			if got := processTelemetryExpectation(tt.args.tme); got.Passed() != tt.want {
 				t.Errorf("ProcessTelemetryExpectation() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestPoll(t *testing.T) {
+	defer SetPollOptions(PollOptions{})
+	tests := []struct {
+		name         string
+		opts         PollOptions
+		passAt       int
+		want         bool
+		wantAttempts int
+	}{
+		{
+			name:         "Polling disabled",
+			opts:         PollOptions{},
+			passAt:       2,
+			want:         false,
+			wantAttempts: 1,
+		},
+		{
+			name:         "Match on first attempt",
+			opts:         PollOptions{Interval: time.Millisecond, Deadline: 50 * time.Millisecond},
+			passAt:       1,
+			want:         true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "Match before deadline",
+			opts:         PollOptions{Interval: time.Millisecond, Deadline: time.Second},
+			passAt:       3,
+			want:         true,
+			wantAttempts: 3,
+		},
+		{
+			name:         "Deadline expired",
+			opts:         PollOptions{Interval: 20 * time.Millisecond, Deadline: 50 * time.Millisecond},
+			passAt:       10,
+			want:         false,
+			wantAttempts: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetPollOptions(tt.opts)
+			attempts := 0
+			got := poll(func() *result.Result {
+				attempts++
+				if attempts >= tt.passAt {
+					return result.Pass()
+				}
+				return result.Mismatch("responses are unequal", tt.passAt, attempts)
+			})
+			if got.Passed() != tt.want {
+				t.Errorf("poll() = %v, want %v", got, tt.want)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("poll() attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestPollDelta(t *testing.T) {
+	defer SetPollOptions(PollOptions{})
+	defer predicate.Reset()
+	SetPollOptions(PollOptions{Interval: time.Millisecond, Deadline: time.Second})
+	predicate.Record("counter", predicate.Number(100))
+	p, err := predicate.Parse("~delta >= 10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//each attempt compares with the sample from before the first attempt, not with the count of the previous attempt
+	counts := []float64{104, 108, 112}
+	attempts := 0
+	got := poll(func() *result.Result {
+		count := counts[attempts]
+		attempts++
+		if err := p.Check("counter", predicate.Number(count)); err != nil {
+			return result.Fail(err)
+		}
+		return result.Pass()
+	})
+	if !got.Passed() || attempts != 3 {
+		t.Errorf("poll() = %v after %d attempts, want passed after 3", got, attempts)
+	}
+	if last, _ := predicate.Last("counter"); last != predicate.Number(112) {
+		t.Errorf("last sample = %s, want 112", last)
+	}
+}
+
+func TestSetPollOptions(t *testing.T) {
+	defer SetPollOptions(PollOptions{})
+	tests := []struct {
+		name string
+		opts PollOptions
+		want time.Duration
+	}{
+		{name: "Zero interval", opts: PollOptions{Interval: 0, Deadline: time.Second}, want: MinPollInterval},
+		{name: "Negative interval", opts: PollOptions{Interval: -time.Second, Deadline: time.Second}, want: MinPollInterval},
+		{name: "Valid interval", opts: PollOptions{Interval: time.Second, Deadline: time.Minute}, want: time.Second},
+		{name: "Polling disabled", opts: PollOptions{}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetPollOptions(tt.opts)
+			if pollOptions.Interval != tt.want {
+				t.Errorf("SetPollOptions() interval = %s, want %s", pollOptions.Interval, tt.want)
+			}
+		})
+	}
+}
@@ -10,48 +10,141 @@ Package testvector implements functions to extract and run each test case from t
 package testvector
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/stratum/testvectors-runner/pkg/logger"
 	"github.com/stratum/testvectors-runner/pkg/orchestrator/action"
 	"github.com/stratum/testvectors-runner/pkg/orchestrator/expectation"
+	"github.com/stratum/testvectors-runner/pkg/result"
 	tv "github.com/stratum/testvectors/proto/testvector"
 )
 
 var log = logger.NewLogger()
 
-//ProcessTestVector parses test vector and calls ProcessTestCase for each test case
-func ProcessTestVector(tv1 *tv.TestVector) bool {
+//FailurePolicy decides what happens to the remaining steps of a test vector after a step failed
+type FailurePolicy int
+
+//Test cases are independent of each other, so with every policy the following test cases are still executed.
+const (
+	//SkipExpectationsOnFailure executes all the actions and skips the expectations of a test case if any of its actions failed,
+	//as Test Vectors don't exercise expectations after failed operations. It is the default policy.
+	SkipExpectationsOnFailure FailurePolicy = iota
+	//ContinueOnFailure executes all the steps and reports all the failures
+	ContinueOnFailure
+	//StopOnFailure skips all the steps of a test case after its first failure: the remaining actions, including the
+	//parallel actions which haven't started yet, the remaining action groups and the remaining expectations
+	StopOnFailure
+)
+
+//failurePolicy is the default policy of the run, it can be overridden per test vector
+var failurePolicy = SkipExpectationsOnFailure
+
+func (p FailurePolicy) String() string {
+	switch p {
+	case SkipExpectationsOnFailure:
+		return "skip-expectations"
+	case StopOnFailure:
+		return "stop"
+	case ContinueOnFailure:
+		return "continue"
+	default:
+		return fmt.Sprintf("FailurePolicy(%d)", int(p))
+	}
+}
+
+//ParseFailurePolicy returns the failure policy with the given name, "skip-expectations", "stop" or "continue"
+func ParseFailurePolicy(name string) (FailurePolicy, error) {
+	switch name {
+	case "skip-expectations":
+		return SkipExpectationsOnFailure, nil
+	case "stop":
+		return StopOnFailure, nil
+	case "continue":
+		return ContinueOnFailure, nil
+	default:
+		return SkipExpectationsOnFailure, fmt.Errorf("unknown failure policy: %s", name)
+	}
+}
+
+//SetFailurePolicy sets the default failure policy used by ProcessTestVector and ProcessTestCase
+func SetFailurePolicy(policy FailurePolicy) {
+	log.Debugf("Failure policy: %s", policy)
+	failurePolicy = policy
+}
+
+//GetFailurePolicy returns the default failure policy
+func GetFailurePolicy() FailurePolicy {
+	return failurePolicy
+}
+
+//ProcessTestVector parses test vector and calls ProcessTestCase for each test case.
+//It returns the combined result with one child result per test case.
+func ProcessTestVector(tv1 *tv.TestVector) *result.Result {
+	return ProcessTestVectorWithPolicy(tv1, failurePolicy)
+}
+
+//ProcessTestVectorWithPolicy processes each test case of the test vector with the given failure policy.
+//Every test case is processed, the policy only applies to the steps within each test case.
+func ProcessTestVectorWithPolicy(tv1 *tv.TestVector, policy FailurePolicy) *result.Result {
 	log.Debug("In ProcessTestVector")
-	result := true
+	res := result.Pass()
 	for _, tc := range tv1.GetTestCases() {
-		result = ProcessTestCase(tc) && result
+		res.Add(ProcessTestCaseWithPolicy(tc, policy))
 	}
-	return result
+	return res
+}
+
+//ProcessTestCase combines the results from action groups and expectations using the default failure policy.
+func ProcessTestCase(tc *tv.TestCase) *result.Result {
+	return ProcessTestCaseWithPolicy(tc, failurePolicy)
 }
 
-//ProcessTestCase combine the results from processActionGroups and processExpectations to return true or false.
-func ProcessTestCase(tc *tv.TestCase) bool {
+//ProcessTestCaseWithPolicy combines the results from action groups and expectations.
+//With StopOnFailure the steps after the first failed step are skipped, with SkipExpectationsOnFailure the expectations are
+//skipped if any action failed and with ContinueOnFailure all the steps are processed.
+func ProcessTestCaseWithPolicy(tc *tv.TestCase, policy FailurePolicy) *result.Result {
 	log.Infof("Test Case ID: %s\n", tc.TestCaseId)
-	return processActionGroups(tc.GetActionGroups()) && processExpectations(tc.GetExpectations())
+	start := time.Now()
+	stop := policy == StopOnFailure
+	res := result.Pass()
+	res.Add(processActionGroups(tc.GetActionGroups(), stop).Children...)
+	skip := policy != ContinueOnFailure && res.Failed()
+	res.Add(processExpectations(tc.GetExpectations(), skip, stop).Children...)
+	return res.Since(tc.GetTestCaseId(), start)
 }
 
-//processActionGroups calls ProcessActionGroup method for each action group in the list, combines all the results to return true or false.
-func processActionGroups(ags []*tv.ActionGroup) bool {
-	actionResult := true
+//processActionGroups calls ProcessActionGroup method for each action group in the list and combines all the results.
+//If stop is true the actions and action groups after the first failed action are skipped.
+func processActionGroups(ags []*tv.ActionGroup, stop bool) *result.Result {
+	actionResult := result.Pass()
 	for _, ag := range ags {
+		if stop && actionResult.Failed() {
+			actionResult.Add(result.Skip(ag.GetActionGroupId(), "previous action group failed"))
+			continue
+		}
 		log.Infof("Action Group ID: %s\n", ag.ActionGroupId)
-		currentResult := action.ProcessActionGroup(ag)
-		actionResult = actionResult && currentResult
+		actionResult.Add(action.ProcessActionGroupWithPolicy(ag, stop))
 	}
 	return actionResult
 }
 
-//processExpectations calls ProcessAExpectation method for each expectation in the list, combines all the results to return true or false.
-func processExpectations(exps []*tv.Expectation) bool {
-	expectationResult := true
+//processExpectations calls ProcessExpectation method for each expectation in the list and combines all the results.
+//If skip is true because a previous action failed, all expectations are skipped.
+//If stop is true the expectations after the first failed expectation are skipped.
+func processExpectations(exps []*tv.Expectation, skip bool, stop bool) *result.Result {
+	expectationResult := result.Pass()
 	for _, exp := range exps {
+		if skip {
+			expectationResult.Add(result.Skip(exp.GetExpectationId(), "action failed"))
+			continue
+		}
+		if stop && expectationResult.Failed() {
+			expectationResult.Add(result.Skip(exp.GetExpectationId(), "previous expectation failed"))
+			continue
+		}
 		log.Infof("Expectation ID: %s\n", exp.ExpectationId)
-		currentResult := expectation.ProcessExpectation(exp)
-		expectationResult = expectationResult && currentResult
+		expectationResult.Add(expectation.ProcessExpectation(exp))
 	}
 	return expectationResult
 }
@@ -7,10 +7,12 @@
 package testvector_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/stratum/testvectors-runner/pkg/framework/gnmi"
 	"github.com/stratum/testvectors-runner/pkg/orchestrator/testvector"
+	"github.com/stratum/testvectors-runner/pkg/result"
 	tg "github.com/stratum/testvectors/proto/target"
 	tv "github.com/stratum/testvectors/proto/testvector"
 )
@@ -108,13 +110,104 @@ func TestProcessTestVector(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := testvector.ProcessTestVector(tt.args.tv1); got != tt.want {
+			if got := testvector.ProcessTestVector(tt.args.tv1); got.Passed() != tt.want {
 				t.Errorf("ProcessTestVector() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestParseFailurePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		want    testvector.FailurePolicy
+		wantErr bool
+	}{
+		{name: "Skip Expectations", policy: "skip-expectations", want: testvector.SkipExpectationsOnFailure},
+		{name: "Stop", policy: "stop", want: testvector.StopOnFailure},
+		{name: "Continue", policy: "continue", want: testvector.ContinueOnFailure},
+		{name: "Unknown", policy: "retry", want: testvector.SkipExpectationsOnFailure, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testvector.ParseFailurePolicy(tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseFailurePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFailurePolicy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessTestVectorWithPolicy(t *testing.T) {
+	//Empty action groups and expectations fail without reaching the switch
+	failingTC := func(id string) *tv.TestCase {
+		return &tv.TestCase{
+			TestCaseId:   id,
+			ActionGroups: []*tv.ActionGroup{{ActionGroupId: "ag1"}, {ActionGroupId: "ag2"}},
+			Expectations: []*tv.Expectation{{ExpectationId: "exp1"}},
+		}
+	}
+	expectationsTC := &tv.TestCase{
+		TestCaseId:   "tc3",
+		Expectations: []*tv.Expectation{{ExpectationId: "exp1"}, {ExpectationId: "exp2"}},
+	}
+	tv1 := &tv.TestVector{TestCases: []*tv.TestCase{failingTC("tc1"), failingTC("tc2"), expectationsTC}}
+	tests := []struct {
+		name   string
+		policy testvector.FailurePolicy
+		want   map[string][]result.Status
+	}{
+		{
+			name:   "Skip expectations on failure",
+			policy: testvector.SkipExpectationsOnFailure,
+			want: map[string][]result.Status{
+				"tc1": {result.Failed, result.Failed, result.Skipped},
+				"tc2": {result.Failed, result.Failed, result.Skipped},
+				"tc3": {result.Failed, result.Failed},
+			},
+		},
+		{
+			name:   "Stop on failure",
+			policy: testvector.StopOnFailure,
+			want: map[string][]result.Status{
+				"tc1": {result.Failed, result.Skipped, result.Skipped},
+				"tc2": {result.Failed, result.Skipped, result.Skipped},
+				"tc3": {result.Failed, result.Skipped},
+			},
+		},
+		{
+			name:   "Continue on failure",
+			policy: testvector.ContinueOnFailure,
+			want: map[string][]result.Status{
+				"tc1": {result.Failed, result.Failed, result.Failed},
+				"tc2": {result.Failed, result.Failed, result.Failed},
+				"tc3": {result.Failed, result.Failed},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testvector.ProcessTestVectorWithPolicy(tv1, tt.policy)
+			if got.Passed() {
+				t.Errorf("ProcessTestVectorWithPolicy() passed, want failed")
+			}
+			for _, tc := range got.Children {
+				var statuses []result.Status
+				for _, step := range tc.Children {
+					statuses = append(statuses, step.Status)
+				}
+				if !reflect.DeepEqual(statuses, tt.want[tc.ID]) {
+					t.Errorf("ProcessTestVectorWithPolicy() test case %s = %v, want %v", tc.ID, statuses, tt.want[tc.ID])
+				}
+			}
+		})
+	}
+}
+
 /*
 validTestVector = &tv.TestVector{
 			TestCases: []*tv.TestCase{
@@ -253,7 +346,7 @@ validTestVector = &tv.TestVector{
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := ProcessTestCase(tt.args.tc); got != tt.want {
+			if got := ProcessTestCase(tt.args.tc); got.Passed() != tt.want {
 				t.Errorf("ProcessTestCase() = %v, want %v", got, tt.want)
 			}
 		})
@@ -0,0 +1,164 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+/*
+Package result implements the result model of actions, expectations and test cases
+*/
+package result
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+//Status of a test step
+type Status int
+
+const (
+	//Passed means the step was executed and met its expectation
+	Passed Status = iota
+	//Failed means the step was executed and did not meet its expectation
+	Failed
+	//Skipped means the step was not executed, e.g. because an earlier step failed
+	Skipped
+)
+
+func (s Status) String() string {
+	switch s {
+	case Passed:
+		return "PASSED"
+	case Failed:
+		return "FAILED"
+	case Skipped:
+		return "SKIPPED"
+	default:
+		return fmt.Sprintf("Status(%d)", int(s))
+	}
+}
+
+//Result stores the outcome of a test step, which could be an action, an action group, an expectation or a test case.
+type Result struct {
+	Status Status
+	//ID identifies the step, e.g. ActionGroupId, ExpectationId or TestCaseId
+	ID       string
+	Duration time.Duration
+	//Err explains why the step failed
+	Err error
+	//Diff shows the expected and actual values when they don't match
+	Diff string
+	//Note records how the step was executed, e.g. the seed and action order of a randomized action group
+	Note string
+	//Children stores the results of the steps executed as part of this step
+	Children []*Result
+}
+
+//Pass returns a passed result
+func Pass() *Result {
+	return &Result{Status: Passed}
+}
+
+//Fail returns a failed result with the given error
+func Fail(err error) *Result {
+	return &Result{Status: Failed, Err: err}
+}
+
+//Failf returns a failed result with an error built from format and arguments
+func Failf(format string, a ...interface{}) *Result {
+	return Fail(fmt.Errorf(format, a...))
+}
+
+//Skip returns a skipped result for the step with given ID and the reason why it was not executed
+func Skip(id string, reason string) *Result {
+	return &Result{Status: Skipped, ID: id, Err: errors.New(reason)}
+}
+
+//Mismatch returns a failed result with the given error message and a diff of expected and actual values
+func Mismatch(msg string, expected, actual interface{}) *Result {
+	return &Result{
+		Status: Failed,
+		Err:    errors.New(msg),
+		Diff:   fmt.Sprintf("Expected: %v\nActual  : %v", expected, actual),
+	}
+}
+
+//Combine returns a result with the given results as children.
+//The combined result fails if any of the children failed. Skipped children don't fail the combined result.
+func Combine(results ...*Result) *Result {
+	r := Pass()
+	r.Add(results...)
+	return r
+}
+
+//Add appends the given results as children and marks r as failed if any of them failed
+func (r *Result) Add(results ...*Result) {
+	for _, child := range results {
+		if child.Failed() {
+			r.Status = Failed
+		}
+		r.Children = append(r.Children, child)
+	}
+}
+
+//Passed returns true if the step passed. A nil result is treated as failed.
+func (r *Result) Passed() bool {
+	return r != nil && r.Status == Passed
+}
+
+//Failed returns true if the step failed. A nil result is treated as failed.
+func (r *Result) Failed() bool {
+	return r == nil || r.Status == Failed
+}
+
+//Skipped returns true if the step was not executed
+func (r *Result) Skipped() bool {
+	return r != nil && r.Status == Skipped
+}
+
+//Since sets the ID of r and its duration measured from start, and returns r
+func (r *Result) Since(id string, start time.Time) *Result {
+	r.ID = id
+	r.Duration = time.Since(start)
+	return r
+}
+
+//String returns a report of the result and all its children, one step per line
+func (r *Result) String() string {
+	var sb strings.Builder
+	r.write(&sb, 0)
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+//write appends the report of r to sb with the given indentation level
+func (r *Result) write(sb *strings.Builder, level int) {
+	if r == nil {
+		return
+	}
+	indent := strings.Repeat("  ", level)
+	sb.WriteString(indent + r.Status.String())
+	if r.ID != "" {
+		sb.WriteString(" " + r.ID)
+	}
+	if r.Duration != 0 {
+		sb.WriteString(fmt.Sprintf(" (%s)", r.Duration.Round(time.Millisecond)))
+	}
+	if r.Note != "" {
+		sb.WriteString(" [" + r.Note + "]")
+	}
+	if r.Err != nil {
+		sb.WriteString(": " + r.Err.Error())
+	}
+	sb.WriteString("\n")
+	if r.Diff != "" {
+		for _, line := range strings.Split(r.Diff, "\n") {
+			sb.WriteString(indent + "    " + line + "\n")
+		}
+	}
+	for _, child := range r.Children {
+		child.write(sb, level+1)
+	}
+}
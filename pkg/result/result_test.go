@@ -0,0 +1,72 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package result
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPassed(t *testing.T) {
+	tests := []struct {
+		name string
+		r    *Result
+		want bool
+	}{
+		{name: "Nil result", r: nil, want: false},
+		{name: "Pass", r: Pass(), want: true},
+		{name: "Fail", r: Fail(errors.New("error")), want: false},
+		{name: "Failf", r: Failf("error %d", 1), want: false},
+		{name: "Mismatch", r: Mismatch("unequal", 1, 2), want: false},
+		{name: "Combine empty", r: Combine(), want: true},
+		{name: "Combine passed", r: Combine(Pass(), Pass()), want: true},
+		{name: "Combine failed", r: Combine(Pass(), Failf("error")), want: false},
+		{name: "Combine nil", r: Combine(Pass(), nil), want: false},
+		{name: "Combine nested failed", r: Combine(Pass(), Combine(Pass(), Failf("error"))), want: false},
+		{name: "Skip", r: Skip("tc1", "previous test case failed"), want: false},
+		{name: "Combine skipped", r: Combine(Pass(), Skip("tc1", "previous test case failed")), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Passed(); got != tt.want {
+				t.Errorf("Passed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name string
+		r    *Result
+		want string
+	}{
+		{name: "Pass", r: Pass(), want: "PASSED"},
+		{name: "Pass with ID", r: &Result{Status: Passed, ID: "tc1"}, want: "PASSED tc1"},
+		{name: "Pass with duration", r: &Result{Status: Passed, ID: "tc1", Duration: 1500 * time.Microsecond}, want: "PASSED tc1 (2ms)"},
+		{name: "Pass with note", r: &Result{Status: Passed, ID: "ag1", Note: "seed 42, action order [1 0]"}, want: "PASSED ag1 [seed 42, action order [1 0]]"},
+		{name: "Fail", r: Failf("error %d", 1), want: "FAILED: error 1"},
+		{name: "Mismatch", r: Mismatch("unequal", 1, 2), want: "FAILED: unequal\n    Expected: 1\n    Actual  : 2"},
+		{name: "Skip", r: Skip("ag2", "previous action group failed"), want: "SKIPPED ag2: previous action group failed"},
+		{
+			name: "Nested",
+			r: &Result{Status: Failed, ID: "tc1", Children: []*Result{
+				{Status: Passed, ID: "ag1"},
+				{Status: Failed, ID: "exp1", Children: []*Result{Mismatch("unequal", "a", "b")}},
+			}},
+			want: "FAILED tc1\n  PASSED ag1\n  FAILED exp1\n    FAILED: unequal\n        Expected: a\n        Actual  : b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
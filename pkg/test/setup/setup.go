@@ -12,9 +12,12 @@ package setup
 import (
 	"github.com/stratum/testvectors-runner/pkg/framework/dataplane"
 	"github.com/stratum/testvectors-runner/pkg/framework/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/framework/gnoi"
 	"github.com/stratum/testvectors-runner/pkg/framework/p4rt"
+	"github.com/stratum/testvectors-runner/pkg/framework/port"
 
 	"github.com/stratum/testvectors-runner/pkg/logger"
+	"github.com/stratum/testvectors-runner/pkg/utils/predicate"
 	pm "github.com/stratum/testvectors/proto/portmap"
 	tg "github.com/stratum/testvectors/proto/target"
 )
@@ -22,13 +25,15 @@ import (
 var log = logger.NewLogger()
 
 //Suite includes steps for setting up a test suite
-func Suite(target *tg.Target, dpMode string, matchType string, portmap *pm.PortMap) {
+func Suite(target *tg.Target, dpMode string, matchType string, portMode string, portmap *pm.PortMap) {
 	log.Info("Setting up test suite...")
 	log.Infof("Target: %s", target)
 	// Create data plane
 	dataplane.CreateDataPlane(dpMode, matchType, portmap)
 	gnmi.Init(target)
+	gnoi.Init(target)
 	p4rt.Init(target, dpMode, portmap)
+	port.CreatePortStimulus(portMode, portmap)
 }
 
 //Test includes steps for setting up a test
@@ -41,4 +46,7 @@ func TestCase() {
 	log.Info("Setting up test case...")
 	// FIXME: only start packet capture if needed
 	dataplane.Capture()
+	p4rt.ClearStreamMessages()
+	//delta predicates only compare with samples of the same test case
+	predicate.Reset()
 }
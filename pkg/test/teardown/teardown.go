@@ -11,19 +11,39 @@ package teardown
 
 import (
 	"strings"
+	"time"
 
 	"github.com/stratum/testvectors-runner/pkg/framework/dataplane"
 	"github.com/stratum/testvectors-runner/pkg/framework/gnmi"
+	"github.com/stratum/testvectors-runner/pkg/framework/gnoi"
 	"github.com/stratum/testvectors-runner/pkg/framework/p4rt"
 	"github.com/stratum/testvectors-runner/pkg/logger"
+	"github.com/stratum/testvectors-runner/pkg/result"
 )
 
 var log = logger.NewLogger()
 
+//p4rtCleanup enables resetting the P4Runtime state after each test case
+var p4rtCleanup bool
+
+//SetP4RuntimeCleanup enables or disables resetting the P4Runtime state to the baseline after each test case
+func SetP4RuntimeCleanup(enabled bool) {
+	p4rtCleanup = enabled
+}
+
+//Baseline keeps the P4Runtime state left by a setup test case as the baseline restored after the following test cases.
+//It must be called before TestCase.
+func Baseline() {
+	if p4rtCleanup {
+		p4rt.SaveBaseline()
+	}
+}
+
 //Suite includes steps for tearing down a test suite
 func Suite() {
 	log.Info("Tearing down test suite...")
 	gnmi.TearDown()
+	gnoi.TearDown()
 	p4rt.TearDown()
 }
 
@@ -32,9 +52,20 @@ func Test() {
 	log.Info("Tearing down test...")
 }
 
-//TestCase includes steps for tearing down a test case
-func TestCase() {
+//TestCase includes steps for tearing down a test case.
+//It returns a failed result if the P4Runtime state cleanup failed, so that the test case reports it.
+func TestCase() *result.Result {
 	log.Info("Tearing down test case...")
 	dataplane.Stop()
+	res := result.Pass()
+	if p4rtCleanup {
+		start := time.Now()
+		if cleanup := p4rt.ResetState(); !cleanup.Passed() {
+			log.Errorf("P4Runtime state cleanup failed\n%s", cleanup)
+			res = cleanup.Since("p4rt-cleanup", start)
+		}
+	}
+	p4rt.ClearStreamMessages()
 	log.Info(strings.Repeat("*", 100))
+	return res
 }
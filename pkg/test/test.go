@@ -10,37 +10,51 @@ Package test implements functions to create and run go tests
 package test
 
 import (
-	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	"github.com/stratum/testvectors-runner/pkg/framework/p4rt"
 	"github.com/stratum/testvectors-runner/pkg/logger"
 	"github.com/stratum/testvectors-runner/pkg/test/setup"
 	"github.com/stratum/testvectors-runner/pkg/test/teardown"
 	"github.com/stratum/testvectors-runner/pkg/test/testsuite"
 	"github.com/stratum/testvectors-runner/pkg/test/tvsuite"
+	"github.com/stratum/testvectors-runner/pkg/utils/transport"
 	pm "github.com/stratum/testvectors/proto/portmap"
 	tg "github.com/stratum/testvectors/proto/target"
 )
 
 var log = logger.NewLogger()
 
-// Deps implements testDeps interface used by MainStart function
-type Deps struct{}
+//Directive comments in the target file set the default P4Runtime controller identity,
+//e.g. "# device-id: 1", "# election-id: 1:5", "# role-id: 1" and "# role-config: role_config.pb.txt"
+const (
+	deviceIDDirective   = "# device-id:"
+	electionIDDirective = "# election-id:"
+	roleIDDirective     = "# role-id:"
+	roleConfigDirective = "# role-config:"
+)
+
+//Directive comments in the target file set the TLS options of the connections to the target, overridden by command line flags,
+//e.g. "# tls: true", "# ca-cert: ca.crt", "# client-cert: client.crt", "# client-key: client.key" and "# tls-server-name: switch"
+const (
+	tlsDirective           = "# tls:"
+	caCertDirective        = "# ca-cert:"
+	clientCertDirective    = "# client-cert:"
+	clientKeyDirective     = "# client-key:"
+	tlsServerNameDirective = "# tls-server-name:"
+)
 
-func (Deps) ImportPath() string                          { return "" }
-func (Deps) MatchString(pat, str string) (bool, error)   { return true, nil }
-func (Deps) StartCPUProfile(io.Writer) error             { return nil }
-func (Deps) StartTestLog(io.Writer)                      {}
-func (Deps) StopCPUProfile()                             {}
-func (Deps) StopTestLog() error                          { return nil }
-func (Deps) WriteHeapProfile(io.Writer) error            { return nil }
-func (Deps) WriteProfileTo(string, io.Writer, int) error { return nil }
+//matchAll matches every test, tests are selected when the suite is created
+func matchAll(pat, str string) (bool, error) { return true, nil }
 
 //Suite interface defines Create method for converting tv files, test names to go test type
 type Suite interface {
@@ -109,6 +123,107 @@ func getTarget(fileName string) *tg.Target {
 	return target
 }
 
+//getController reads the controller identity directives of the given target file, starting from the default controller.
+//The role config file is a google.protobuf.Any in text format, relative to the target file.
+//panics if a directive is invalid
+func getController(fileName string) p4rt.Controller {
+	tgdata, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		log.Fatalf("Error opening target file: %s\n%s", fileName, err)
+	}
+	c := p4rt.GetDefaultController()
+	var roleConfig *any.Any
+	for _, line := range strings.Split(string(tgdata), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, deviceIDDirective):
+			value := strings.TrimSpace(strings.TrimPrefix(line, deviceIDDirective))
+			if c.DeviceID, err = strconv.ParseUint(value, 10, 64); err != nil {
+				log.Fatalf("Error parsing device ID of target file %s\n%s", fileName, err)
+			}
+		case strings.HasPrefix(line, electionIDDirective):
+			value := strings.TrimSpace(strings.TrimPrefix(line, electionIDDirective))
+			if c.ElectionID, err = p4rt.ParseElectionID(value); err != nil {
+				log.Fatalf("Error parsing election ID of target file %s\n%s", fileName, err)
+			}
+		case strings.HasPrefix(line, roleIDDirective):
+			value := strings.TrimSpace(strings.TrimPrefix(line, roleIDDirective))
+			roleID, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				log.Fatalf("Error parsing role ID of target file %s\n%s", fileName, err)
+			}
+			c.Role = &v1.Role{Id: roleID}
+		case strings.HasPrefix(line, roleConfigDirective):
+			roleConfigFile := strings.TrimSpace(strings.TrimPrefix(line, roleConfigDirective))
+			if !filepath.IsAbs(roleConfigFile) {
+				roleConfigFile = filepath.Join(filepath.Dir(fileName), roleConfigFile)
+			}
+			roleConfig = getRoleConfig(roleConfigFile)
+		}
+	}
+	if roleConfig != nil {
+		if c.Role == nil {
+			log.Fatalf("Role config given without role ID in target file %s", fileName)
+		}
+		c.Role.Config = roleConfig
+	}
+	log.Infof("Controller: %s", c)
+	return c
+}
+
+//getTLSOptions reads the TLS directives of the given target file. Certificate and key files are relative to the target file.
+//panics if a directive is invalid
+func getTLSOptions(fileName string) transport.TLSOptions {
+	tgdata, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		log.Fatalf("Error opening target file: %s\n%s", fileName, err)
+	}
+	var opts transport.TLSOptions
+	//getFile returns the file of the directive relative to the target file
+	getFile := func(line, directive string) string {
+		file := strings.TrimSpace(strings.TrimPrefix(line, directive))
+		if file != "" && !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(fileName), file)
+		}
+		return file
+	}
+	for _, line := range strings.Split(string(tgdata), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, tlsDirective):
+			value := strings.TrimSpace(strings.TrimPrefix(line, tlsDirective))
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				log.Fatalf("Error parsing TLS directive of target file %s\n%s", fileName, err)
+			}
+			opts.Enabled = &enabled
+		case strings.HasPrefix(line, caCertDirective):
+			opts.CACert = getFile(line, caCertDirective)
+		case strings.HasPrefix(line, clientCertDirective):
+			opts.ClientCert = getFile(line, clientCertDirective)
+		case strings.HasPrefix(line, clientKeyDirective):
+			opts.ClientKey = getFile(line, clientKeyDirective)
+		case strings.HasPrefix(line, tlsServerNameDirective):
+			opts.ServerName = strings.TrimSpace(strings.TrimPrefix(line, tlsServerNameDirective))
+		}
+	}
+	return opts
+}
+
+//getRoleConfig reads the given file and converts it to a google.protobuf.Any proto.
+//panics if file is invalid
+func getRoleConfig(fileName string) *any.Any {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		log.Fatalf("Error opening role config file: %s\n%s", fileName, err)
+	}
+	roleConfig := &any.Any{}
+	if err = proto.UnmarshalText(string(data), roleConfig); err != nil {
+		log.Fatalf("Error parsing proto message of type %T from file %s\n%s", roleConfig, fileName, err)
+	}
+	return roleConfig
+}
+
 //getPortMap reads the given file and converts it to portmap proto.
 //panics if file is invalid
 func getPortMap(fileName string) *pm.PortMap {
@@ -125,13 +240,22 @@ func getPortMap(fileName string) *pm.PortMap {
 }
 
 //Run calls suite setup, teardown and runs all tests in the testSuite against given target
-func Run(tgFile string, dpMode string, matchType string, pmFile string, testSuite []testing.InternalTest) {
+func Run(tgFile string, dpMode string, matchType string, portMode string, pmFile string, testSuite []testing.InternalTest) {
 	log.Debug("In Run")
 	target := getTarget(tgFile)
+	transport.SetTLSOptions(getTLSOptions(tgFile).Override(transport.GetTLSOptions()))
+	p4rt.SetDefaultController(getController(tgFile))
 	portmap := getPortMap(pmFile)
-	setup.Suite(target, dpMode, matchType, portmap)
-	var match Deps
-	code := testing.MainStart(match, testSuite, nil, nil).Run()
-	teardown.Suite()
-	os.Exit(code)
+	setup.Suite(target, dpMode, matchType, portMode, portmap)
+	//testing.Main exits when the tests are done, so the suite is torn down at the end of the last test
+	if len(testSuite) == 0 {
+		teardown.Suite()
+	} else {
+		last := testSuite[len(testSuite)-1]
+		testSuite[len(testSuite)-1].F = func(t *testing.T) {
+			defer teardown.Suite()
+			last.F(t)
+		}
+	}
+	testing.Main(matchAll, testSuite, nil, nil)
 }
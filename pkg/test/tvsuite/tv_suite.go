@@ -12,22 +12,61 @@ package tvsuite
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"testing"
 	"text/template"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/stratum/testvectors-runner/pkg/framework/p4rt"
 	"github.com/stratum/testvectors-runner/pkg/logger"
+	"github.com/stratum/testvectors-runner/pkg/orchestrator/expectation"
 	"github.com/stratum/testvectors-runner/pkg/orchestrator/testvector"
 	"github.com/stratum/testvectors-runner/pkg/test/setup"
 	"github.com/stratum/testvectors-runner/pkg/test/teardown"
+	"github.com/stratum/testvectors-runner/pkg/utils/p4info"
+	"github.com/stratum/testvectors-runner/pkg/utils/predicate"
+	"github.com/stratum/testvectors-runner/pkg/utils/schema"
 	tv "github.com/stratum/testvectors/proto/testvector"
 )
 
 var log = logger.NewLogger()
 
+//failurePolicyDirective is a comment line in Test Vector and template files which overrides the failure policy of the run for that file,
+//e.g. "# failure-policy: continue"
+const failurePolicyDirective = "# failure-policy:"
+
+//baselineDirective is a comment line in Test Vector and template files which keeps the P4Runtime state left by each of
+//their test cases as the baseline restored by the P4Runtime state cleanup, e.g. in a setup Test Vector
+const baselineDirective = "# cleanup: baseline"
+
+//Counter directives are comment lines in Test Vector and template files which check the packet or byte counts of the counter entries
+//of a read expectation against a predicate instead of the expected counts, e.g. "# counter-packets: read-counters ~delta == 3"
+//followed by "# counter-bytes: read-counters ~delta >= 180" for the read expectation with ID read-counters
+const (
+	counterPacketsDirective = "# counter-packets:"
+	counterBytesDirective   = "# counter-bytes:"
+)
+
+//p4NameCall matches a template action calling one of the P4 name functions, e.g. "{{p4Table".
+//Only Test Vector files which contain one are expanded as template, because the payloads of other files may contain "{{".
+var p4NameCall = regexp.MustCompile(`\{\{-?\s*(` + strings.Join(p4NameFuncs(), "|") + `)\b`)
+
+//p4NameFuncs returns the names of the P4 name template functions
+func p4NameFuncs() []string {
+	var names []string
+	for name := range p4info.FuncMap() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 //TVSuite struct stores a list of testvector file names, list of template file names and template config file
 type TVSuite struct {
 	TvFiles        []string
@@ -35,40 +74,148 @@ type TVSuite struct {
 	TemplateConfig string
 }
 
+//tvSource stores a Test Vector file or template file and the Test Vector loaded from it
+type tvSource struct {
+	fileName string
+	template bool
+	tv       *tv.TestVector
+	policy   testvector.FailurePolicy
+	baseline bool
+	counters map[string]expectation.CounterPredicates
+	err      error
+}
+
 // Create builds and returns a slice of testing.InternalTest from a slice of Test Vector files.
 // It iterates through Test Vector files and template files and for each test case it wraps around ProcessTestCase
 // to build anonymous functions for testing.InternalTest.
+// Files which refer to P4 entities by name are loaded after the others, so that the P4Info can be taken from a pipeline config
+// Test Vector when no P4Info file is loaded. All Test Vectors are validated against the P4Info if there is one,
+// and their gNMI payloads against the gNMI schema if one is selected. A file which cannot be loaded or fails validation
+// is reported as a failed test without running it, the other files are still run.
 func (tv TVSuite) Create() []testing.InternalTest {
 	log.Debug("In Create")
 	testSuite := []testing.InternalTest{}
-	// Read TV files and add them to the test suite
-	for _, tvFile := range tv.TvFiles {
-		tv := getTVFromFile(tvFile)
-		t := getInternalTest(tvFile, tv)
+	for _, src := range tv.sources() {
+		if src.err != nil {
+			log.Errorf("Skipping file %s\n%s", src.fileName, src.err)
+			testSuite = append(testSuite, getFailedTest(src))
+			continue
+		}
+		t := getInternalTest(src)
 		testSuite = append(testSuite, t)
 	}
-	// Read TV template files and add them to the test suite
+	return testSuite
+}
+
+// sources loads and validates the Test Vector and template files. The error of a file which cannot be loaded
+// or fails validation is stored in its source.
+func (tv TVSuite) sources() []*tvSource {
+	var sources, deferred []*tvSource
+	for _, tvFile := range tv.TvFiles {
+		sources = append(sources, &tvSource{fileName: tvFile})
+	}
 	for _, templateFile := range tv.TemplateFiles {
-		tv := getTVFromTemplateFile(templateFile, tv.TemplateConfig)
-		t := getInternalTest(templateFile, tv)
-		testSuite = append(testSuite, t)
+		sources = append(sources, &tvSource{fileName: templateFile, template: true})
 	}
-	return testSuite
+	for _, src := range sources {
+		if err := tv.load(src); errors.Is(err, p4info.ErrNoP4Info) {
+			deferred = append(deferred, src)
+		} else if err != nil {
+			src.err = err
+		}
+	}
+	if p4info.Get() == nil {
+		for _, src := range sources {
+			if info := p4info.FromTestVector(src.tv); info != nil {
+				log.Infof("Using P4Info from pipeline config in %s", src.fileName)
+				p4info.Set(info)
+				break
+			}
+		}
+	}
+	for _, src := range deferred {
+		if err := tv.load(src); errors.Is(err, p4info.ErrNoP4Info) {
+			src.err = fmt.Errorf("Error resolving P4 names in file %s\n%s\nSpecify a P4Info file or include a pipeline config Test Vector", src.fileName, err)
+		} else if err != nil {
+			src.err = err
+		}
+	}
+	for _, src := range sources {
+		if src.err == nil && p4info.Get() != nil {
+			if err := p4info.Validate(src.tv); err != nil {
+				src.err = fmt.Errorf("Error validating file %s against P4Info\n%s", src.fileName, err)
+			}
+		}
+		if src.err == nil && schema.Enabled() {
+			if err := schema.Validate(src.tv); err != nil {
+				src.err = fmt.Errorf("Error validating file %s against gNMI schema\n%s", src.fileName, err)
+			}
+		}
+	}
+	return sources
 }
 
-func getInternalTest(tvFile string, tv *tv.TestVector) testing.InternalTest {
+// load reads the Test Vector of src. It returns p4info.ErrNoP4Info if the file refers to P4 entities by name and no P4Info is loaded yet.
+func (tv TVSuite) load(src *tvSource) error {
+	var err error
+	var tvdata string
+	if src.template {
+		src.tv, tvdata, err = getTVFromTemplateFile(src.fileName, tv.TemplateConfig)
+	} else {
+		src.tv, tvdata, err = getTVFromFile(src.fileName)
+	}
+	if err != nil {
+		return err
+	}
+	if src.policy, err = getFailurePolicy(src.fileName, tvdata); err != nil {
+		return err
+	}
+	src.baseline = isBaseline(tvdata)
+	src.counters, err = getCounterPredicates(src.fileName, tvdata, src.tv)
+	return err
+}
+
+//getFailedTest returns a test which fails with the error of a Test Vector that cannot be run
+func getFailedTest(src *tvSource) testing.InternalTest {
 	return testing.InternalTest{
-		Name: strings.Replace(filepath.Base(tvFile), ".pb.txt", "", 1),
+		Name: testName(src.fileName),
+		F: func(t *testing.T) {
+			t.Fatal(src.err)
+		},
+	}
+}
+
+//testName returns the name of the test of a Test Vector or template file
+func testName(fileName string) string {
+	return strings.Replace(filepath.Base(fileName), ".pb.txt", "", 1)
+}
+
+//getInternalTest wraps the test cases of a Test Vector into a test with one subtest per test case.
+//The failure policy applies to the steps within each test case, test cases are always executed.
+func getInternalTest(src *tvSource) testing.InternalTest {
+	tv, policy := src.tv, src.policy
+	return testing.InternalTest{
+		Name: testName(src.fileName),
 		F: func(t *testing.T) {
 			setup.Test()
+			expectation.SetCounterPredicates(src.counters)
+			defer expectation.SetCounterPredicates(nil)
 			// Process test cases and add them to the test
 			for _, tc := range tv.GetTestCases() {
 				t.Run(tc.TestCaseId, func(t *testing.T) {
 					setup.TestCase()
-					result := testvector.ProcessTestCase(tc)
-					teardown.TestCase()
-					if !result {
-						t.Fail()
+					result := testvector.ProcessTestCaseWithPolicy(tc, policy)
+					if stream := p4rt.CheckStream(); !stream.Passed() {
+						result.Add(stream)
+					}
+					if src.baseline {
+						teardown.Baseline()
+					}
+					if cleanup := teardown.TestCase(); !cleanup.Passed() {
+						result.Add(cleanup)
+					}
+					if !result.Passed() {
+						t.Error(result)
 					}
 				})
 			}
@@ -77,43 +224,144 @@ func getInternalTest(tvFile string, tv *tv.TestVector) testing.InternalTest {
 	}
 }
 
-// getTVFromFile reads Test Vector file with given file name and returns Test Vectors.
-func getTVFromFile(fileName string) *tv.TestVector {
+// getTVFromFile reads Test Vector file with given file name, resolves P4 names and returns Test Vectors and the resolved file data.
+// It returns p4info.ErrNoP4Info if the file refers to P4 entities by name and no P4Info is loaded.
+func getTVFromFile(fileName string) (*tv.TestVector, string, error) {
 	log.Debug("In getTVFromFile")
 	tvdata, err := ioutil.ReadFile(fileName)
 	if err != nil {
-		log.Fatalf("Error opening test vector file: %s\n%s", fileName, err)
+		return nil, "", fmt.Errorf("Error opening test vector file: %s\n%s", fileName, err)
+	}
+	data := string(tvdata)
+	if p4NameCall.MatchString(data) {
+		t, err := template.New(filepath.Base(fileName)).Funcs(p4info.FuncMap()).Parse(data)
+		if err != nil {
+			return nil, "", fmt.Errorf("Error parsing P4 names in test vector file: %s\n%s\nWrite \"{\" as \"\\173\" in payloads of files using P4 names", fileName, err)
+		}
+		buf := new(bytes.Buffer)
+		if err = t.Execute(buf, nil); err != nil {
+			if errors.Is(err, p4info.ErrNoP4Info) {
+				return nil, "", err
+			}
+			return nil, "", fmt.Errorf("Error resolving P4 names in test vector file: %s\n%s", fileName, err)
+		}
+		data = buf.String()
 	}
 	testvector := &tv.TestVector{}
-	if err = proto.UnmarshalText(string(tvdata), testvector); err != nil {
-		log.Fatalf("Error parsing proto message of type %T from file %s\n%s", testvector, fileName, err)
+	if err = proto.UnmarshalText(data, testvector); err != nil {
+		return nil, "", fmt.Errorf("Error parsing proto message of type %T from file %s\n%s", testvector, fileName, err)
 	}
-	return testvector
+	return testvector, data, nil
 }
 
-// getTVFromTemplateFile reads Template file, config file and returns the converted Test Vectors.
-func getTVFromTemplateFile(templateFile string, templateConfigFile string) *tv.TestVector {
+// getTVFromTemplateFile reads Template file, config file and returns the converted Test Vectors and the converted file data.
+// It returns p4info.ErrNoP4Info if the file refers to P4 entities by name and no P4Info is loaded.
+func getTVFromTemplateFile(templateFile string, templateConfigFile string) (*tv.TestVector, string, error) {
 	log.Debug("In getTVFromTemplateFile")
 	tvdata, err := ioutil.ReadFile(templateFile)
 	if err != nil {
-		log.Fatalf("Error opening test vector file: %s\n%s", templateFile, err)
+		return nil, "", fmt.Errorf("Error opening test vector file: %s\n%s", templateFile, err)
+	}
+	t, err := template.New("tv.tmpl").Funcs(p4info.FuncMap()).Parse(string(tvdata))
+	if err != nil {
+		return nil, "", fmt.Errorf("Error parsing template file: %s\n%s", templateFile, err)
 	}
-	t := template.Must(template.New("tv.tmpl").Parse(string(tvdata)))
 	jsondata, err := ioutil.ReadFile(templateConfigFile)
 	if err != nil {
-		log.Fatalf("Error opening template config file: %s\n%s", templateConfigFile, err)
+		return nil, "", fmt.Errorf("Error opening template config file: %s\n%s", templateConfigFile, err)
 	}
 	m := map[string]interface{}{}
 	if err := json.Unmarshal([]byte(jsondata), &m); err != nil {
-		panic(err)
+		return nil, "", fmt.Errorf("Error parsing template config file: %s\n%s", templateConfigFile, err)
 	}
 	buf := new(bytes.Buffer)
 	if err = t.Execute(buf, m); err != nil {
-		panic(err)
+		if errors.Is(err, p4info.ErrNoP4Info) {
+			return nil, "", err
+		}
+		return nil, "", fmt.Errorf("Error executing template file: %s\n%s", templateFile, err)
 	}
 	testvector := &tv.TestVector{}
 	if err = proto.UnmarshalText(buf.String(), testvector); err != nil {
-		log.Fatalf("Error parsing proto message of type %T from file %s\n%s", testvector, templateFile, err)
+		return nil, "", fmt.Errorf("Error parsing proto message of type %T from file %s\n%s", testvector, templateFile, err)
+	}
+	return testvector, buf.String(), nil
+}
+
+// getFailurePolicy returns the failure policy set by the failure policy directive in Test Vector data.
+// If there is no directive the default failure policy of the run is returned.
+func getFailurePolicy(fileName string, tvdata string) (testvector.FailurePolicy, error) {
+	for _, line := range strings.Split(tvdata, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, failurePolicyDirective) {
+			continue
+		}
+		name := strings.TrimSpace(strings.TrimPrefix(line, failurePolicyDirective))
+		policy, err := testvector.ParseFailurePolicy(name)
+		if err != nil {
+			return policy, fmt.Errorf("Error parsing failure policy of file %s\n%s", fileName, err)
+		}
+		log.Infof("Using failure policy %s for file %s", policy, fileName)
+		return policy, nil
+	}
+	return testvector.GetFailurePolicy(), nil
+}
+
+// isBaseline returns true if Test Vector data has the baseline directive.
+func isBaseline(tvdata string) bool {
+	for _, line := range strings.Split(tvdata, "\n") {
+		if strings.TrimSpace(line) == baselineDirective {
+			return true
+		}
+	}
+	return false
+}
+
+// getCounterPredicates returns the counter predicates set by the counter directives in Test Vector data by expectation ID.
+// The directives must refer to read expectations of the Test Vector.
+func getCounterPredicates(fileName string, tvdata string, testvector *tv.TestVector) (map[string]expectation.CounterPredicates, error) {
+	counters := make(map[string]expectation.CounterPredicates)
+	for _, line := range strings.Split(tvdata, "\n") {
+		line = strings.TrimSpace(line)
+		var directive string
+		switch {
+		case strings.HasPrefix(line, counterPacketsDirective):
+			directive = counterPacketsDirective
+		case strings.HasPrefix(line, counterBytesDirective):
+			directive = counterBytesDirective
+		default:
+			continue
+		}
+		fields := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(line, directive)), " ", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("Error parsing counter directive of file %s\n%q is not \"%s <expectation ID> <predicate>\"", fileName, line, directive)
+		}
+		id, value := fields[0], strings.TrimSpace(fields[1])
+		if _, err := predicate.Parse(value); err != nil {
+			return nil, fmt.Errorf("Error parsing counter directive of file %s\n%s", fileName, err)
+		}
+		if !hasReadExpectation(testvector, id) {
+			return nil, fmt.Errorf("Error parsing counter directive of file %s\nno read expectation with ID %s", fileName, id)
+		}
+		c := counters[id]
+		if directive == counterPacketsDirective {
+			c.Packets = value
+		} else {
+			c.Bytes = value
+		}
+		counters[id] = c
+	}
+	return counters, nil
+}
+
+// hasReadExpectation returns true if the Test Vector has a read expectation with the given ID.
+func hasReadExpectation(testvector *tv.TestVector, id string) bool {
+	for _, tc := range testvector.GetTestCases() {
+		for _, exp := range tc.GetExpectations() {
+			if exp.GetExpectationId() == id && exp.GetControlPlaneExpectation().GetReadExpectation() != nil {
+				return true
+			}
+		}
 	}
-	return testvector
+	return false
 }
@@ -0,0 +1,180 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tvsuite
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	config "github.com/p4lang/p4runtime/go/p4/config/v1"
+	"github.com/stratum/testvectors-runner/pkg/orchestrator/expectation"
+	"github.com/stratum/testvectors-runner/pkg/utils/p4info"
+	tv "github.com/stratum/testvectors/proto/testvector"
+)
+
+var testP4Info = &config.P4Info{
+	ControllerPacketMetadata: []*config.ControllerPacketMetadata{
+		{
+			Preamble: &config.Preamble{Id: 67121543, Name: "packet_out"},
+			Metadata: []*config.ControllerPacketMetadata_Metadata{{Id: 1, Name: "egress_port", Bitwidth: 9}},
+		},
+	},
+}
+
+//packetOutTV returns a Test Vector with a packet-out of the given payload and metadata ID
+func packetOutTV(payload, metadataID string) string {
+	return `
+test_cases: <
+  test_case_id: "packet-out"
+  action_groups: <
+    action_group_id: "1"
+    sequential_action_group: <
+      actions: <
+        control_plane_operation: <
+          packet_out_operation: <
+            p4_packet_out: <
+              payload: "` + payload + `"
+              metadata: <
+                metadata_id: ` + metadataID + `
+                value: "\000\001"
+              >
+            >
+          >
+        >
+      >
+    >
+  >
+>`
+}
+
+func TestGetTVFromFile(t *testing.T) {
+	defer p4info.Set(nil)
+	dir, err := ioutil.TempDir("", "tvsuite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name        string
+		p4info      *config.P4Info
+		data        string
+		wantPayload string
+		wantID      uint32
+		wantErr     error
+	}{
+		{name: "Payload With Braces", data: packetOutTV(`\000{{\001}}`, "1"), wantPayload: "\x00{{\x01}}", wantID: 1},
+		{name: "P4 Name", p4info: testP4Info, data: packetOutTV(`\000\001`, `{{p4Metadata "packet_out" "egress_port"}}`), wantPayload: "\x00\x01", wantID: 1},
+		{name: "P4 Name And Escaped Braces", p4info: testP4Info, data: packetOutTV(`\000\173\173\001`, `{{ p4Metadata "packet_out" "egress_port" }}`), wantPayload: "\x00{{\x01", wantID: 1},
+		{name: "P4 Name Without P4Info", data: packetOutTV(`\000\001`, `{{p4Metadata "packet_out" "egress_port"}}`), wantErr: p4info.ErrNoP4Info},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p4info.Set(tt.p4info)
+			fileName := filepath.Join(dir, string(rune('a'+i))+".pb.txt")
+			if err := ioutil.WriteFile(fileName, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, _, err := getTVFromFile(fileName)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("getTVFromFile() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			pktOut := got.GetTestCases()[0].GetActionGroups()[0].GetSequentialActionGroup().GetActions()[0].GetControlPlaneOperation().GetPacketOutOperation().GetP4PacketOut()
+			if string(pktOut.GetPayload()) != tt.wantPayload {
+				t.Errorf("payload = %q, want %q", pktOut.GetPayload(), tt.wantPayload)
+			}
+			if pktOut.GetMetadata()[0].GetMetadataId() != tt.wantID {
+				t.Errorf("metadata ID = %d, want %d", pktOut.GetMetadata()[0].GetMetadataId(), tt.wantID)
+			}
+		})
+	}
+}
+
+func TestGetCounterPredicates(t *testing.T) {
+	testvector := &tv.TestVector{TestCases: []*tv.TestCase{{
+		Expectations: []*tv.Expectation{
+			{ExpectationId: "read-counters", Expectations: &tv.Expectation_ControlPlaneExpectation{ControlPlaneExpectation: &tv.ControlPlaneExpectation{
+				Expectations: &tv.ControlPlaneExpectation_ReadExpectation_{ReadExpectation: &tv.ControlPlaneExpectation_ReadExpectation{}},
+			}}},
+		},
+	}}}
+	tests := []struct {
+		name    string
+		tvdata  string
+		want    map[string]expectation.CounterPredicates
+		wantErr bool
+	}{
+		{name: "No Directive", tvdata: "test_cases: <>", want: map[string]expectation.CounterPredicates{}},
+		{
+			name:   "Packets And Bytes",
+			tvdata: "# counter-packets: read-counters ~delta == 3\n  # counter-bytes: read-counters  ~delta >= 180\ntest_cases: <>",
+			want:   map[string]expectation.CounterPredicates{"read-counters": {Packets: "~delta == 3", Bytes: "~delta >= 180"}},
+		},
+		{
+			name:   "Packets Only",
+			tvdata: "# counter-packets: read-counters ~> 0",
+			want:   map[string]expectation.CounterPredicates{"read-counters": {Packets: "~> 0"}},
+		},
+		{name: "Missing Predicate", tvdata: "# counter-packets: read-counters", wantErr: true},
+		{name: "Invalid Predicate", tvdata: "# counter-packets: read-counters ~delta 3", wantErr: true},
+		{name: "Unknown Expectation", tvdata: "# counter-bytes: read-meters ~> 0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getCounterPredicates("test.pb.txt", tt.tvdata, testvector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getCounterPredicates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getCounterPredicates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tvsuite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"valid.pb.txt":     packetOutTV(`\000\001`, "1"),
+		"malformed.pb.txt": "test_cases: <",
+		"policy.pb.txt":    "# failure-policy: retry\n" + packetOutTV(`\000\001`, "1"),
+		"p4name.pb.txt":    packetOutTV(`\000\001`, `{{p4Metadata "packet_out" "egress_port"}}`),
+	}
+	var suite TVSuite
+	for name, data := range files {
+		fileName := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(fileName, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		suite.TvFiles = append(suite.TvFiles, fileName)
+	}
+	sort.Strings(suite.TvFiles)
+	//files which cannot be loaded keep their error, the other files are still loaded
+	wantErr := map[string]bool{"malformed": true, "p4name": true, "policy": true, "valid": false}
+	sources := suite.sources()
+	if len(sources) != len(files) {
+		t.Fatalf("sources() = %d sources, want %d", len(sources), len(files))
+	}
+	for _, src := range sources {
+		name := testName(src.fileName)
+		if (src.err != nil) != wantErr[name] {
+			t.Errorf("sources() error of %s = %v, wantErr %v", name, src.err, wantErr[name])
+		}
+	}
+}
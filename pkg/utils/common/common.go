@@ -29,11 +29,11 @@ func GetUint16(i uint32) []byte {
 	return byteSlice
 }
 
-//GetInt converts byte slice to int
+//GetInt converts big-endian byte slice to int
 func GetInt(s []byte) int {
 	var res int
 	for _, v := range s {
-		res <<= 4
+		res <<= 8
 		res |= int(v)
 	}
 	return res
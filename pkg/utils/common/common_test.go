@@ -0,0 +1,50 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package common
+
+import "testing"
+
+func TestGetInt(t *testing.T) {
+	tests := []struct {
+		name string
+		s    []byte
+		want int
+	}{
+		{name: "Empty", s: []byte{}, want: 0},
+		{name: "Single Byte", s: []byte{0xff}, want: 255},
+		{name: "Two Bytes 256", s: []byte{0x01, 0x00}, want: 256},
+		{name: "Two Bytes", s: []byte{0x12, 0x34}, want: 0x1234},
+		{name: "Port Number", s: GetUint16(511), want: 511},
+		{name: "Four Bytes", s: GetUint32(0x01020304), want: 0x01020304},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetInt(tt.s); got != tt.want {
+				t.Errorf("GetInt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetStr(t *testing.T) {
+	tests := []struct {
+		name string
+		i    interface{}
+		want string
+	}{
+		{name: "Byte Slice 256", i: []byte{0x01, 0x00}, want: "256"},
+		{name: "Uint16", i: uint16(300), want: "300"},
+		{name: "Unsupported", i: 1.5, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetStr(tt.i); got != tt.want {
+				t.Errorf("GetStr() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+/*
+Package p4info implements P4Info loading and resolution of P4 entity names to IDs
+*/
+package p4info
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"text/template"
+
+	"github.com/golang/protobuf/proto"
+	config "github.com/p4lang/p4runtime/go/p4/config/v1"
+	"github.com/stratum/testvectors-runner/pkg/logger"
+)
+
+var (
+	log = logger.NewLogger()
+	//p4info is the P4Info used to resolve names in Test Vectors
+	p4info *config.P4Info
+)
+
+//ErrNoP4Info is returned when a name is resolved before any P4Info is loaded
+var ErrNoP4Info = errors.New("no P4Info loaded")
+
+//Load reads the P4Info from a text or binary proto file and uses it to resolve names
+func Load(fileName string) error {
+	info, err := LoadFile(fileName)
+	if err != nil {
+		return err
+	}
+	log.Infof("Loaded P4Info from %s", fileName)
+	Set(info)
+	return nil
+}
+
+//LoadFile reads the P4Info from a text or binary proto file
+func LoadFile(fileName string) (*config.P4Info, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read P4Info file %s, %v", fileName, err)
+	}
+	info := &config.P4Info{}
+	if err = proto.UnmarshalText(string(data), info); err == nil {
+		return info, nil
+	}
+	if err = proto.Unmarshal(data, info); err != nil {
+		return nil, fmt.Errorf("cannot parse P4Info file %s, %v", fileName, err)
+	}
+	return info, nil
+}
+
+//Set sets the P4Info used to resolve names
+func Set(info *config.P4Info) {
+	p4info = info
+}
+
+//Get returns the P4Info used to resolve names, nil if none is loaded
+func Get() *config.P4Info {
+	return p4info
+}
+
+//matches returns true if name is the fully qualified name or the alias of the preamble
+func matches(pre *config.Preamble, name string) bool {
+	return pre.GetName() == name || pre.GetAlias() == name
+}
+
+//findTable returns the table with the given name
+func findTable(name string) (*config.Table, error) {
+	if p4info == nil {
+		return nil, ErrNoP4Info
+	}
+	for _, table := range p4info.GetTables() {
+		if matches(table.GetPreamble(), name) {
+			return table, nil
+		}
+	}
+	return nil, fmt.Errorf("table %s not found in P4Info", name)
+}
+
+//findAction returns the action with the given name
+func findAction(name string) (*config.Action, error) {
+	if p4info == nil {
+		return nil, ErrNoP4Info
+	}
+	for _, action := range p4info.GetActions() {
+		if matches(action.GetPreamble(), name) {
+			return action, nil
+		}
+	}
+	return nil, fmt.Errorf("action %s not found in P4Info", name)
+}
+
+//findControllerPacketMetadata returns the controller packet metadata with the given name, e.g. packet_in or packet_out
+func findControllerPacketMetadata(name string) (*config.ControllerPacketMetadata, error) {
+	if p4info == nil {
+		return nil, ErrNoP4Info
+	}
+	for _, cpm := range p4info.GetControllerPacketMetadata() {
+		if matches(cpm.GetPreamble(), name) {
+			return cpm, nil
+		}
+	}
+	return nil, fmt.Errorf("controller packet metadata %s not found in P4Info", name)
+}
+
+//TableID returns the ID of the table with the given name
+func TableID(name string) (uint32, error) {
+	table, err := findTable(name)
+	if err != nil {
+		return 0, err
+	}
+	return table.GetPreamble().GetId(), nil
+}
+
+//ActionID returns the ID of the action with the given name
+func ActionID(name string) (uint32, error) {
+	action, err := findAction(name)
+	if err != nil {
+		return 0, err
+	}
+	return action.GetPreamble().GetId(), nil
+}
+
+//MatchFieldID returns the ID of the match field with the given name in the given table
+func MatchFieldID(tableName, fieldName string) (uint32, error) {
+	table, err := findTable(tableName)
+	if err != nil {
+		return 0, err
+	}
+	for _, mf := range table.GetMatchFields() {
+		if mf.GetName() == fieldName {
+			return mf.GetId(), nil
+		}
+	}
+	return 0, fmt.Errorf("match field %s not found in table %s", fieldName, tableName)
+}
+
+//ParamID returns the ID of the parameter with the given name of the given action
+func ParamID(actionName, paramName string) (uint32, error) {
+	action, err := findAction(actionName)
+	if err != nil {
+		return 0, err
+	}
+	for _, param := range action.GetParams() {
+		if param.GetName() == paramName {
+			return param.GetId(), nil
+		}
+	}
+	return 0, fmt.Errorf("param %s not found in action %s", paramName, actionName)
+}
+
+//Metadata returns the metadata with the given name of the given controller packet metadata, e.g. ingress_port of packet_in
+func Metadata(cpmName, metadataName string) (*config.ControllerPacketMetadata_Metadata, error) {
+	cpm, err := findControllerPacketMetadata(cpmName)
+	if err != nil {
+		return nil, err
+	}
+	for _, md := range cpm.GetMetadata() {
+		if md.GetName() == metadataName {
+			return md, nil
+		}
+	}
+	return nil, fmt.Errorf("metadata %s not found in controller packet metadata %s", metadataName, cpmName)
+}
+
+//MetadataID returns the ID of the metadata with the given name of the given controller packet metadata
+func MetadataID(cpmName, metadataName string) (uint32, error) {
+	md, err := Metadata(cpmName, metadataName)
+	if err != nil {
+		return 0, err
+	}
+	return md.GetId(), nil
+}
+
+//FuncMap returns the template functions which resolve names to IDs in Test Vector files, e.g.
+//	table_id: {{p4Table "ingress.table0_control.table0"}}
+//	field_id: {{p4MatchField "ingress.table0_control.table0" "standard_metadata.ingress_port"}}
+//	action_id: {{p4Action "ingress.table0_control.set_egress_port"}}
+//	param_id: {{p4Param "ingress.table0_control.set_egress_port" "port"}}
+//	metadata_id: {{p4Metadata "packet_out" "egress_port"}}
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"p4Table":      TableID,
+		"p4Action":     ActionID,
+		"p4MatchField": MatchFieldID,
+		"p4Param":      ParamID,
+		"p4Metadata":   MetadataID,
+	}
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package p4info
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"text/template"
+
+	"github.com/golang/protobuf/proto"
+	config "github.com/p4lang/p4runtime/go/p4/config/v1"
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	tv "github.com/stratum/testvectors/proto/testvector"
+)
+
+var testP4Info = &config.P4Info{
+	Tables: []*config.Table{
+		{
+			Preamble:    &config.Preamble{Id: 33598026, Name: "ingress.table0_control.table0", Alias: "table0"},
+			MatchFields: []*config.MatchField{{Id: 1, Name: "standard_metadata.ingress_port"}, {Id: 2, Name: "hdr.ethernet.src_addr"}},
+			ActionRefs:  []*config.ActionRef{{Id: 16820507}},
+		},
+	},
+	Actions: []*config.Action{
+		{
+			Preamble: &config.Preamble{Id: 16820507, Name: "ingress.table0_control.set_egress_port", Alias: "set_egress_port"},
+			Params:   []*config.Action_Param{{Id: 1, Name: "port", Bitwidth: 9}},
+		},
+		{
+			Preamble: &config.Preamble{Id: 16800567, Name: "NoAction"},
+		},
+	},
+	ControllerPacketMetadata: []*config.ControllerPacketMetadata{
+		{
+			Preamble: &config.Preamble{Id: 67146229, Name: "packet_in"},
+			Metadata: []*config.ControllerPacketMetadata_Metadata{{Id: 1, Name: "ingress_port", Bitwidth: 9}},
+		},
+		{
+			Preamble: &config.Preamble{Id: 67121543, Name: "packet_out"},
+			Metadata: []*config.ControllerPacketMetadata_Metadata{{Id: 1, Name: "egress_port", Bitwidth: 9}},
+		},
+	},
+}
+
+func TestFuncMap(t *testing.T) {
+	defer Set(nil)
+	tests := []struct {
+		name    string
+		p4info  *config.P4Info
+		text    string
+		want    string
+		wantErr error
+	}{
+		{name: "Table by name", p4info: testP4Info, text: `{{p4Table "ingress.table0_control.table0"}}`, want: "33598026"},
+		{name: "Table by alias", p4info: testP4Info, text: `{{p4Table "table0"}}`, want: "33598026"},
+		{name: "Action", p4info: testP4Info, text: `{{p4Action "set_egress_port"}}`, want: "16820507"},
+		{name: "Match field", p4info: testP4Info, text: `{{p4MatchField "table0" "hdr.ethernet.src_addr"}}`, want: "2"},
+		{name: "Param", p4info: testP4Info, text: `{{p4Param "set_egress_port" "port"}}`, want: "1"},
+		{name: "Metadata", p4info: testP4Info, text: `{{p4Metadata "packet_out" "egress_port"}}`, want: "1"},
+		{name: "Unknown table", p4info: testP4Info, text: `{{p4Table "table1"}}`},
+		{name: "Unknown match field", p4info: testP4Info, text: `{{p4MatchField "table0" "hdr.ipv4.dst_addr"}}`},
+		{name: "Unknown param", p4info: testP4Info, text: `{{p4Param "NoAction" "port"}}`},
+		{name: "No P4Info", p4info: nil, text: `{{p4Table "table0"}}`, wantErr: ErrNoP4Info},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Set(tt.p4info)
+			buf := new(bytes.Buffer)
+			err := template.Must(template.New("tv").Funcs(FuncMap()).Parse(tt.text)).Execute(buf, nil)
+			switch {
+			case tt.want != "" && err != nil:
+				t.Errorf("Execute() error = %v", err)
+			case tt.want == "" && err == nil:
+				t.Errorf("Execute() = %s, want error", buf)
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("Execute() error = %v, want %v", err, tt.wantErr)
+			case tt.want != "" && buf.String() != tt.want:
+				t.Errorf("Execute() = %s, want %s", buf, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	defer Set(nil)
+	Set(testP4Info)
+	writeTV := func(entry *v1.TableEntry) *tv.TestVector {
+		return &tv.TestVector{TestCases: []*tv.TestCase{{
+			TestCaseId: "tc1",
+			ActionGroups: []*tv.ActionGroup{{
+				ActionGroupId: "ag1",
+				ActionGroup: &tv.ActionGroup_SequentialActionGroup{SequentialActionGroup: &tv.SequentialActionGroup{Actions: []*tv.Action{{
+					Actions: &tv.Action_ControlPlaneOperation{ControlPlaneOperation: &tv.ControlPlaneOperation{
+						Operations: &tv.ControlPlaneOperation_WriteOperation_{WriteOperation: &tv.ControlPlaneOperation_WriteOperation{
+							P4WriteRequest: &v1.WriteRequest{Updates: []*v1.Update{{Type: v1.Update_INSERT, Entity: &v1.Entity{Entity: &v1.Entity_TableEntry{TableEntry: entry}}}}},
+						}},
+					}},
+				}}}},
+			}},
+		}}}
+	}
+	tableEntry := func(tableID, fieldID, actionID, paramID uint32) *v1.TableEntry {
+		return &v1.TableEntry{
+			TableId: tableID,
+			Match:   []*v1.FieldMatch{{FieldId: fieldID, FieldMatchType: &v1.FieldMatch_Exact_{Exact: &v1.FieldMatch_Exact{Value: []byte{1}}}}},
+			Action: &v1.TableAction{Type: &v1.TableAction_Action{Action: &v1.Action{
+				ActionId: actionID,
+				Params:   []*v1.Action_Param{{ParamId: paramID, Value: []byte{2}}},
+			}}},
+		}
+	}
+	packetInTV := func(metadataID uint32) *tv.TestVector {
+		return &tv.TestVector{TestCases: []*tv.TestCase{{
+			TestCaseId: "tc1",
+			Expectations: []*tv.Expectation{{
+				ExpectationId: "exp1",
+				Expectations: &tv.Expectation_ControlPlaneExpectation{ControlPlaneExpectation: &tv.ControlPlaneExpectation{
+					Expectations: &tv.ControlPlaneExpectation_PacketInExpectation_{PacketInExpectation: &tv.ControlPlaneExpectation_PacketInExpectation{
+						P4PacketIn: &v1.PacketIn{Metadata: []*v1.PacketMetadata{{MetadataId: metadataID, Value: []byte{1}}}},
+					}},
+				}},
+			}},
+		}}}
+	}
+	tests := []struct {
+		name    string
+		tv      *tv.TestVector
+		wantErr bool
+	}{
+		{name: "Empty test vector", tv: &tv.TestVector{}},
+		{name: "Valid table entry", tv: writeTV(tableEntry(33598026, 1, 16820507, 1))},
+		{name: "Unknown table", tv: writeTV(tableEntry(33598027, 1, 16820507, 1)), wantErr: true},
+		{name: "Unknown match field", tv: writeTV(tableEntry(33598026, 3, 16820507, 1)), wantErr: true},
+		{name: "Unknown action", tv: writeTV(tableEntry(33598026, 1, 16820508, 1)), wantErr: true},
+		{name: "Action not in table", tv: writeTV(tableEntry(33598026, 1, 16800567, 0)), wantErr: true},
+		{name: "Unknown param", tv: writeTV(tableEntry(33598026, 1, 16820507, 2)), wantErr: true},
+		{name: "Valid packet in metadata", tv: packetInTV(1)},
+		{name: "Unknown packet in metadata", tv: packetInTV(2), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.tv); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFromTestVector(t *testing.T) {
+	pipelineTV := &tv.TestVector{TestCases: []*tv.TestCase{{
+		ActionGroups: []*tv.ActionGroup{{
+			ActionGroup: &tv.ActionGroup_SequentialActionGroup{SequentialActionGroup: &tv.SequentialActionGroup{Actions: []*tv.Action{{
+				Actions: &tv.Action_ControlPlaneOperation{ControlPlaneOperation: &tv.ControlPlaneOperation{
+					Operations: &tv.ControlPlaneOperation_PipelineConfigOperation_{PipelineConfigOperation: &tv.ControlPlaneOperation_PipelineConfigOperation{
+						P4SetPipelineConfigRequest: &v1.SetForwardingPipelineConfigRequest{Config: &v1.ForwardingPipelineConfig{P4Info: testP4Info}},
+					}},
+				}},
+			}}}},
+		}},
+	}}}
+	if got := FromTestVector(pipelineTV); !proto.Equal(got, testP4Info) {
+		t.Errorf("FromTestVector() = %v, want %v", got, testP4Info)
+	}
+	if got := FromTestVector(&tv.TestVector{}); got != nil {
+		t.Errorf("FromTestVector() = %v, want nil", got)
+	}
+}
@@ -0,0 +1,231 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+/*
+Package p4info implements P4Info loading and resolution of P4 entity names to IDs
+*/
+package p4info
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	config "github.com/p4lang/p4runtime/go/p4/config/v1"
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	tv "github.com/stratum/testvectors/proto/testvector"
+)
+
+//FromTestVector returns the P4Info of the first SetForwardingPipelineConfigRequest in the Test Vector, nil if there is none
+func FromTestVector(tv1 *tv.TestVector) *config.P4Info {
+	for _, tc := range tv1.GetTestCases() {
+		for _, ag := range tc.GetActionGroups() {
+			for _, action := range getActions(ag) {
+				req := action.GetControlPlaneOperation().GetPipelineConfigOperation().GetP4SetPipelineConfigRequest()
+				if info := req.GetConfig().GetP4Info(); info != nil {
+					return info
+				}
+			}
+		}
+	}
+	return nil
+}
+
+//Validate checks that all the P4 entity IDs used by the Test Vector exist in the loaded P4Info.
+//It returns an error listing every mismatch.
+func Validate(tv1 *tv.TestVector) error {
+	if p4info == nil {
+		return ErrNoP4Info
+	}
+	v := &validator{}
+	for _, tc := range tv1.GetTestCases() {
+		for _, ag := range tc.GetActionGroups() {
+			v.actionGroup(tc.GetTestCaseId()+"/"+ag.GetActionGroupId(), ag)
+		}
+		for _, exp := range tc.GetExpectations() {
+			v.expectation(tc.GetTestCaseId()+"/"+exp.GetExpectationId(), exp)
+		}
+	}
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(v.errs, "\n"))
+}
+
+//getActions returns the actions of the action group regardless of its type
+func getActions(ag *tv.ActionGroup) []*tv.Action {
+	switch {
+	case ag.GetSequentialActionGroup() != nil:
+		return ag.GetSequentialActionGroup().GetActions()
+	case ag.GetParallelActionGroup() != nil:
+		return ag.GetParallelActionGroup().GetActions()
+	case ag.GetRandomizedActionGroup() != nil:
+		return ag.GetRandomizedActionGroup().GetActions()
+	}
+	return nil
+}
+
+//validator collects the mismatches between Test Vector and P4Info
+type validator struct {
+	errs []string
+}
+
+func (v *validator) errorf(step string, format string, a ...interface{}) {
+	v.errs = append(v.errs, step+": "+fmt.Sprintf(format, a...))
+}
+
+func (v *validator) actionGroup(step string, ag *tv.ActionGroup) {
+	for _, action := range getActions(ag) {
+		cpo := action.GetControlPlaneOperation()
+		for _, update := range cpo.GetWriteOperation().GetP4WriteRequest().GetUpdates() {
+			v.entity(step, update.GetEntity())
+		}
+		if po := cpo.GetPacketOutOperation().GetP4PacketOut(); po != nil {
+			v.metadata(step, "packet_out", po.GetMetadata())
+		}
+	}
+}
+
+func (v *validator) expectation(step string, exp *tv.Expectation) {
+	cpe := exp.GetControlPlaneExpectation()
+	for _, entity := range cpe.GetReadExpectation().GetP4ReadRequest().GetEntities() {
+		v.entity(step, entity)
+	}
+	for _, resp := range cpe.GetReadExpectation().GetP4ReadResponses() {
+		for _, entity := range resp.GetEntities() {
+			v.entity(step, entity)
+		}
+	}
+	if pi := cpe.GetPacketInExpectation().GetP4PacketIn(); pi != nil {
+		v.metadata(step, "packet_in", pi.GetMetadata())
+	}
+	if ag := exp.GetTelemetryExpectation().GetActionGroup(); ag != nil {
+		v.actionGroup(step, ag)
+	}
+}
+
+func (v *validator) entity(step string, entity *v1.Entity) {
+	switch {
+	case entity.GetTableEntry() != nil:
+		v.tableEntry(step, entity.GetTableEntry())
+	case entity.GetDirectCounterEntry() != nil:
+		v.tableEntry(step, entity.GetDirectCounterEntry().GetTableEntry())
+	case entity.GetCounterEntry() != nil:
+		v.counter(step, entity.GetCounterEntry().GetCounterId())
+	case entity.GetMeterEntry() != nil:
+		v.meter(step, entity.GetMeterEntry().GetMeterId())
+	}
+}
+
+//tableEntry checks table, match field, action and param IDs. Table ID 0 is a wildcard in read requests and is not checked.
+func (v *validator) tableEntry(step string, entry *v1.TableEntry) {
+	if entry == nil || entry.GetTableId() == 0 {
+		return
+	}
+	var table *config.Table
+	for _, t := range p4info.GetTables() {
+		if t.GetPreamble().GetId() == entry.GetTableId() {
+			table = t
+		}
+	}
+	if table == nil {
+		v.errorf(step, "table ID %d not found in P4Info", entry.GetTableId())
+		return
+	}
+	for _, fm := range entry.GetMatch() {
+		found := false
+		for _, mf := range table.GetMatchFields() {
+			found = found || mf.GetId() == fm.GetFieldId()
+		}
+		if !found {
+			v.errorf(step, "match field ID %d not found in table %s", fm.GetFieldId(), table.GetPreamble().GetName())
+		}
+	}
+	tableAction := entry.GetAction()
+	if tableAction.GetAction() != nil {
+		v.action(step, table, tableAction.GetAction())
+	}
+	for _, profileAction := range tableAction.GetActionProfileActionSet().GetActionProfileActions() {
+		v.action(step, table, profileAction.GetAction())
+	}
+}
+
+//action checks that the action exists, that the table refers to it and that its params exist
+func (v *validator) action(step string, table *config.Table, action *v1.Action) {
+	var info *config.Action
+	for _, a := range p4info.GetActions() {
+		if a.GetPreamble().GetId() == action.GetActionId() {
+			info = a
+		}
+	}
+	if info == nil {
+		v.errorf(step, "action ID %d not found in P4Info", action.GetActionId())
+		return
+	}
+	ref := false
+	for _, ar := range table.GetActionRefs() {
+		ref = ref || ar.GetId() == action.GetActionId()
+	}
+	if !ref {
+		v.errorf(step, "action %s is not an action of table %s", info.GetPreamble().GetName(), table.GetPreamble().GetName())
+	}
+	for _, param := range action.GetParams() {
+		found := false
+		for _, p := range info.GetParams() {
+			found = found || p.GetId() == param.GetParamId()
+		}
+		if !found {
+			v.errorf(step, "param ID %d not found in action %s", param.GetParamId(), info.GetPreamble().GetName())
+		}
+	}
+}
+
+//counter checks the counter ID, 0 is a wildcard in read requests
+func (v *validator) counter(step string, id uint32) {
+	if id == 0 {
+		return
+	}
+	for _, c := range p4info.GetCounters() {
+		if c.GetPreamble().GetId() == id {
+			return
+		}
+	}
+	v.errorf(step, "counter ID %d not found in P4Info", id)
+}
+
+//meter checks the meter ID, 0 is a wildcard in read requests
+func (v *validator) meter(step string, id uint32) {
+	if id == 0 {
+		return
+	}
+	for _, m := range p4info.GetMeters() {
+		if m.GetPreamble().GetId() == id {
+			return
+		}
+	}
+	v.errorf(step, "meter ID %d not found in P4Info", id)
+}
+
+//metadata checks the packet metadata IDs against the controller packet metadata with the given name
+func (v *validator) metadata(step string, cpmName string, metadata []*v1.PacketMetadata) {
+	if len(metadata) == 0 {
+		return
+	}
+	cpm, err := findControllerPacketMetadata(cpmName)
+	if err != nil {
+		v.errorf(step, "%v", err)
+		return
+	}
+	for _, pm := range metadata {
+		found := false
+		for _, md := range cpm.GetMetadata() {
+			found = found || md.GetId() == pm.GetMetadataId()
+		}
+		if !found {
+			v.errorf(step, "metadata ID %d not found in controller packet metadata %s", pm.GetMetadataId(), cpmName)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+/*
+Package predicate implements conditions on leaf values used by expectations instead of exact values,
+e.g. on gNMI updates and P4Runtime counters
+*/
+package predicate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//Prefix marks a string value of an expectation as a predicate
+const Prefix = "~"
+
+//Predicate is a condition on a value, written as "~<op> <args>":
+//"~any" accepts any value, "~> 10", "~>= 10", "~< 10", "~<= 10", "~== 10" and "~!= 10" compare numbers,
+//"~range 10 20" accepts numbers from 10 to 20 included and "~regex <expr>" matches strings against a regular expression.
+//Comparisons and ranges prefixed with delta, e.g. "~delta >= 10", apply to the difference with the previous sample of the value.
+type Predicate struct {
+	text  string
+	op    string
+	delta bool
+	args  []float64
+	re    *regexp.Regexp
+}
+
+//Value is a leaf value a predicate is evaluated on, either a number or a string
+type Value struct {
+	num   float64
+	str   string
+	isNum bool
+}
+
+//Number returns a numeric value
+func Number(f float64) Value {
+	return Value{num: f, isNum: true}
+}
+
+//String returns a string value
+func String(s string) Value {
+	return Value{str: s}
+}
+
+func (v Value) String() string {
+	if v.isNum {
+		return strconv.FormatFloat(v.num, 'f', -1, 64)
+	}
+	return strconv.Quote(v.str)
+}
+
+var (
+	mu sync.Mutex
+	//samples holds the last value of each key, used by delta predicates
+	samples = make(map[string]Value)
+)
+
+//IsPredicate returns true if the string is a predicate
+func IsPredicate(s string) bool {
+	return strings.HasPrefix(s, Prefix)
+}
+
+//Parse returns the predicate written in s
+func Parse(s string) (*Predicate, error) {
+	if !IsPredicate(s) {
+		return nil, fmt.Errorf("predicate %q doesn't start with %s", s, Prefix)
+	}
+	p := &Predicate{text: s}
+	body := strings.TrimSpace(strings.TrimPrefix(s, Prefix))
+	if strings.HasPrefix(body, "regex ") {
+		re, err := regexp.Compile(strings.TrimSpace(strings.TrimPrefix(body, "regex ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid predicate %q: %v", s, err)
+		}
+		p.op, p.re = "regex", re
+		return p, nil
+	}
+	fields := strings.Fields(body)
+	if len(fields) > 0 && fields[0] == "delta" {
+		p.delta, fields = true, fields[1:]
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("invalid predicate %q: missing operator", s)
+	}
+	p.op = fields[0]
+	var want int
+	switch p.op {
+	case "any":
+		want = 0
+	case ">", ">=", "<", "<=", "==", "!=":
+		want = 1
+	case "range":
+		want = 2
+	default:
+		return nil, fmt.Errorf("invalid predicate %q: unknown operator %s", s, p.op)
+	}
+	if p.delta && p.op == "any" {
+		return nil, fmt.Errorf("invalid predicate %q: delta needs a comparison or range", s)
+	}
+	if len(fields)-1 != want {
+		return nil, fmt.Errorf("invalid predicate %q: %s takes %d arguments", s, p.op, want)
+	}
+	for _, f := range fields[1:] {
+		arg, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid predicate %q: %s is not a number", s, f)
+		}
+		p.args = append(p.args, arg)
+	}
+	return p, nil
+}
+
+func (p *Predicate) String() string {
+	return p.text
+}
+
+//Match returns an error if the value doesn't satisfy the predicate.
+//prev is the previous sample of the value, needed by delta predicates.
+func (p *Predicate) Match(v Value, prev *Value) error {
+	switch p.op {
+	case "any":
+		return nil
+	case "regex":
+		s := v.str
+		if v.isNum {
+			s = v.String()
+		}
+		if !p.re.MatchString(s) {
+			return fmt.Errorf("%s doesn't match %s", v, p)
+		}
+		return nil
+	}
+	if !v.isNum {
+		return fmt.Errorf("%s is not a number as required by %s", v, p)
+	}
+	x := v.num
+	if p.delta {
+		if prev == nil || !prev.isNum {
+			return fmt.Errorf("no previous numeric sample to compute the delta of %s for %s", v, p)
+		}
+		x -= prev.num
+	}
+	if !compare(p.op, x, p.args) {
+		if p.delta {
+			return fmt.Errorf("delta %s from %s to %s doesn't satisfy %s", strconv.FormatFloat(x, 'f', -1, 64), prev, v, p)
+		}
+		return fmt.Errorf("%s doesn't satisfy %s", v, p)
+	}
+	return nil
+}
+
+//Check evaluates the predicate on the value of key against the last sample of key.
+//The value then becomes the last sample of key, whether it satisfies the predicate or not.
+func (p *Predicate) Check(key string, v Value) error {
+	var err error
+	if prev, ok := Last(key); ok {
+		err = p.Match(v, &prev)
+	} else {
+		err = p.Match(v, nil)
+	}
+	Record(key, v)
+	return err
+}
+
+//compare applies a comparison or range operator to x
+func compare(op string, x float64, args []float64) bool {
+	switch op {
+	case ">":
+		return x > args[0]
+	case ">=":
+		return x >= args[0]
+	case "<":
+		return x < args[0]
+	case "<=":
+		return x <= args[0]
+	case "==":
+		return x == args[0]
+	case "!=":
+		return x != args[0]
+	case "range":
+		return x >= args[0] && x <= args[1]
+	default:
+		return false
+	}
+}
+
+//Record keeps the value as the last sample of key
+func Record(key string, v Value) {
+	mu.Lock()
+	defer mu.Unlock()
+	samples[key] = v
+}
+
+//Last returns the last sample of key, false if there is none
+func Last(key string) (Value, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	v, ok := samples[key]
+	return v, ok
+}
+
+//Snapshot returns a copy of the samples, e.g. to evaluate the delta predicates of a polled expectation against the same samples each time
+func Snapshot() map[string]Value {
+	mu.Lock()
+	defer mu.Unlock()
+	s := make(map[string]Value, len(samples))
+	for key, v := range samples {
+		s[key] = v
+	}
+	return s
+}
+
+//Restore replaces the samples with a snapshot
+func Restore(s map[string]Value) {
+	mu.Lock()
+	defer mu.Unlock()
+	samples = make(map[string]Value, len(s))
+	for key, v := range s {
+		samples[key] = v
+	}
+}
+
+//Reset drops all the samples
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	samples = make(map[string]Value)
+}
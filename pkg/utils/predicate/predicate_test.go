@@ -0,0 +1,129 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package predicate
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		wantErr bool
+	}{
+		{name: "Any", s: "~any"},
+		{name: "Greater", s: "~> 10"},
+		{name: "Less Or Equal Negative", s: "~<= -1.5"},
+		{name: "Range", s: "~range 10 20"},
+		{name: "Delta", s: "~delta >= 10"},
+		{name: "Delta Range", s: "~delta range 0 5"},
+		{name: "Regex With Spaces", s: "~regex ^Ethernet [0-9]+$"},
+		{name: "No Prefix", s: "> 10", wantErr: true},
+		{name: "Missing Operator", s: "~", wantErr: true},
+		{name: "Unknown Operator", s: "~=~ 10", wantErr: true},
+		{name: "Missing Argument", s: "~>", wantErr: true},
+		{name: "Range One Argument", s: "~range 10", wantErr: true},
+		{name: "Not A Number", s: "~> ten", wantErr: true},
+		{name: "Delta Any", s: "~delta any", wantErr: true},
+		{name: "Invalid Regex", s: "~regex (", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.s); (err != nil) != tt.wantErr {
+				t.Errorf("Parse(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	prev := Number(100)
+	tests := []struct {
+		name    string
+		s       string
+		v       Value
+		prev    *Value
+		wantErr bool
+	}{
+		{name: "Any Number", s: "~any", v: Number(1)},
+		{name: "Any String", s: "~any", v: String("up")},
+		{name: "Greater", s: "~> 10", v: Number(11)},
+		{name: "Not Greater", s: "~> 10", v: Number(10), wantErr: true},
+		{name: "Greater Or Equal", s: "~>= 10", v: Number(10)},
+		{name: "Less", s: "~< 0", v: Number(-1)},
+		{name: "Not Equal", s: "~!= 0", v: Number(0), wantErr: true},
+		{name: "In Range", s: "~range 20 80", v: Number(45.5)},
+		{name: "Out Of Range", s: "~range 20 80", v: Number(81), wantErr: true},
+		{name: "String Compared", s: "~> 10", v: String("11"), wantErr: true},
+		{name: "Regex", s: "~regex ^(UP|TESTING)$", v: String("UP")},
+		{name: "Regex Mismatch", s: "~regex ^UP$", v: String("DOWN"), wantErr: true},
+		{name: "Regex Number", s: "~regex ^1[0-9]$", v: Number(12)},
+		{name: "Delta", s: "~delta >= 10", v: Number(110), prev: &prev},
+		{name: "Delta Too Small", s: "~delta >= 10", v: Number(109), prev: &prev, wantErr: true},
+		{name: "Delta Range", s: "~delta range -5 5", v: Number(96), prev: &prev},
+		{name: "Delta Without Previous", s: "~delta >= 10", v: Number(110), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Match(tt.v, tt.prev); (err != nil) != tt.wantErr {
+				t.Errorf("Match(%s) error = %v, wantErr %v", tt.v, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	defer Reset()
+	tests := []struct {
+		name    string
+		s       string
+		v       Value
+		wantErr bool
+	}{
+		{name: "First Sample", s: "~any", v: Number(100)},
+		{name: "Increased", s: "~delta >= 10", v: Number(120)},
+		{name: "Not Increased Enough", s: "~delta >= 10", v: Number(125), wantErr: true},
+		{name: "Delta From Last Sample", s: "~delta == 5", v: Number(130)},
+	}
+	for _, tt := range tests {
+		p, err := Parse(tt.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Check("counter", tt.v); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Check(%s) error = %v, wantErr %v", tt.name, tt.v, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	defer Reset()
+	Record("counter", Number(100))
+	s := Snapshot()
+	Record("counter", Number(105))
+	Record("other", Number(1))
+	Restore(s)
+	tests := []struct {
+		name   string
+		key    string
+		want   Value
+		wantOK bool
+	}{
+		{name: "Restored Sample", key: "counter", want: Number(100), wantOK: true},
+		{name: "Sample Recorded After Snapshot", key: "other"},
+	}
+	for _, tt := range tests {
+		if got, ok := Last(tt.key); got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: Last(%s) = %s, %v, want %s, %v", tt.name, tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+/*
+Package schema implements validation of the gNMI payloads of Test Vectors against YANG schemas,
+either compiled into Go packages by ygot or read from the YANG modules of a directory
+*/
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/uexampleoc"
+	"github.com/openconfig/ygot/ytypes"
+	"github.com/stratum/testvectors-runner/pkg/logger"
+)
+
+//OpenConfig is the origin of the gNMI paths which are validated, paths without origin are validated too
+const OpenConfig = "openconfig"
+
+//Example is the name of the example schema shipped with ygot, compiled from a snapshot of a few OpenConfig models.
+//It only covers what the example models cover and is meant to try out validation, use LoadDir with the models of the target instead.
+const Example = "ygot-example"
+
+var (
+	log = logger.NewLogger()
+	//schemas are the compiled schemas by name
+	schemas = map[string]func() (*ytypes.Schema, error){Example: uexampleoc.Schema}
+	//schema is the compiled schema used to validate Test Vectors, nil if validation is disabled or the schema is read from YANG modules
+	schema *ytypes.Schema
+	//root is the root schema entry used to validate paths, nil if validation is disabled
+	root *yang.Entry
+)
+
+//Register adds a compiled schema, e.g. the Schema function of a package generated by ygot from vendor-augmented models.
+//The package must be generated without path compression, so that gNMI paths map one to one to schema nodes.
+func Register(name string, f func() (*ytypes.Schema, error)) {
+	schemas[name] = f
+}
+
+//Set selects the schema used to validate Test Vectors by name, an empty name disables validation
+func Set(name string) error {
+	if name == "" {
+		schema, root = nil, nil
+		return nil
+	}
+	f, ok := schemas[name]
+	if !ok {
+		return fmt.Errorf("unknown schema %s, must be one of %s", name, strings.Join(Names(), ", "))
+	}
+	s, err := f()
+	if err != nil {
+		return fmt.Errorf("cannot load schema %s, %v", name, err)
+	}
+	log.Infof("Validating gNMI payloads against schema %s", name)
+	schema, root = s, s.RootSchema()
+	return nil
+}
+
+//LoadDir selects the YANG modules in the directory and its subdirectories as schema used to validate Test Vectors.
+//Imported and included modules are looked up in the same directories. Only paths are validated against YANG modules,
+//values are validated against compiled schemas only.
+func LoadDir(dir string) error {
+	ms := yang.NewModules()
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case info.IsDir():
+			ms.AddPath(path)
+		case filepath.Ext(path) == ".yang":
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cannot read YANG directory %s, %v", dir, err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no YANG modules in directory %s", dir)
+	}
+	for _, f := range files {
+		if err := ms.Read(f); err != nil {
+			return fmt.Errorf("cannot read YANG module %s, %v", f, err)
+		}
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		var msgs []string
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		return fmt.Errorf("cannot process YANG modules in directory %s\n%s", dir, strings.Join(msgs, "\n"))
+	}
+	//the top level nodes of all the modules are children of the root, as in compiled schemas
+	r := &yang.Entry{Name: "device", Kind: yang.DirectoryEntry, Dir: map[string]*yang.Entry{}}
+	for name, m := range ms.Modules {
+		//modules are also stored under their name with revision
+		if name != m.Name {
+			continue
+		}
+		for childName, child := range yang.ToEntry(m).Dir {
+			r.Dir[childName] = child
+		}
+	}
+	log.Infof("Validating gNMI paths against %d YANG modules in directory %s", len(files), dir)
+	schema, root = nil, r
+	return nil
+}
+
+//Get returns the compiled schema used to validate Test Vectors, nil if validation is disabled or the schema is read from YANG modules
+func Get() *ytypes.Schema {
+	return schema
+}
+
+//Enabled returns true if a schema is selected, either compiled or read from YANG modules
+func Enabled() bool {
+	return root != nil
+}
+
+//Names returns the names of the registered schemas in lexical order
+func Names() []string {
+	var names []string
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
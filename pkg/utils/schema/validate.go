@@ -0,0 +1,259 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/openconfig/ygot/ytypes"
+	"github.com/stratum/testvectors-runner/pkg/utils/predicate"
+	tv "github.com/stratum/testvectors/proto/testvector"
+	"google.golang.org/grpc/status"
+)
+
+//path wildcards, as in expected gNMI paths
+const (
+	anyElem  = "*"
+	anyElems = "..."
+)
+
+//Validate checks the paths and values of the gNMI SetRequests, GetRequests, GetResponses, SubscribeRequests and
+//SubscribeResponses of the Test Vector against the selected schema. It returns an error listing every mismatch.
+//Paths are checked up to their first wildcard element, values of predicates and of wildcard paths are not checked.
+//Values are checked only against compiled schemas.
+func Validate(tv1 *tv.TestVector) error {
+	if root == nil {
+		return errors.New("no schema selected")
+	}
+	v := &validator{}
+	for _, tc := range tv1.GetTestCases() {
+		for _, ag := range tc.GetActionGroups() {
+			v.actionGroup(tc.GetTestCaseId()+"/"+ag.GetActionGroupId(), ag)
+		}
+		for _, exp := range tc.GetExpectations() {
+			v.expectation(tc.GetTestCaseId()+"/"+exp.GetExpectationId(), exp)
+		}
+	}
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(v.errs, "\n"))
+}
+
+//getActions returns the actions of the action group regardless of its type
+func getActions(ag *tv.ActionGroup) []*tv.Action {
+	switch {
+	case ag.GetSequentialActionGroup() != nil:
+		return ag.GetSequentialActionGroup().GetActions()
+	case ag.GetParallelActionGroup() != nil:
+		return ag.GetParallelActionGroup().GetActions()
+	case ag.GetRandomizedActionGroup() != nil:
+		return ag.GetRandomizedActionGroup().GetActions()
+	}
+	return nil
+}
+
+//validator collects the mismatches between Test Vector and schema
+type validator struct {
+	errs []string
+}
+
+func (v *validator) errorf(step string, format string, a ...interface{}) {
+	v.errs = append(v.errs, step+": "+fmt.Sprintf(format, a...))
+}
+
+func (v *validator) actionGroup(step string, ag *tv.ActionGroup) {
+	for _, action := range getActions(ag) {
+		req := action.GetConfigOperation().GetGnmiSetRequest()
+		for _, p := range req.GetDelete() {
+			v.path(step, joinPath(req.GetPrefix(), p), true)
+		}
+		for _, u := range append(req.GetReplace(), req.GetUpdate()...) {
+			v.update(step, joinPath(req.GetPrefix(), u.GetPath()), u.GetVal(), true)
+		}
+	}
+}
+
+func (v *validator) expectation(step string, exp *tv.Expectation) {
+	ce := exp.GetConfigExpectation()
+	for _, p := range ce.GetGnmiGetRequest().GetPath() {
+		v.path(step, joinPath(ce.GetGnmiGetRequest().GetPrefix(), p), false)
+	}
+	for _, n := range ce.GetGnmiGetResponse().GetNotification() {
+		v.notification(step, n)
+	}
+	te := exp.GetTelemetryExpectation()
+	sl := te.GetGnmiSubscribeRequest().GetSubscribe()
+	for _, sub := range sl.GetSubscription() {
+		v.path(step, joinPath(sl.GetPrefix(), sub.GetPath()), false)
+	}
+	for _, resp := range te.GetGnmiSubscribeResponse() {
+		v.notification(step, resp.GetUpdate())
+	}
+	if ag := te.GetActionGroup(); ag != nil {
+		v.actionGroup(step, ag)
+	}
+}
+
+func (v *validator) notification(step string, n *gnmi.Notification) {
+	for _, p := range n.GetDelete() {
+		v.path(step, joinPath(n.GetPrefix(), p), false)
+	}
+	for _, u := range n.GetUpdate() {
+		v.update(step, joinPath(n.GetPrefix(), u.GetPath()), u.GetVal(), false)
+	}
+}
+
+//path checks the path, set is true for paths of SetRequests which must be writable.
+//It returns the schema entry of the path, nil if it is not checked up to its end, and whether all its list keys are given without wildcards.
+func (v *validator) path(step string, path *gnmi.Path, set bool) (*yang.Entry, bool) {
+	if origin := path.GetOrigin(); origin != "" && origin != OpenConfig {
+		log.Debugf("Path %s with origin %s is not validated", pathString(path), origin)
+		return nil, false
+	}
+	entry := root
+	complete := true
+	for _, elem := range path.GetElem() {
+		if elem.GetName() == anyElem || elem.GetName() == anyElems {
+			return nil, false
+		}
+		child := childEntry(entry, elem.GetName())
+		if child == nil {
+			v.errorf(step, "unknown element %s of %s in path %s", elem.GetName(), entry.Name, pathString(path))
+			return nil, false
+		}
+		keys := strings.Fields(child.Key)
+		for k, val := range elem.GetKey() {
+			if !contains(keys, k) {
+				v.errorf(step, "%s is not a key of list %s in path %s", k, child.Name, pathString(path))
+				return nil, false
+			}
+			complete = complete && val != anyElem
+		}
+		complete = complete && len(elem.GetKey()) == len(keys)
+		entry = child
+	}
+	if set && entry.ReadOnly() {
+		v.errorf(step, "path %s is read-only", pathString(path))
+		return nil, false
+	}
+	return entry, complete
+}
+
+//update checks the path and the value of an update. Updates of SetRequests must give all the list keys of their path.
+func (v *validator) update(step string, path *gnmi.Path, val *gnmi.TypedValue, set bool) {
+	entry, complete := v.path(step, path, set)
+	if set && entry != nil && !complete {
+		v.errorf(step, "missing list keys in path %s", pathString(path))
+		return
+	}
+	if schema == nil || entry == nil || !complete || val == nil || predicate.IsPredicate(val.GetStringVal()) {
+		return
+	}
+	if err := setValue(stripModules(path), entry, val); err != nil {
+		v.errorf(step, "invalid value %s of path %s: %s", strings.TrimSpace(val.String()), pathString(path), status.Convert(err).Message())
+	}
+}
+
+//setValue sets the value in a new root of the schema and validates the resulting tree
+func setValue(path *gnmi.Path, entry *yang.Entry, val *gnmi.TypedValue) error {
+	root := reflect.New(reflect.TypeOf(schema.Root).Elem()).Interface().(ygot.ValidatedGoStruct)
+	var err error
+	switch {
+	case val.GetJsonIetfVal() != nil:
+		err = setJSON(root, path, entry, val.GetJsonIetfVal())
+	case val.GetJsonVal() != nil:
+		err = setJSON(root, path, entry, val.GetJsonVal())
+	default:
+		err = ytypes.SetNode(schema.RootSchema(), root, path, val, &ytypes.InitMissingElements{})
+	}
+	if err != nil {
+		return err
+	}
+	return root.Validate(&ytypes.LeafrefOptions{IgnoreMissingData: true})
+}
+
+//setJSON unmarshals the JSON encoded value into the node of the path, or into its parent for leaves
+func setJSON(root ygot.ValidatedGoStruct, path *gnmi.Path, entry *yang.Entry, b []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	if (entry.IsLeaf() || entry.IsLeafList()) && len(path.GetElem()) > 0 {
+		path = &gnmi.Path{Elem: path.GetElem()[:len(path.GetElem())-1]}
+	}
+	node, _, err := ytypes.GetOrCreateNode(schema.RootSchema(), root, path)
+	if err != nil {
+		return err
+	}
+	return ytypes.Unmarshal(entry, node, value)
+}
+
+//childEntry returns the child schema entry with the name, looking through choices and cases. Module names are ignored.
+func childEntry(entry *yang.Entry, name string) *yang.Entry {
+	name = stripModule(name)
+	if child, ok := entry.Dir[name]; ok && !child.IsChoice() && !child.IsCase() {
+		return child
+	}
+	for _, child := range entry.Dir {
+		if child.IsChoice() || child.IsCase() {
+			if found := childEntry(child, name); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+//joinPath returns the path appended to the prefix
+func joinPath(prefix, path *gnmi.Path) *gnmi.Path {
+	origin := prefix.GetOrigin()
+	if origin == "" {
+		origin = path.GetOrigin()
+	}
+	elems := append(append([]*gnmi.PathElem{}, prefix.GetElem()...), path.GetElem()...)
+	return &gnmi.Path{Origin: origin, Elem: elems}
+}
+
+//stripModules returns the path without module names in its element names
+func stripModules(path *gnmi.Path) *gnmi.Path {
+	p := &gnmi.Path{}
+	for _, elem := range path.GetElem() {
+		p.Elem = append(p.Elem, &gnmi.PathElem{Name: stripModule(elem.GetName()), Key: elem.GetKey()})
+	}
+	return p
+}
+
+//stripModule returns the name without module name, e.g. "interfaces" for "openconfig-interfaces:interfaces"
+func stripModule(name string) string {
+	return name[strings.LastIndex(name, ":")+1:]
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+//pathString returns the path in the form "/a/b[k=v]"
+func pathString(path *gnmi.Path) string {
+	s, err := ygot.PathToString(path)
+	if err != nil {
+		return path.String()
+	}
+	return s
+}
@@ -0,0 +1,202 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package schema
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	tv "github.com/stratum/testvectors/proto/testvector"
+)
+
+//ifPath returns the path of a node of an interface, e.g. ifPath("eth1", "config", "mtu"), or of all the interfaces if name is empty
+func ifPath(name string, elems ...string) *gnmi.Path {
+	path := &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interfaces"}, {Name: "interface", Key: map[string]string{"name": name}}}}
+	if name == "" {
+		path.Elem[1].Key = nil
+	}
+	for _, e := range elems {
+		path.Elem = append(path.Elem, &gnmi.PathElem{Name: e})
+	}
+	return path
+}
+
+func uintVal(u uint64) *gnmi.TypedValue {
+	return &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: u}}
+}
+
+func stringVal(s string) *gnmi.TypedValue {
+	return &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: s}}
+}
+
+func jsonIetfVal(s string) *gnmi.TypedValue {
+	return &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: []byte(s)}}
+}
+
+//setVector returns a Test Vector with a single SetRequest
+func setVector(req *gnmi.SetRequest) *tv.TestVector {
+	action := &tv.Action{Actions: &tv.Action_ConfigOperation{ConfigOperation: &tv.ConfigOperation{GnmiSetRequest: req}}}
+	ag := &tv.ActionGroup{ActionGroupId: "ag", ActionGroup: &tv.ActionGroup_SequentialActionGroup{
+		SequentialActionGroup: &tv.SequentialActionGroup{Actions: []*tv.Action{action}}}}
+	return &tv.TestVector{TestCases: []*tv.TestCase{{TestCaseId: "tc", ActionGroups: []*tv.ActionGroup{ag}}}}
+}
+
+//getVector returns a Test Vector with a single Get expectation
+func getVector(req *gnmi.GetRequest, updates ...*gnmi.Update) *tv.TestVector {
+	ce := &tv.ConfigExpectation{GnmiGetRequest: req, GnmiGetResponse: &gnmi.GetResponse{Notification: []*gnmi.Notification{{Update: updates}}}}
+	exp := &tv.Expectation{ExpectationId: "exp", Expectations: &tv.Expectation_ConfigExpectation{ConfigExpectation: ce}}
+	return &tv.TestVector{TestCases: []*tv.TestCase{{TestCaseId: "tc", Expectations: []*tv.Expectation{exp}}}}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Set(Example); err != nil {
+		t.Fatal(err)
+	}
+	defer Set("")
+	update := func(path *gnmi.Path, val *gnmi.TypedValue) *gnmi.SetRequest {
+		return &gnmi.SetRequest{Update: []*gnmi.Update{{Path: path, Val: val}}}
+	}
+	tests := []struct {
+		name    string
+		tv      *tv.TestVector
+		wantErr bool
+	}{
+		{name: "Set Leaf", tv: setVector(update(ifPath("eth1", "config", "mtu"), uintVal(1500))), wantErr: false},
+		{name: "Set Unknown Leaf", tv: setVector(update(ifPath("eth1", "config", "mtuu"), uintVal(1500))), wantErr: true},
+		{name: "Set Out Of Range", tv: setVector(update(ifPath("eth1", "config", "mtu"), uintVal(70000))), wantErr: true},
+		{name: "Set Wrong Type", tv: setVector(update(ifPath("eth1", "config", "mtu"), stringVal("1500"))), wantErr: true},
+		{name: "Set State Leaf", tv: setVector(update(ifPath("eth1", "state", "mtu"), uintVal(1500))), wantErr: true},
+		{name: "Set Missing Keys", tv: setVector(update(ifPath("", "config", "mtu"), uintVal(1500))), wantErr: true},
+		{name: "Set Unknown Key", tv: setVector(update(&gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interfaces"}, {Name: "interface", Key: map[string]string{"id": "1"}}}}, nil)), wantErr: true},
+		{
+			name: "Set Prefix And Module Names",
+			tv: setVector(&gnmi.SetRequest{
+				Prefix:  &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "openconfig-interfaces:interfaces"}}},
+				Replace: []*gnmi.Update{{Path: &gnmi.Path{Elem: ifPath("eth1", "config", "enabled").GetElem()[1:]}, Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_BoolVal{BoolVal: true}}}},
+			}),
+			wantErr: false,
+		},
+		{name: "Set JSON Container", tv: setVector(update(ifPath("eth1", "config"), jsonIetfVal(`{"openconfig-interfaces:mtu": 9000, "enabled": false}`))), wantErr: false},
+		{name: "Set JSON Out Of Range", tv: setVector(update(ifPath("eth1", "config"), jsonIetfVal(`{"mtu": 70000}`))), wantErr: true},
+		{name: "Set JSON Unknown Member", tv: setVector(update(ifPath("eth1", "config"), jsonIetfVal(`{"mtuu": 9000}`))), wantErr: true},
+		{name: "Set JSON Leaf", tv: setVector(update(ifPath("eth1", "config", "mtu"), jsonIetfVal(`9000`))), wantErr: false},
+		{name: "Set Pattern Mismatch", tv: setVector(update(&gnmi.Path{Elem: []*gnmi.PathElem{{Name: "system"}, {Name: "config"}, {Name: "hostname"}}}, stringVal("bad host"))), wantErr: true},
+		{name: "Delete List Entry", tv: setVector(&gnmi.SetRequest{Delete: []*gnmi.Path{ifPath("eth1")}}), wantErr: false},
+		{name: "Other Origin", tv: setVector(update(&gnmi.Path{Origin: "cli", Elem: []*gnmi.PathElem{{Name: "show"}}}, stringVal("version"))), wantErr: false},
+		{name: "Get", tv: getVector(&gnmi.GetRequest{Path: []*gnmi.Path{ifPath("*", "state")}}, &gnmi.Update{Path: ifPath("eth1", "state", "oper-status"), Val: stringVal("UP")}), wantErr: false},
+		{name: "Get Request Without Keys", tv: getVector(&gnmi.GetRequest{Path: []*gnmi.Path{ifPath("", "state", "counters")}}), wantErr: false},
+		{name: "Get Request Typo", tv: getVector(&gnmi.GetRequest{Path: []*gnmi.Path{ifPath("eth1", "sate")}}), wantErr: true},
+		{name: "Get Invalid Enum", tv: getVector(nil, &gnmi.Update{Path: ifPath("eth1", "state", "oper-status"), Val: stringVal("RUNNING")}), wantErr: true},
+		{name: "Get Predicate", tv: getVector(nil, &gnmi.Update{Path: ifPath("eth1", "state", "counters", "in-pkts"), Val: stringVal("~delta >= 10")}), wantErr: false},
+		{name: "Get Predicate Typo In Path", tv: getVector(nil, &gnmi.Update{Path: ifPath("eth1", "state", "counter", "in-pkts"), Val: stringVal("~any")}), wantErr: true},
+		{name: "Get Wildcards", tv: getVector(nil, &gnmi.Update{Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interfaces"}, {Name: "..."}, {Name: "in-pkts"}}}, Val: uintVal(1)}), wantErr: false},
+		{name: "Get Key Wildcard Value", tv: getVector(nil, &gnmi.Update{Path: ifPath("*", "state", "counters", "in-pkts"), Val: stringVal("any")}), wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.tv); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	defer Set("")
+	if err := Set("vendor"); err == nil {
+		t.Errorf("Set() of unknown schema succeeded")
+	}
+	if err := Set(Example); err != nil || Get() == nil {
+		t.Errorf("Set() error = %v, schema %v", err, Get())
+	}
+	if err := Set(""); err != nil || Get() != nil || Enabled() {
+		t.Errorf("Set() of empty name error = %v, schema %v", err, Get())
+	}
+}
+
+//yangModules are the YANG modules of TestLoadDir by file name, the types module is imported from a subdirectory
+var yangModules = map[string]string{
+	"example-ports.yang": `module example-ports {
+  namespace "urn:example:ports";
+  prefix "ep";
+  import example-types { prefix "et"; }
+  container ports {
+    list port {
+      key "name";
+      leaf name { type string; }
+      container config {
+        leaf speed { type et:speed; }
+      }
+      container state {
+        config false;
+        leaf speed { type et:speed; }
+      }
+    }
+  }
+}`,
+	"types/example-types.yang": `module example-types {
+  namespace "urn:example:types";
+  prefix "et";
+  typedef speed { type uint32; }
+}`,
+}
+
+func TestLoadDir(t *testing.T) {
+	defer Set("")
+	dir, err := ioutil.TempDir("", "schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := LoadDir(dir); err == nil {
+		t.Errorf("LoadDir() of empty directory succeeded")
+	}
+	for name, data := range yangModules {
+		fileName := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fileName, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := LoadDir(dir); err != nil || !Enabled() || Get() != nil {
+		t.Fatalf("LoadDir() error = %v, enabled %v, schema %v", err, Enabled(), Get())
+	}
+	portPath := func(elems ...string) *gnmi.Path {
+		path := &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "example-ports:ports"}, {Name: "port", Key: map[string]string{"name": "1"}}}}
+		for _, e := range elems {
+			path.Elem = append(path.Elem, &gnmi.PathElem{Name: e})
+		}
+		return path
+	}
+	update := func(path *gnmi.Path, val *gnmi.TypedValue) *gnmi.SetRequest {
+		return &gnmi.SetRequest{Update: []*gnmi.Update{{Path: path, Val: val}}}
+	}
+	tests := []struct {
+		name    string
+		tv      *tv.TestVector
+		wantErr bool
+	}{
+		{name: "Set Leaf", tv: setVector(update(portPath("config", "speed"), uintVal(100))), wantErr: false},
+		{name: "Set Unknown Leaf", tv: setVector(update(portPath("config", "sped"), uintVal(100))), wantErr: true},
+		{name: "Set State Leaf", tv: setVector(update(portPath("state", "speed"), uintVal(100))), wantErr: true},
+		{name: "Values Not Checked", tv: setVector(update(portPath("config", "speed"), stringVal("fast"))), wantErr: false},
+		{name: "Get Request Typo", tv: getVector(&gnmi.GetRequest{Path: []*gnmi.Path{portPath("sate")}}), wantErr: true},
+		{name: "Get Unknown Module", tv: getVector(&gnmi.GetRequest{Path: []*gnmi.Path{ifPath("eth1", "state")}}), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.tv); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+/*
+Package transport implements the transport credentials used to dial the switch under test
+*/
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/stratum/testvectors-runner/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+var (
+	log = logger.NewLogger()
+	//tlsOptions are applied to every gRPC connection to the switch
+	tlsOptions TLSOptions
+)
+
+//TLSOptions stores the TLS settings of the gRPC connections to the switch.
+//Target carries no TLS settings yet, so the options are read from directives of the target file and command line flags
+//and set once for the whole run.
+type TLSOptions struct {
+	//Enabled dials with TLS if true and without TLS if false. If nil, TLS is implied by any of the other options
+	Enabled *bool
+	//CACert is the PEM file of the CA which signed the switch certificate, system roots are used if empty
+	CACert string
+	//ClientCert and ClientKey are the PEM files of the client certificate and key for mutual TLS
+	ClientCert string
+	ClientKey  string
+	//ServerName overrides the server name used to verify the switch certificate
+	ServerName string
+}
+
+//enabled returns true if connections are dialed with TLS
+func (o TLSOptions) enabled() bool {
+	if o.Enabled != nil {
+		return *o.Enabled
+	}
+	return o.CACert != "" || o.ClientCert != "" || o.ClientKey != "" || o.ServerName != ""
+}
+
+//Override returns the options with the fields which are set in overrides replaced by their values.
+//TLS is enabled if overrides imply it without setting Enabled, so that any of the overriding options turns TLS on.
+func (o TLSOptions) Override(overrides TLSOptions) TLSOptions {
+	if overrides.Enabled != nil {
+		o.Enabled = overrides.Enabled
+	} else if overrides.enabled() {
+		enabled := true
+		o.Enabled = &enabled
+	}
+	if overrides.CACert != "" {
+		o.CACert = overrides.CACert
+	}
+	if overrides.ClientCert != "" {
+		o.ClientCert = overrides.ClientCert
+	}
+	if overrides.ClientKey != "" {
+		o.ClientKey = overrides.ClientKey
+	}
+	if overrides.ServerName != "" {
+		o.ServerName = overrides.ServerName
+	}
+	return o
+}
+
+//SetTLSOptions sets the TLS options used by all connections to the switch
+func SetTLSOptions(opts TLSOptions) {
+	log.Debugf("TLS options: %+v", opts)
+	tlsOptions = opts
+}
+
+//GetTLSOptions returns the TLS options used by all connections to the switch
+func GetTLSOptions() TLSOptions {
+	return tlsOptions
+}
+
+//DialOption returns the gRPC dial option with the transport credentials built from the TLS options.
+//If TLS is not enabled it returns an insecure dial option.
+func DialOption() (grpc.DialOption, error) {
+	if !tlsOptions.enabled() {
+		return grpc.WithInsecure(), nil
+	}
+	config, err := newTLSConfig(tlsOptions)
+	if err != nil {
+		return nil, err
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(config)), nil
+}
+
+//newTLSConfig builds the TLS client config from the TLS options
+func newTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{ServerName: opts.ServerName}
+	if opts.CACert != "" {
+		pem, err := ioutil.ReadFile(opts.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA certificate %s, %v", opts.CACert, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in CA certificate %s", opts.CACert)
+		}
+		config.RootCAs = pool
+	}
+	switch {
+	case opts.ClientCert != "" && opts.ClientKey != "":
+		cert, err := tls.LoadX509KeyPair(opts.ClientCert, opts.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate %s and key %s, %v", opts.ClientCert, opts.ClientKey, err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	case opts.ClientCert != "" || opts.ClientKey != "":
+		return nil, errors.New("client certificate and key must be specified together")
+	}
+	return config, nil
+}
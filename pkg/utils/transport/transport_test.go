@@ -0,0 +1,279 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package transport
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+//testPKI stores the file names of a self-signed CA and the certificates and keys it signed
+type testPKI struct {
+	caCert, serverCert, serverKey, clientCert, clientKey string
+}
+
+//newTestPKI generates a self-signed CA, a server certificate for localhost and 127.0.0.1 and a client certificate under dir
+func newTestPKI(t *testing.T, dir string) testPKI {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "tvrunner test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Error creating CA certificate: %v", err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+	pki := testPKI{caCert: filepath.Join(dir, "ca.pem")}
+	writePEM(t, pki.caCert, "CERTIFICATE", caDER)
+
+	issue := func(serial int64, name string, usage x509.ExtKeyUsage) (string, string) {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     []string{name},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("Error creating certificate for %s: %v", name, err)
+		}
+		keyDER, _ := x509.MarshalECPrivateKey(key)
+		certFile, keyFile := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+".key")
+		writePEM(t, certFile, "CERTIFICATE", der)
+		writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+		return certFile, keyFile
+	}
+	pki.serverCert, pki.serverKey = issue(2, "localhost", x509.ExtKeyUsageServerAuth)
+	pki.clientCert, pki.clientKey = issue(3, "tvrunner", x509.ExtKeyUsageClientAuth)
+	return pki
+}
+
+func writePEM(t *testing.T, fileName, blockType string, der []byte) {
+	if err := ioutil.WriteFile(fileName, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("Error writing %s: %v", fileName, err)
+	}
+}
+
+//startServer starts a gRPC health server with TLS on a local port, which requires client certificates if mutual is true.
+//It returns the server address and a function to stop the server.
+func startServer(t *testing.T, pki testPKI, mutual bool) (string, func()) {
+	cert, err := tls.LoadX509KeyPair(pki.serverCert, pki.serverKey)
+	if err != nil {
+		t.Fatalf("Error loading server certificate: %v", err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if mutual {
+		caPEM, _ := ioutil.ReadFile(pki.caCert)
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(caPEM)
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(config)))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(lis)
+	return lis.Addr().String(), server.Stop
+}
+
+//check dials address with the TLS options and returns true if the health check succeeds
+func check(t *testing.T, address string, opts TLSOptions) bool {
+	SetTLSOptions(opts)
+	dialOpt, err := DialOption()
+	if err != nil {
+		t.Logf("DialOption() error: %v", err)
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, address, dialOpt)
+	if err != nil {
+		t.Logf("DialContext() error: %v", err)
+		return false
+	}
+	defer conn.Close()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Logf("Check() error: %v", err)
+	}
+	return err == nil
+}
+
+func TestDialOption(t *testing.T) {
+	defer SetTLSOptions(TLSOptions{})
+	dir, err := ioutil.TempDir("", "transport")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	pkiDir, otherDir := filepath.Join(dir, "pki"), filepath.Join(dir, "other")
+	os.Mkdir(pkiDir, 0700)
+	os.Mkdir(otherDir, 0700)
+	pki := newTestPKI(t, pkiDir)
+	//otherPKI has its own CA which is unknown to the servers and clients using pki
+	otherPKI := newTestPKI(t, otherDir)
+
+	tlsAddress, stopTLS := startServer(t, pki, false)
+	defer stopTLS()
+	mtlsAddress, stopMTLS := startServer(t, pki, true)
+	defer stopMTLS()
+
+	tests := []struct {
+		name    string
+		address string
+		opts    TLSOptions
+		want    bool
+	}{
+		{
+			name:    "Insecure to TLS server",
+			address: tlsAddress,
+			opts:    TLSOptions{},
+			want:    false,
+		},
+		{
+			name:    "TLS",
+			address: tlsAddress,
+			opts:    TLSOptions{CACert: pki.caCert},
+			want:    true,
+		},
+		{
+			name:    "TLS with server name override",
+			address: tlsAddress,
+			opts:    TLSOptions{CACert: pki.caCert, ServerName: "localhost"},
+			want:    true,
+		},
+		{
+			name:    "TLS with wrong server name",
+			address: tlsAddress,
+			opts:    TLSOptions{CACert: pki.caCert, ServerName: "switch"},
+			want:    false,
+		},
+		{
+			name:    "TLS with unknown CA",
+			address: tlsAddress,
+			opts:    TLSOptions{CACert: otherPKI.caCert},
+			want:    false,
+		},
+		{
+			name:    "TLS with missing CA file",
+			address: tlsAddress,
+			opts:    TLSOptions{CACert: filepath.Join(dir, "missing.pem")},
+			want:    false,
+		},
+		{
+			name:    "Mutual TLS",
+			address: mtlsAddress,
+			opts:    TLSOptions{CACert: pki.caCert, ClientCert: pki.clientCert, ClientKey: pki.clientKey},
+			want:    true,
+		},
+		{
+			name:    "Mutual TLS without client certificate",
+			address: mtlsAddress,
+			opts:    TLSOptions{CACert: pki.caCert},
+			want:    false,
+		},
+		{
+			name:    "Mutual TLS with client certificate from unknown CA",
+			address: mtlsAddress,
+			opts:    TLSOptions{CACert: pki.caCert, ClientCert: otherPKI.clientCert, ClientKey: otherPKI.clientKey},
+			want:    false,
+		},
+		{
+			name:    "Client certificate without key",
+			address: mtlsAddress,
+			opts:    TLSOptions{CACert: pki.caCert, ClientCert: pki.clientCert},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := check(t, tt.address, tt.opts); got != tt.want {
+				t.Errorf("check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverride(t *testing.T) {
+	on, off := true, false
+	file := TLSOptions{CACert: "ca.pem", ClientCert: "client.pem", ClientKey: "client.key", ServerName: "switch"}
+	disabledFile := file
+	disabledFile.Enabled = &off
+	tests := []struct {
+		name        string
+		opts        TLSOptions
+		overrides   TLSOptions
+		want        TLSOptions
+		wantEnabled bool
+	}{
+		{name: "No overrides", opts: file, overrides: TLSOptions{}, want: file, wantEnabled: true},
+		{name: "No options", opts: TLSOptions{}, overrides: file, want: file, wantEnabled: true},
+		{name: "Enabled", opts: TLSOptions{}, overrides: TLSOptions{Enabled: &on}, want: TLSOptions{}, wantEnabled: true},
+		{name: "Enabled not cleared", opts: TLSOptions{Enabled: &on}, overrides: TLSOptions{}, want: TLSOptions{}, wantEnabled: true},
+		{name: "Disabled by directive", opts: disabledFile, overrides: TLSOptions{}, want: file, wantEnabled: false},
+		{name: "Disabled by flag", opts: file, overrides: TLSOptions{Enabled: &off}, want: file, wantEnabled: false},
+		{name: "Enabled by flag", opts: disabledFile, overrides: TLSOptions{Enabled: &on}, want: file, wantEnabled: true},
+		{
+			name:        "Implied by flag",
+			opts:        disabledFile,
+			overrides:   TLSOptions{CACert: "other-ca.pem"},
+			want:        TLSOptions{CACert: "other-ca.pem", ClientCert: "client.pem", ClientKey: "client.key", ServerName: "switch"},
+			wantEnabled: true,
+		},
+		{
+			name:        "Some overrides",
+			opts:        file,
+			overrides:   TLSOptions{CACert: "other-ca.pem", ServerName: "localhost"},
+			want:        TLSOptions{CACert: "other-ca.pem", ClientCert: "client.pem", ClientKey: "client.key", ServerName: "localhost"},
+			wantEnabled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.opts.Override(tt.overrides)
+			if got.enabled() != tt.wantEnabled {
+				t.Errorf("Override().enabled() = %v, want %v", got.enabled(), tt.wantEnabled)
+			}
+			got.Enabled = nil
+			if got != tt.want {
+				t.Errorf("Override() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
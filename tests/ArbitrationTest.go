@@ -0,0 +1,81 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tests
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+
+	"github.com/stratum/testvectors-runner/pkg/framework/p4rt"
+	"github.com/stratum/testvectors-runner/pkg/test/setup"
+	"github.com/stratum/testvectors-runner/pkg/test/teardown"
+	"github.com/stretchr/testify/assert"
+)
+
+// ArbitrationTest connects a second controller as backup, checks that it cannot write, lets it take over as primary
+// with a higher election ID and checks that the runner's controller gets primary again when the second controller leaves.
+func (st Test) ArbitrationTest(t *testing.T) {
+	setup.TestCase()
+
+	c := p4rt.GetDefaultController()
+	lowerID := &v1.Uint128{High: 0, Low: 1}
+	higherID := &v1.Uint128{High: c.ElectionID.GetHigh() + 1, Low: c.ElectionID.GetLow()}
+	//arbitration returns the arbitration update expected by a controller of the device and role of the default controller
+	arbitration := func(electionID *v1.Uint128, code codes.Code) []*v1.MasterArbitrationUpdate {
+		return []*v1.MasterArbitrationUpdate{{DeviceId: c.DeviceID, Role: c.Role, ElectionId: electionID, Status: &status.Status{Code: int32(code)}}}
+	}
+
+	// Make sure the runner's controller is primary by writing with its identity
+	request := &v1.WriteRequest{}
+	if err := proto.UnmarshalText(writeRequest, request); err != nil {
+		log.Fatalf("Error parsing proto message of type %T\n%s", request, err)
+	}
+	request.DeviceId, request.ElectionId, request.RoleId = c.DeviceID, c.ElectionID, c.Role.GetId()
+	result := p4rt.ProcessP4WriteRequest(request, nil)
+	assert.True(t, result.Passed(), "Write request failed")
+	request.GetUpdates()[0].Type = v1.Update_DELETE
+	result = p4rt.ProcessP4WriteRequest(request, nil)
+	assert.True(t, result.Passed(), "Write request failed")
+
+	// Connect a second controller with a lower election ID, it is told about the primary election ID
+	result = p4rt.OpenSession("second", p4rt.Controller{DeviceID: c.DeviceID, ElectionID: lowerID, Role: c.Role})
+	assert.True(t, result.Passed(), "Opening session failed")
+	result = p4rt.ProcessArbitrationUpdate("second", arbitration(c.ElectionID, codes.AlreadyExists))
+	assert.True(t, result.Passed(), "Backup arbitration update not received")
+
+	// Writes of the backup controller are rejected
+	request.GetUpdates()[0].Type = v1.Update_INSERT
+	result = p4rt.ProcessSessionWriteRequest("second", request, nil)
+	assert.False(t, result.Passed(), "Write request of backup controller succeeded")
+
+	// The second controller takes over with a higher election ID, the runner's controller is demoted
+	result = p4rt.ArbitrateSession("second", higherID)
+	assert.True(t, result.Passed(), "Arbitrating session failed")
+	result = p4rt.ProcessArbitrationUpdate("second", arbitration(higherID, codes.OK))
+	assert.True(t, result.Passed(), "Primary arbitration update not received")
+	result = p4rt.ProcessArbitrationUpdate("", arbitration(higherID, codes.AlreadyExists))
+	assert.True(t, result.Passed(), "Demotion of runner's controller not received")
+
+	// Writes of the new primary controller are accepted
+	result = p4rt.ProcessSessionWriteRequest("second", request, nil)
+	assert.True(t, result.Passed(), "Write request of primary controller failed")
+	request.GetUpdates()[0].Type = v1.Update_DELETE
+	result = p4rt.ProcessSessionWriteRequest("second", request, nil)
+	assert.True(t, result.Passed(), "Write request of primary controller failed")
+
+	// The runner's controller gets primary again when the second controller leaves
+	result = p4rt.CloseSession("second")
+	assert.True(t, result.Passed(), "Closing session failed")
+	result = p4rt.ProcessArbitrationUpdate("", arbitration(c.ElectionID, codes.OK))
+	assert.True(t, result.Passed(), "Primary arbitration update of runner's controller not received")
+
+	assert.True(t, teardown.TestCase().Passed(), "Test case teardown failed")
+}
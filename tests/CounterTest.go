@@ -0,0 +1,67 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tests
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+
+	"github.com/stratum/testvectors-runner/pkg/framework/dataplane"
+	"github.com/stratum/testvectors-runner/pkg/framework/p4rt"
+	"github.com/stratum/testvectors-runner/pkg/test/setup"
+	"github.com/stratum/testvectors-runner/pkg/test/teardown"
+	"github.com/stretchr/testify/assert"
+)
+
+// ACLDirectCounterTest redirects packet-outs to a port via an ACL rule and verifies that the direct counter of the rule counts them.
+// It requires a pipeline whose ACL table has a direct counter.
+func (st Test) ACLDirectCounterTest(t *testing.T) {
+	setup.TestCase()
+
+	// Build write request
+	request := &v1.WriteRequest{}
+	if err := proto.UnmarshalText(writeRequest, request); err != nil {
+		log.Fatalf("Error parsing proto message of type %T\n%s", request, err)
+	}
+	// Insert table entry
+	result := p4rt.ProcessP4WriteRequest(request, nil)
+	assert.True(t, result.Passed(), "Write request failed")
+
+	// Read the direct counter of the entry to record the counts before sending packets
+	counter := &v1.Entity{Entity: &v1.Entity_DirectCounterEntry{DirectCounterEntry: &v1.DirectCounterEntry{
+		TableEntry: request.GetUpdates()[0].GetEntity().GetTableEntry(),
+	}}}
+	result = p4rt.ProcessCounterRead(counter, "~any", "~any")
+	assert.True(t, result.Passed(), "Counter read failed")
+
+	// Build packet-out
+	pktOut := &v1.PacketOut{}
+	if err := proto.UnmarshalText(pktOutToPort0, pktOut); err != nil {
+		log.Fatalf("Error parsing proto message of type %T\n%s", pktOut, err)
+	}
+	// Send 3 packet-outs
+	for i := 0; i < 3; i++ {
+		result = p4rt.ProcessPacketOutOperation(pktOut)
+		assert.True(t, result.Passed(), "PacketOut operation failed")
+	}
+	// Check if we received the packets from data plane port 2
+	result = dataplane.ProcessTrafficExpectation([][]byte{[]byte(payload), []byte(payload), []byte(payload)}, []uint32{2})
+	assert.True(t, result.Passed(), "Packets not received on port 2")
+
+	// Check if the counter increased by 3 packets and their bytes
+	result = p4rt.ProcessCounterRead(counter, "~delta == 3", "~delta >= 180")
+	assert.True(t, result.Passed(), "Counter did not count the packets")
+
+	// Delete table entry
+	request.GetUpdates()[0].Type = v1.Update_DELETE
+	result = p4rt.ProcessP4WriteRequest(request, nil)
+	assert.True(t, result.Passed(), "Write request failed")
+
+	assert.True(t, teardown.TestCase().Passed(), "Test case teardown failed")
+}
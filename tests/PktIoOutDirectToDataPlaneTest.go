@@ -107,17 +107,17 @@ func (st Test) PktIoOutDirectToDataPlaneTest(t *testing.T) {
 	}
 	// Send packet-out
 	result := p4rt.ProcessPacketOutOperation(pktOut)
-	assert.True(t, result, "PacketOut operation failed")
+	assert.True(t, result.Passed(), "PacketOut operation failed")
 
 	// Check if we received packets from data plane port 1
 	result = dataplane.ProcessTrafficExpectation([][]byte{[]byte(payload)}, []uint32{1})
-	assert.True(t, result, "Packet not received on port 1")
+	assert.True(t, result.Passed(), "Packet not received on port 1")
 	// Check if we received no packets from data plane port 2
 	result = dataplane.ProcessTrafficExpectation([][]byte{}, []uint32{2})
-	assert.True(t, result, "Unexpected packet received on port 2")
+	assert.True(t, result.Passed(), "Unexpected packet received on port 2")
 
 	// Stop packet capturing
-	teardown.TestCase()
+	assert.True(t, teardown.TestCase().Passed(), "Test case teardown failed")
 }
 
 // PktIoOutToIngressPipelineACLRedirectToPortTest sends packets out through the ingress pipeline and redirect it to a port via an ACL rule.
@@ -133,7 +133,7 @@ func (st Test) PktIoOutToIngressPipelineACLRedirectToPortTest(t *testing.T) {
 
 	// Insert table entry
 	result := p4rt.ProcessP4WriteRequest(request, nil)
-	assert.True(t, result, "Write request failed")
+	assert.True(t, result.Passed(), "Write request failed")
 
 	// Build packet-out
 	pktOut := &v1.PacketOut{}
@@ -142,14 +142,14 @@ func (st Test) PktIoOutToIngressPipelineACLRedirectToPortTest(t *testing.T) {
 	}
 	// Send packet-out
 	result = p4rt.ProcessPacketOutOperation(pktOut)
-	assert.True(t, result, "PacketOut operation failed")
+	assert.True(t, result.Passed(), "PacketOut operation failed")
 
 	// Check if we received packets from data plane port 2
 	result = dataplane.ProcessTrafficExpectation([][]byte{[]byte(payload)}, []uint32{2})
-	assert.True(t, result, "Packet not received on port 1")
+	assert.True(t, result.Passed(), "Packet not received on port 1")
 	// Check if we received no packets from data plane port 1
 	result = dataplane.ProcessTrafficExpectation([][]byte{}, []uint32{1})
-	assert.True(t, result, "Unexpected packet received on port 2")
+	assert.True(t, result.Passed(), "Unexpected packet received on port 2")
 
 	// Build delete write request
 	request = &v1.WriteRequest{}
@@ -159,7 +159,7 @@ func (st Test) PktIoOutToIngressPipelineACLRedirectToPortTest(t *testing.T) {
 
 	// Delete table entry
 	result = p4rt.ProcessP4WriteRequest(request, nil)
-	assert.True(t, result, "Write request failed")
+	assert.True(t, result.Passed(), "Write request failed")
 	// Stop packet capturing
-	teardown.TestCase()
+	assert.True(t, teardown.TestCase().Passed(), "Test case teardown failed")
 }
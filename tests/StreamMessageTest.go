@@ -0,0 +1,167 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tests
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+
+	"github.com/golang/protobuf/proto"
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	"google.golang.org/grpc/codes"
+
+	"github.com/stratum/testvectors-runner/pkg/framework/dataplane"
+	"github.com/stratum/testvectors-runner/pkg/framework/p4rt"
+	"github.com/stratum/testvectors-runner/pkg/test/setup"
+	"github.com/stratum/testvectors-runner/pkg/test/teardown"
+	"github.com/stratum/testvectors-runner/pkg/utils/p4info"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	pktOutUnknownMetadata = `
+payload: "\x3c\xfd\xfe\xa8\xea\x31\x00\x00\x00\xc0\x1a\x10\x08\x00"
+metadata: <
+  metadata_id: 100
+  value: "\000\001"
+>`
+	idleTimeoutWriteRequest = `
+device_id: 1
+election_id: <
+  low: 4
+>
+updates: <
+  type: INSERT
+  entity: <
+    table_entry: <
+      table_id: {{p4Table "ingress.punt.punt_table"}}
+      priority: 10
+      match: <
+        field_id: {{p4MatchField "ingress.punt.punt_table" "hdr.ethernet.ether_type"}}
+        ternary: <
+          value: "\010\006"
+          mask: "\377\377"
+        >
+      >
+      action: <
+        action: <
+          action_id: {{p4Action "ingress.punt.set_egress_port"}}
+          params: <
+            param_id: {{p4Param "ingress.punt.set_egress_port" "port"}}
+            value: "\000\002"
+          >
+        >
+      >
+      idle_timeout_ns: 1000000000
+    >
+  >
+>`
+	digestList = `
+digest_id: 401827287
+data: <
+  struct: <
+    members: <
+      bitstring: "\000\000\000\300\032\020"
+    >
+    members: <
+      bitstring: "\000\001"
+    >
+  >
+>`
+)
+
+// StreamErrorPacketOutTest sends a packet-out with unknown metadata and verifies that the switch reports it with a stream error.
+func (st Test) StreamErrorPacketOutTest(t *testing.T) {
+	// Clear stream messages of previous tests
+	setup.TestCase()
+
+	// Build packet-out
+	pktOut := &v1.PacketOut{}
+	if err := proto.UnmarshalText(pktOutUnknownMetadata, pktOut); err != nil {
+		log.Fatalf("Error parsing proto message of type %T\n%s", pktOut, err)
+	}
+	// Send packet-out
+	result := p4rt.ProcessPacketOutOperation(pktOut)
+	assert.True(t, result.Passed(), "PacketOut operation failed")
+
+	// Check if the rejected packet-out is reported, the error message is not compared
+	streamError := &v1.StreamError{
+		CanonicalCode: int32(codes.InvalidArgument),
+		Details:       &v1.StreamError_PacketOut{PacketOut: &v1.PacketOutError{PacketOut: pktOut}},
+	}
+	result = p4rt.ProcessStreamError([]*v1.StreamError{streamError})
+	assert.True(t, result.Passed(), "Stream error not received")
+
+	assert.True(t, teardown.TestCase().Passed(), "Test case teardown failed")
+}
+
+// IdleTimeoutNotificationTest inserts a table entry with an idle timeout and verifies that the switch notifies its expiry.
+// It requires a pipeline whose ACL table supports idle timeouts, and the P4Info of the pipeline given with --p4info.
+func (st Test) IdleTimeoutNotificationTest(t *testing.T) {
+	setup.TestCase()
+
+	// Build write request, resolving the table and action names with the P4Info
+	text, err := resolveP4Names(idleTimeoutWriteRequest)
+	if err != nil {
+		t.Fatalf("Error resolving P4 names of write request, run with --p4info\n%s", err)
+	}
+	request := &v1.WriteRequest{}
+	if err := proto.UnmarshalText(text, request); err != nil {
+		log.Fatalf("Error parsing proto message of type %T\n%s", request, err)
+	}
+	// Insert table entry
+	result := p4rt.ProcessP4WriteRequest(request, nil)
+	assert.True(t, result.Passed(), "Write request failed")
+
+	// Check if the entry expires within the stream message timeout, the notification timestamp is not compared
+	entry := proto.Clone(request.GetUpdates()[0].GetEntity().GetTableEntry()).(*v1.TableEntry)
+	notification := &v1.IdleTimeoutNotification{TableEntry: []*v1.TableEntry{entry}}
+	result = p4rt.ProcessIdleTimeoutNotification([]*v1.IdleTimeoutNotification{notification})
+	assert.True(t, result.Passed(), "Idle timeout notification not received")
+
+	// Delete table entry
+	request.GetUpdates()[0].Type = v1.Update_DELETE
+	result = p4rt.ProcessP4WriteRequest(request, nil)
+	assert.True(t, result.Passed(), "Write request failed")
+
+	assert.True(t, teardown.TestCase().Passed(), "Test case teardown failed")
+}
+
+// DigestListTest sends a packet from an unknown source MAC address and verifies the digest list the switch sends to learn it.
+// It requires a pipeline which generates a digest of source MAC address and ingress port for unknown source MAC addresses.
+func (st Test) DigestListTest(t *testing.T) {
+	setup.TestCase()
+
+	// Send packet to port 1
+	result := dataplane.ProcessTrafficStimulus([][]byte{[]byte(payload)}, 1)
+	assert.True(t, result.Passed(), "Traffic stimulus failed")
+
+	// Build expected digest list with the source MAC address of the packet and port 1, its list ID and timestamp are not compared
+	digest := &v1.DigestList{}
+	if err := proto.UnmarshalText(digestList, digest); err != nil {
+		log.Fatalf("Error parsing proto message of type %T\n%s", digest, err)
+	}
+	// Check if the digest list is received, it is acknowledged automatically
+	result = p4rt.ProcessDigestList([]*v1.DigestList{digest})
+	assert.True(t, result.Passed(), "Digest list not received")
+
+	assert.True(t, teardown.TestCase().Passed(), "Test case teardown failed")
+}
+
+// resolveP4Names resolves the P4 names of a text proto to IDs with the loaded P4Info, as in Test Vector files.
+func resolveP4Names(text string) (string, error) {
+	t, err := template.New("").Funcs(p4info.FuncMap()).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, nil); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2019-present Open Networking Foundation
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tests
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	v1 "github.com/p4lang/p4runtime/go/p4/v1"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+
+	"github.com/stratum/testvectors-runner/pkg/framework/p4rt"
+	"github.com/stratum/testvectors-runner/pkg/test/setup"
+	"github.com/stratum/testvectors-runner/pkg/test/teardown"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	//batchInsertStatus expects the first update of a batch to succeed and the second one to be rejected as duplicate
+	batchInsertStatus = `
+code: 2
+details: <
+  [type.googleapis.com/p4.v1.Error]: < canonical_code: 0 >
+>
+details: <
+  [type.googleapis.com/p4.v1.Error]: < canonical_code: 6 >
+>`
+)
+
+// WriteErrorTest inserts a table entry and then a batch with a new entry and the same entry again.
+// It verifies that the batch fails with one p4.v1.Error per update, only the duplicate entry being rejected.
+func (st Test) WriteErrorTest(t *testing.T) {
+	setup.TestCase()
+
+	// Build write request
+	request := &v1.WriteRequest{}
+	if err := proto.UnmarshalText(writeRequest, request); err != nil {
+		log.Fatalf("Error parsing proto message of type %T\n%s", request, err)
+	}
+	// Insert table entry, a nil status expects the write to succeed
+	result := p4rt.ProcessP4WriteRequestWithStatus(request, nil)
+	assert.True(t, result.Passed(), "Write request failed")
+
+	// Build batch with a new entry, matching another ether type, followed by the entry inserted above
+	update := request.GetUpdates()[0]
+	newUpdate := proto.Clone(update).(*v1.Update)
+	newUpdate.GetEntity().GetTableEntry().GetMatch()[0].GetTernary().Value = []byte("\x86\xdd")
+	batch := proto.Clone(request).(*v1.WriteRequest)
+	batch.Updates = []*v1.Update{newUpdate, update}
+	expected := &spb.Status{}
+	if err := proto.UnmarshalText(batchInsertStatus, expected); err != nil {
+		log.Fatalf("Error parsing proto message of type %T\n%s", expected, err)
+	}
+	// Insert batch, which is expected to fail for the duplicate entry only
+	result = p4rt.ProcessP4WriteRequestWithStatus(batch, expected)
+	assert.True(t, result.Passed(), "Write request did not fail as expected")
+
+	// Delete both table entries
+	for _, u := range batch.GetUpdates() {
+		u.Type = v1.Update_DELETE
+	}
+	result = p4rt.ProcessP4WriteRequestWithStatus(batch, nil)
+	assert.True(t, result.Passed(), "Write request failed")
+
+	assert.True(t, teardown.TestCase().Passed(), "Test case teardown failed")
+}
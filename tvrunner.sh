@@ -25,14 +25,66 @@ Usage: $0
     [--tv-dir <directory>]              run all the testvectors from provided directory
     [--template-config <filename>]      use the provided config file to convert templates to test vectors
     [--tv-name <regex>]                 run all the testvectors matching provided regular expression
+    [--p4info <filename>]               resolve P4 names in testvectors using the provided P4Info file
+                                        default is empty which uses the P4Info of the pipeline config testvector
     [--dp-mode <mode>]                  run the testvectors in provided mode
                                         default is direct; acceptable modes are <direct, loopbak>
     [--match-type <type>]               match packets based on the provided match-type
                                         default is exact; acceptable modes <exact, in>
+    [--pkt-out-port-metadata <id>:<bitwidth>]   in loopback mode, send the egress port in provided packet-out metadata
+                                        default is derived from P4Info egress_port metadata, or 1:16 without P4Info
+    [--pkt-in-port-metadata <id>:<bitwidth>]    in loopback mode, read the ingress port from provided packet-in metadata
+                                        default is derived from P4Info ingress_port metadata, or 1:16 without P4Info
+    [--pkt-out-padding <id>:<bitwidth>,...]     in loopback mode, set provided packet-out metadata to zero
+                                        default is the other packet_out metadata in P4Info; use none for no padding
+    [--pkt-in-padding <id>:<bitwidth>,...]      in loopback mode, expect provided packet-in metadata to be zero
+                                        default is the other packet_in metadata in P4Info; use none for no padding
+    [--ignore-metadata-ids <id>,...]    skip provided packet-in metadata IDs when verifying packet-ins
+                                        default is empty which compares all metadata
+    [--port-mode <mode>]                bring ports up or down using provided mode
+                                        default is gnmi; acceptable modes <gnmi, link>
+                                        link mode runs the container with the NET_ADMIN capability
+    [--alarm-mode <mode>]               raise alarms using provided mode
+                                        default is gnmi; acceptable modes <gnmi, exec>
+    [--alarm-set-leaf <path>]           in gnmi alarm mode, set provided leaf under the alarm path to true
+                                        default is empty which sets the alarm path itself
+    [--tls[=false]]                     connect to the target with TLS, implied by the other TLS arguments
+                                        --tls=false connects without TLS even if the target file enables it
+    [--ca-cert <filename>]              verify the target certificate with the provided CA certificate
+                                        default is empty which uses the system CA certificates
+    [--client-cert <filename>]          use the provided client certificate for mutual TLS
+    [--client-key <filename>]           use the provided client key for mutual TLS
+    [--tls-server-name <name>]          verify the target certificate against the provided server name
+    [--failure-policy <policy>]         skip expectations of test cases whose actions failed, continue and report all failures
+                                        or stop each test case at its first failure
+                                        default is skip-expectations; acceptable policies are <skip-expectations, continue, stop>
+    [--p4rt-reconnect-timeout <duration>]   try reopening a broken P4Runtime stream channel for provided duration
+                                        default is 1m; 0 disables reconnecting
+    [--p4rt-cleanup]                    after each test case delete P4Runtime entities which are not in the baseline
+                                        and reset meters and counters
     [--log-level <level>]               run tvrunner binary with provided log level
                                         default is warn; acceptable levels are <panic, fatal, error, warn, info, debug>
     [--log-dir <directory>]             save logs to provided directory
                                         default is /tmp
+    [--random-seed <seed>]              shuffle randomized action groups using provided seed
+                                        default is 0 which generates and logs a new seed
+    [--parallel-max-concurrency <num>]  limit the number of actions running at once in parallel action groups
+                                        default is 0 which means no limit
+    [--parallel-barrier]                release all actions in parallel action groups at the same instant
+    [--parallel-start-skew <duration>]  start the i-th action of parallel action groups i times provided duration after the start of the group
+                                        default is 0s; e.g. 10ms
+    [--poll-interval <duration>]        re-issue requests of polled expectations at provided interval
+                                        default is 1s
+    [--poll-deadline <duration>]        poll gNMI Get, P4Runtime Read and pipeline config expectations until they match or provided deadline expires
+                                        default is 0s which sends each request once
+    [--gnmi-match-type <type>]          match gNMI subscription responses using provided type
+                                        default is exact; acceptable types are <exact, in>
+    [--gnmi-sample-tolerance <ratio>]   accept samples of gNMI SAMPLE subscriptions deviating from the sample interval by provided ratio
+                                        default is 0.2
+    [--gnmi-schema <name>]              validate gNMI paths and values in testvectors against provided compiled schema before running them
+                                        default is empty which disables validation; acceptable schemas are <ygot-example>
+    [--gnmi-yang-dir <directory>]       validate gNMI paths in testvectors against the YANG modules in provided directory before running them
+                                        default is empty which disables validation
 
     ***docker arguments***
     [--pull]                            get latest docker image
@@ -98,6 +150,78 @@ do
         MATCH_TYPE="$2"
         shift 2
         ;;
+    --pkt-out-port-metadata)
+        PKT_OUT_PORT_METADATA="$2"
+        shift 2
+        ;;
+    --pkt-in-port-metadata)
+        PKT_IN_PORT_METADATA="$2"
+        shift 2
+        ;;
+    --pkt-out-padding)
+        PKT_OUT_PADDING="$2"
+        shift 2
+        ;;
+    --pkt-in-padding)
+        PKT_IN_PADDING="$2"
+        shift 2
+        ;;
+    --ignore-metadata-ids)
+        IGNORE_METADATA_IDS="$2"
+        shift 2
+        ;;
+    --port-mode)
+        PORT_MODE="$2"
+        shift 2
+        ;;
+    --alarm-mode)
+        ALARM_MODE="$2"
+        shift 2
+        ;;
+    --alarm-set-leaf)
+        ALARM_SET_LEAF="$2"
+        shift 2
+        ;;
+    --failure-policy)
+        FAILURE_POLICY="$2"
+        shift 2
+        ;;
+    --p4rt-reconnect-timeout)
+        P4RT_RECONNECT_TIMEOUT="$2"
+        shift 2
+        ;;
+    --p4rt-cleanup)
+        P4RT_CLEANUP=YES
+        shift
+        ;;
+    --p4info)
+        P4INFO_FILE="$2"
+        shift 2
+        ;;
+    --tls)
+        TLS=true
+        shift
+        ;;
+    --tls=*)
+        TLS="${key#--tls=}"
+        shift
+        ;;
+    --ca-cert)
+        CA_CERT="$2"
+        shift 2
+        ;;
+    --client-cert)
+        CLIENT_CERT="$2"
+        shift 2
+        ;;
+    --client-key)
+        CLIENT_KEY="$2"
+        shift 2
+        ;;
+    --tls-server-name)
+        TLS_SERVER_NAME="$2"
+        shift 2
+        ;;
     --log-level)
         LOG_LEVEL="$2"
         shift 2
@@ -106,6 +230,46 @@ do
         LOG_DIR="$2"
         shift 2
         ;;
+    --random-seed)
+        RANDOM_SEED="$2"
+        shift 2
+        ;;
+    --parallel-max-concurrency)
+        PARALLEL_MAX_CONCURRENCY="$2"
+        shift 2
+        ;;
+    --parallel-barrier)
+        PARALLEL_BARRIER=YES
+        shift
+        ;;
+    --parallel-start-skew)
+        PARALLEL_START_SKEW="$2"
+        shift 2
+        ;;
+    --poll-interval)
+        POLL_INTERVAL="$2"
+        shift 2
+        ;;
+    --poll-deadline)
+        POLL_DEADLINE="$2"
+        shift 2
+        ;;
+    --gnmi-match-type)
+        GNMI_MATCH_TYPE="$2"
+        shift 2
+        ;;
+    --gnmi-sample-tolerance)
+        GNMI_SAMPLE_TOLERANCE="$2"
+        shift 2
+        ;;
+    --gnmi-schema)
+        GNMI_SCHEMA="$2"
+        shift 2
+        ;;
+    --gnmi-yang-dir)
+        GNMI_YANG_DIR="$2"
+        shift 2
+        ;;
     *)  # unknown option
         print_help
         exit 1
@@ -141,6 +305,14 @@ ENTRY_POINT="--entrypoint /root/$BINARY"
 
 TV_RUN_OPTIONS="--target $TG_FILE_MOUNT --portmap $PM_FILE_MOUNT --tv-dir $TV_DIR_MOUNT"
 
+# role config file given by a "# role-config: <filename>" directive, relative to the target file
+ROLE_CONFIG_FILE=$(sed -n 's/^[[:space:]]*# role-config:[[:space:]]*//p' $TG_FILE | head -n 1)
+if [ -n "$ROLE_CONFIG_FILE" ]; then
+    ROLE_CONFIG_FILE_ABS=$(dirname $TG_FILE_ABS)/$ROLE_CONFIG_FILE
+    ROLE_CONFIG_FILE_MOUNT=$DOCKER_TV_SETUP/$ROLE_CONFIG_FILE
+    DOCKER_RUN_OPTIONS="$DOCKER_RUN_OPTIONS --mount type=bind,source=$ROLE_CONFIG_FILE_ABS,target=$ROLE_CONFIG_FILE_MOUNT"
+fi
+
 if [ -n "$TEMPLATE_CONFIG_FILE" ]; then
     TEMPLATE_CONFIG_FILE_ABS=$(cd $(dirname $TEMPLATE_CONFIG_FILE); pwd)/$(basename $TEMPLATE_CONFIG_FILE)
     TEMPLATE_CONFIG_FILE_MOUNT=$DOCKER_TV_SETUP/$(basename $TEMPLATE_CONFIG_FILE)
@@ -152,6 +324,58 @@ if [ -n "$TV_NAME" ]; then
     TV_RUN_OPTIONS="$TV_RUN_OPTIONS --tv-name $TV_NAME"
 fi
 
+if [ -n "$P4INFO_FILE" ]; then
+    P4INFO_FILE_ABS=$(cd $(dirname $P4INFO_FILE); pwd)/$(basename $P4INFO_FILE)
+    P4INFO_FILE_MOUNT=$DOCKER_TV_SETUP/$(basename $P4INFO_FILE)
+    DOCKER_RUN_OPTIONS="$DOCKER_RUN_OPTIONS --mount type=bind,source=$P4INFO_FILE_ABS,target=$P4INFO_FILE_MOUNT"
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --p4info $P4INFO_FILE_MOUNT"
+fi
+
+# certificate and key files given by TLS directives of the target file, relative to the target file
+for DIRECTIVE in ca-cert client-cert client-key; do
+    DIRECTIVE_FILE=$(sed -n "s/^[[:space:]]*# $DIRECTIVE:[[:space:]]*//p" $TG_FILE | head -n 1)
+    if [ -z "$DIRECTIVE_FILE" ]; then
+        continue
+    fi
+    if [[ $DIRECTIVE_FILE == /* ]]; then
+        DIRECTIVE_FILE_ABS=$DIRECTIVE_FILE
+        DIRECTIVE_FILE_MOUNT=$DIRECTIVE_FILE
+    else
+        DIRECTIVE_FILE_ABS=$(dirname $TG_FILE_ABS)/$DIRECTIVE_FILE
+        DIRECTIVE_FILE_MOUNT=$DOCKER_TV_SETUP/$DIRECTIVE_FILE
+    fi
+    DOCKER_RUN_OPTIONS="$DOCKER_RUN_OPTIONS --mount type=bind,source=$DIRECTIVE_FILE_ABS,target=$DIRECTIVE_FILE_MOUNT"
+done
+
+if [ -n "$TLS" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --tls=$TLS"
+fi
+
+if [ -n "$CA_CERT" ]; then
+    CA_CERT_ABS=$(cd $(dirname $CA_CERT); pwd)/$(basename $CA_CERT)
+    CA_CERT_MOUNT=$DOCKER_TV_SETUP/$(basename $CA_CERT)
+    DOCKER_RUN_OPTIONS="$DOCKER_RUN_OPTIONS --mount type=bind,source=$CA_CERT_ABS,target=$CA_CERT_MOUNT"
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --ca-cert $CA_CERT_MOUNT"
+fi
+
+if [ -n "$CLIENT_CERT" ]; then
+    CLIENT_CERT_ABS=$(cd $(dirname $CLIENT_CERT); pwd)/$(basename $CLIENT_CERT)
+    CLIENT_CERT_MOUNT=$DOCKER_TV_SETUP/$(basename $CLIENT_CERT)
+    DOCKER_RUN_OPTIONS="$DOCKER_RUN_OPTIONS --mount type=bind,source=$CLIENT_CERT_ABS,target=$CLIENT_CERT_MOUNT"
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --client-cert $CLIENT_CERT_MOUNT"
+fi
+
+if [ -n "$CLIENT_KEY" ]; then
+    CLIENT_KEY_ABS=$(cd $(dirname $CLIENT_KEY); pwd)/$(basename $CLIENT_KEY)
+    CLIENT_KEY_MOUNT=$DOCKER_TV_SETUP/$(basename $CLIENT_KEY)
+    DOCKER_RUN_OPTIONS="$DOCKER_RUN_OPTIONS --mount type=bind,source=$CLIENT_KEY_ABS,target=$CLIENT_KEY_MOUNT"
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --client-key $CLIENT_KEY_MOUNT"
+fi
+
+if [ -n "$TLS_SERVER_NAME" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --tls-server-name $TLS_SERVER_NAME"
+fi
+
 if [ -n "$DP_MODE" ]; then
     TV_RUN_OPTIONS="$TV_RUN_OPTIONS --dp-mode $DP_MODE"
 fi
@@ -160,6 +384,55 @@ if [ -n "$MATCH_TYPE" ]; then
     TV_RUN_OPTIONS="$TV_RUN_OPTIONS --match-type $MATCH_TYPE"
 fi
 
+if [ -n "$PKT_OUT_PORT_METADATA" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --pkt-out-port-metadata $PKT_OUT_PORT_METADATA"
+fi
+
+if [ -n "$PKT_IN_PORT_METADATA" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --pkt-in-port-metadata $PKT_IN_PORT_METADATA"
+fi
+
+if [ -n "$PKT_OUT_PADDING" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --pkt-out-padding $PKT_OUT_PADDING"
+fi
+
+if [ -n "$PKT_IN_PADDING" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --pkt-in-padding $PKT_IN_PADDING"
+fi
+
+if [ -n "$IGNORE_METADATA_IDS" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --ignore-metadata-ids $IGNORE_METADATA_IDS"
+fi
+
+if [ -n "$PORT_MODE" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --port-mode $PORT_MODE"
+fi
+
+# link port mode brings host interfaces up or down with "ip link set"
+if [ "$PORT_MODE" == link ]; then
+    DOCKER_RUN_OPTIONS="$DOCKER_RUN_OPTIONS --cap-add NET_ADMIN"
+fi
+
+if [ -n "$ALARM_MODE" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --alarm-mode $ALARM_MODE"
+fi
+
+if [ -n "$ALARM_SET_LEAF" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --alarm-set-leaf $ALARM_SET_LEAF"
+fi
+
+if [ -n "$FAILURE_POLICY" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --failure-policy $FAILURE_POLICY"
+fi
+
+if [ -n "$P4RT_RECONNECT_TIMEOUT" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --p4rt-reconnect-timeout $P4RT_RECONNECT_TIMEOUT"
+fi
+
+if [ "$P4RT_CLEANUP" == YES ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --p4rt-cleanup"
+fi
+
 if [ -n "$LOG_LEVEL" ]; then
     TV_RUN_OPTIONS="$TV_RUN_OPTIONS --log-level $LOG_LEVEL"
 fi
@@ -168,6 +441,49 @@ if [ -n "$LOG_DIR" ]; then
     TV_RUN_OPTIONS="$TV_RUN_OPTIONS --log-dir $LOG_DIR"
 fi
 
+if [ -n "$RANDOM_SEED" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --random-seed $RANDOM_SEED"
+fi
+
+if [ -n "$PARALLEL_MAX_CONCURRENCY" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --parallel-max-concurrency $PARALLEL_MAX_CONCURRENCY"
+fi
+
+if [ "$PARALLEL_BARRIER" == YES ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --parallel-barrier"
+fi
+
+if [ -n "$PARALLEL_START_SKEW" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --parallel-start-skew $PARALLEL_START_SKEW"
+fi
+
+if [ -n "$POLL_INTERVAL" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --poll-interval $POLL_INTERVAL"
+fi
+
+if [ -n "$POLL_DEADLINE" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --poll-deadline $POLL_DEADLINE"
+fi
+
+if [ -n "$GNMI_MATCH_TYPE" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --gnmi-match-type $GNMI_MATCH_TYPE"
+fi
+
+if [ -n "$GNMI_SAMPLE_TOLERANCE" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --gnmi-sample-tolerance $GNMI_SAMPLE_TOLERANCE"
+fi
+
+if [ -n "$GNMI_SCHEMA" ]; then
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --gnmi-schema $GNMI_SCHEMA"
+fi
+
+if [ -n "$GNMI_YANG_DIR" ]; then
+    GNMI_YANG_DIR_ABS=$(cd $GNMI_YANG_DIR; pwd)
+    GNMI_YANG_DIR_MOUNT=$DOCKER_TV_SETUP/$(basename $GNMI_YANG_DIR)
+    DOCKER_RUN_OPTIONS="$DOCKER_RUN_OPTIONS --mount type=bind,source=$GNMI_YANG_DIR_ABS,target=$GNMI_YANG_DIR_MOUNT"
+    TV_RUN_OPTIONS="$TV_RUN_OPTIONS --gnmi-yang-dir $GNMI_YANG_DIR_MOUNT"
+fi
+
 CMD="docker run $DOCKER_RUN_OPTIONS $ENTRY_POINT -ti $IMAGE_NAME"
 
 CMD="$CMD $TV_RUN_OPTIONS"